@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/signal"
 	"runtime/debug"
+	"sync"
 	"syscall"
 	"time"
 
@@ -63,9 +64,44 @@ func main() {
 	// Инициализация зависимостей (repo -> service -> handler)
 	walletRepo := repo.NewWalletRepo(db)
 	q := queue.NewQueue(walletRepo, cfg.QueueBuffSize, cfg.QueueFlushPeriod)
+	q.SetEnqueueTimeout(cfg.QueueEnqueueTimeout)
+	q.SetWorkers(cfg.QueueWorkers)
 	go q.ProcessQueue(appCtx)
 
 	walletSrv := service.NewWalletService(q, walletRepo)
+	if cfg.FeeRulesFile != "" {
+		fees, err := service.LoadFeeSchedule(cfg.FeeRulesFile)
+		if err != nil {
+			logger.Error(fmt.Sprintf("fee rules: %v", err))
+			logger.Fatal(err)
+		}
+		walletSrv.SetFeeSchedule(fees)
+		logger.Info(fmt.Sprintf("fee rules loaded: %d", len(fees.Rules)))
+	}
+	walletSrv.SetQuoteTTL(cfg.FXQuoteTTL)
+	if cfg.FXRatesFile != "" {
+		rates, err := service.LoadExchangeRates(cfg.FXRatesFile)
+		if err != nil {
+			logger.Error(fmt.Sprintf("fx rates: %v", err))
+			logger.Fatal(err)
+		}
+		if _, err := walletSrv.SetExchangeRates(appCtx, rates); err != nil {
+			logger.Error(fmt.Sprintf("fx rates: %v", err))
+			logger.Fatal(err)
+		}
+		logger.Info(fmt.Sprintf("fx rates loaded: %d", len(rates)))
+	}
+	// Фоновые задачи останавливаются раньше очереди, чтобы не отправлять в
+	// неё операции во время Drain
+	bgCtx, bgCancel := context.WithCancel(appCtx)
+	defer bgCancel()
+	var bg sync.WaitGroup
+	// Фоновое освобождение истёкших холдов
+	bg.Go(func() { walletSrv.RunHoldExpiry(bgCtx, cfg.HoldExpiryPeriod) })
+	// Запуск отложенных и регулярных операций
+	bg.Go(func() { walletSrv.RunScheduler(bgCtx, cfg.SchedulerPeriod) })
+	// Снимки балансов для запросов баланса на момент времени
+	bg.Go(func() { walletSrv.RunBalanceSnapshots(bgCtx, cfg.SnapshotPeriod) })
 	walletHandler := handlers.NewWalletHandler(walletSrv, cfg.RequestTimeout)
 
 	// Rate limiting middleware
@@ -98,9 +134,8 @@ func main() {
 	logger.Info(fmt.Sprintf("shutdown signal received: %v", sig))
 	fmt.Printf("shutdown signal received: %v\n", sig)
 
-	appCancel()
-
-	// Завершение работы: закрытие сервера и БД
+	// Завершение работы: сначала сервер, чтобы обработчики дождались
+	// ответов очереди, затем фоновые задачи, очередь и только потом БД
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
@@ -108,6 +143,18 @@ func main() {
 		logger.Error(fmt.Sprintf("shutdown error: %v", err))
 	}
 
+	bgCancel()
+	bg.Wait()
+	logger.Info("background tasks stopped")
+
+	if err := q.Drain(ctx); err != nil {
+		logger.Error(fmt.Sprintf("queue drain error: %v", err))
+	} else {
+		logger.Info("queue drained")
+	}
+
+	appCancel()
+
 	sqlDB, err := db.DB()
 	if err == nil {
 		if err := sqlDB.Close(); err != nil {
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"test-psql/internal/database"
+	"test-psql/internal/models"
+	"test-psql/internal/repo"
+	"test-psql/pkg/env"
+	"test-psql/pkg/logger"
+)
+
+// Коды выхода: 0 — расхождений нет, 1 — ошибка, 2 — найдены расхождения
+// (даже если они исправлены с --fix)
+const (
+	exitError = 1
+	exitDrift = 2
+)
+
+type mismatchReport struct {
+	WalletID       string `json:"walletId"`
+	Currency       string `json:"currency"`
+	Balance        int64  `json:"balance"`
+	HistoryBalance int64  `json:"historyBalance"`
+	Drift          int64  `json:"drift"`
+	Fixed          bool   `json:"fixed"`
+}
+
+func main() {
+	format := flag.String("format", "json", "report format: json or csv")
+	fix := flag.Bool("fix", false, "write adjustment entries that bring history in line with balances")
+	configPath := flag.String("config", "config.env", "path to the config file")
+	verbose := flag.Bool("v", false, "enable verbose logging")
+	flag.Parse()
+
+	if *format != "json" && *format != "csv" {
+		fmt.Fprintf(os.Stderr, "unknown format %q: want json or csv\n", *format)
+		os.Exit(exitError)
+	}
+
+	if err := logger.Init(*verbose, "reconcile.log"); err != nil {
+		fmt.Fprintf(os.Stderr, "logger init: %v\n", err)
+		os.Exit(exitError)
+	}
+	code := run(*configPath, *format, *fix, os.Stdout)
+	_ = logger.Close()
+	os.Exit(code)
+}
+
+// fail reports an error both to the log and to stderr: without -v the log
+// is discarded.
+func fail(msg string) {
+	logger.Error(msg)
+	fmt.Fprintln(os.Stderr, msg)
+}
+
+func run(configPath, format string, fix bool, out io.Writer) int {
+	cfg, err := env.LoadFromFile(configPath)
+	if err != nil {
+		fail(fmt.Sprintf("config: %v", err))
+		return exitError
+	}
+
+	db, err := database.New(cfg, nil)
+	if err != nil {
+		fail(fmt.Sprintf("database: %v", err))
+		return exitError
+	}
+	defer func() {
+		sqlDB, _ := db.DB()
+		if sqlDB != nil {
+			sqlDB.Close()
+		}
+	}()
+
+	ctx := context.Background()
+	walletRepo := repo.NewWalletRepo(db)
+	mismatches, err := walletRepo.FindBalanceMismatches(ctx)
+	if err != nil {
+		fail(fmt.Sprintf("reconcile: %v", err))
+		return exitError
+	}
+	logger.Info(fmt.Sprintf("reconcile found %d mismatched wallets", len(mismatches)))
+
+	code := 0
+	if fix {
+		remaining := mismatches[:0]
+		for _, m := range mismatches {
+			fixed, err := walletRepo.FixBalanceMismatch(ctx, m.WalletID)
+			if err != nil {
+				fail(fmt.Sprintf("fix walletId=%s: %v", m.WalletID, err))
+				code = exitError
+				remaining = append(remaining, m)
+				continue
+			}
+			// Между поиском и исправлением баланс мог измениться,
+			// в отчёт попадает то, что было исправлено на самом деле.
+			// Расхождение, которого уже нет, не считается найденным
+			if fixed.Drift() == 0 {
+				logger.Info(fmt.Sprintf("reconcile walletId=%s no longer drifts", m.WalletID))
+				continue
+			}
+			remaining = append(remaining, fixed)
+		}
+		mismatches = remaining
+	}
+
+	if err := writeReport(out, format, mismatches); err != nil {
+		fail(fmt.Sprintf("write report: %v", err))
+		return exitError
+	}
+	if code == 0 && len(mismatches) > 0 {
+		code = exitDrift
+	}
+	return code
+}
+
+func writeReport(out io.Writer, format string, mismatches []models.BalanceMismatch) error {
+	reports := make([]mismatchReport, 0, len(mismatches))
+	for _, m := range mismatches {
+		reports = append(reports, mismatchReport{
+			WalletID:       m.WalletID.String(),
+			Currency:       m.Currency,
+			Balance:        m.Balance,
+			HistoryBalance: m.HistoryBalance,
+			Drift:          m.Drift(),
+			Fixed:          m.Fixed,
+		})
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(reports)
+	}
+
+	w := csv.NewWriter(out)
+	w.Write([]string{"wallet_id", "currency", "balance", "history_balance", "drift", "fixed"})
+	for _, r := range reports {
+		w.Write([]string{
+			r.WalletID,
+			r.Currency,
+			strconv.FormatInt(r.Balance, 10),
+			strconv.FormatInt(r.HistoryBalance, 10),
+			strconv.FormatInt(r.Drift, 10),
+			strconv.FormatBool(r.Fixed),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
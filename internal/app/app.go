@@ -9,7 +9,35 @@ import (
 
 type handler interface {
 	UpdateWalletBalance(w http.ResponseWriter, r *http.Request)
+	BatchUpdateWalletBalance(w http.ResponseWriter, r *http.Request)
 	GetWalletBalance(w http.ResponseWriter, r *http.Request)
+	GetWalletTransactions(w http.ResponseWriter, r *http.Request)
+	GetWalletStatement(w http.ResponseWriter, r *http.Request)
+	CreateTransfer(w http.ResponseWriter, r *http.Request)
+	CreateWallet(w http.ResponseWriter, r *http.Request)
+	ListWallets(w http.ResponseWriter, r *http.Request)
+	SetWalletMetadata(w http.ResponseWriter, r *http.Request)
+	FreezeWallet(w http.ResponseWriter, r *http.Request)
+	UnfreezeWallet(w http.ResponseWriter, r *http.Request)
+	CloseWallet(w http.ResponseWriter, r *http.Request)
+	SetCreditLimit(w http.ResponseWriter, r *http.Request)
+	GetWalletLimits(w http.ResponseWriter, r *http.Request)
+	SetWalletLimits(w http.ResponseWriter, r *http.Request)
+	CreateHold(w http.ResponseWriter, r *http.Request)
+	CaptureHold(w http.ResponseWriter, r *http.Request)
+	VoidHold(w http.ResponseWriter, r *http.Request)
+	ReverseOperation(w http.ResponseWriter, r *http.Request)
+	GetExchangeRates(w http.ResponseWriter, r *http.Request)
+	SetExchangeRates(w http.ResponseWriter, r *http.Request)
+	CreateQuote(w http.ResponseWriter, r *http.Request)
+	ExecuteQuote(w http.ResponseWriter, r *http.Request)
+	CreateSchedule(w http.ResponseWriter, r *http.Request)
+	GetSchedule(w http.ResponseWriter, r *http.Request)
+	ListSchedules(w http.ResponseWriter, r *http.Request)
+	PauseSchedule(w http.ResponseWriter, r *http.Request)
+	ResumeSchedule(w http.ResponseWriter, r *http.Request)
+	CancelSchedule(w http.ResponseWriter, r *http.Request)
+	GetSystemAccounts(w http.ResponseWriter, r *http.Request)
 }
 
 type Server struct {
@@ -29,8 +57,56 @@ func (s *Server) Router() http.Handler {
 
 	// POST api/v1/wallet
 	mux.HandleFunc("POST /api/v1/wallet", s.Handler.UpdateWalletBalance)
+	// POST api/v1/wallet/batch
+	mux.HandleFunc("POST /api/v1/wallet/batch", s.Handler.BatchUpdateWalletBalance)
 	// GET api/v1/wallets/{WALLET_UUID}
 	mux.HandleFunc("GET /api/v1/wallets/", s.Handler.GetWalletBalance)
+	// GET api/v1/wallets/{WALLET_UUID}/transactions?limit=&offset=
+	mux.HandleFunc("GET /api/v1/wallets/{id}/transactions", s.Handler.GetWalletTransactions)
+	// GET api/v1/wallets/{WALLET_UUID}/statement?from=&to=&format=csv|jsonl
+	mux.HandleFunc("GET /api/v1/wallets/{id}/statement", s.Handler.GetWalletStatement)
+	// POST api/v1/transfers
+	mux.HandleFunc("POST /api/v1/transfers", s.Handler.CreateTransfer)
+	// POST api/v1/wallets
+	mux.HandleFunc("POST /api/v1/wallets", s.Handler.CreateWallet)
+	// GET api/v1/wallets?owner_id=&label=&sort=&limit=&cursor=
+	mux.HandleFunc("GET /api/v1/wallets", s.Handler.ListWallets)
+	// PUT api/v1/wallets/{WALLET_UUID}/metadata
+	mux.HandleFunc("PUT /api/v1/wallets/{id}/metadata", s.Handler.SetWalletMetadata)
+	// POST api/v1/wallets/{WALLET_UUID}/freeze|unfreeze|close
+	mux.HandleFunc("POST /api/v1/wallets/{id}/freeze", s.Handler.FreezeWallet)
+	mux.HandleFunc("POST /api/v1/wallets/{id}/unfreeze", s.Handler.UnfreezeWallet)
+	mux.HandleFunc("POST /api/v1/wallets/{id}/close", s.Handler.CloseWallet)
+	// PUT api/v1/admin/wallets/{WALLET_UUID}/credit-limit
+	mux.HandleFunc("PUT /api/v1/admin/wallets/{id}/credit-limit", s.Handler.SetCreditLimit)
+	// GET|PUT api/v1/admin/wallets/{WALLET_UUID}/limits
+	mux.HandleFunc("GET /api/v1/admin/wallets/{id}/limits", s.Handler.GetWalletLimits)
+	mux.HandleFunc("PUT /api/v1/admin/wallets/{id}/limits", s.Handler.SetWalletLimits)
+
+	mux.HandleFunc("POST /api/v1/wallets/{id}/holds", s.Handler.CreateHold)
+	mux.HandleFunc("POST /api/v1/wallets/{id}/holds/{holdId}/capture", s.Handler.CaptureHold)
+	mux.HandleFunc("POST /api/v1/wallets/{id}/holds/{holdId}/void", s.Handler.VoidHold)
+	// POST api/v1/operations/{OPERATION_UUID}/reverse
+	mux.HandleFunc("POST /api/v1/operations/{id}/reverse", s.Handler.ReverseOperation)
+	// GET|PUT api/v1/admin/fx/rates
+	mux.HandleFunc("GET /api/v1/admin/fx/rates", s.Handler.GetExchangeRates)
+	mux.HandleFunc("PUT /api/v1/admin/fx/rates", s.Handler.SetExchangeRates)
+	// POST api/v1/fx/quotes
+	mux.HandleFunc("POST /api/v1/fx/quotes", s.Handler.CreateQuote)
+	// POST api/v1/fx/quotes/{QUOTE_UUID}/execute
+	mux.HandleFunc("POST /api/v1/fx/quotes/{id}/execute", s.Handler.ExecuteQuote)
+	// POST api/v1/schedules
+	mux.HandleFunc("POST /api/v1/schedules", s.Handler.CreateSchedule)
+	// GET api/v1/schedules/{SCHEDULE_UUID}
+	mux.HandleFunc("GET /api/v1/schedules/{id}", s.Handler.GetSchedule)
+	// GET api/v1/wallets/{WALLET_UUID}/schedules?limit=&offset=
+	mux.HandleFunc("GET /api/v1/wallets/{id}/schedules", s.Handler.ListSchedules)
+	// POST api/v1/schedules/{SCHEDULE_UUID}/pause|resume|cancel
+	mux.HandleFunc("POST /api/v1/schedules/{id}/pause", s.Handler.PauseSchedule)
+	mux.HandleFunc("POST /api/v1/schedules/{id}/resume", s.Handler.ResumeSchedule)
+	mux.HandleFunc("POST /api/v1/schedules/{id}/cancel", s.Handler.CancelSchedule)
+	// GET api/v1/system-accounts
+	mux.HandleFunc("GET /api/v1/system-accounts", s.Handler.GetSystemAccounts)
 
 	h := middleware.RecoverMiddleware(mux)
 	if s.Limiter != nil {
@@ -3,6 +3,7 @@ package database
 
 import (
 	"fmt"
+	"time"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -17,6 +18,10 @@ func New(e *env.Env, gormConfig *gorm.Config) (*gorm.DB, error) {
 	if gormConfig == nil {
 		gormConfig = &gorm.Config{}
 	}
+	// created_at и updated_at пишутся в UTC, как и значения по умолчанию в БД
+	if gormConfig.NowFunc == nil {
+		gormConfig.NowFunc = func() time.Time { return time.Now().UTC() }
+	}
 	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s TimeZone=%s",
 		e.DBHost, e.DBPort, e.DBUser, e.DBPassword, e.DBName, e.DBSSLMode, e.DBTimezone)
 
@@ -0,0 +1,11 @@
+package dto
+
+type SystemAccountResponse struct {
+	Account  string `json:"account"`
+	Currency string `json:"currency"`
+	Balance  int64  `json:"balance"`
+}
+
+type GetSystemAccountsResponse struct {
+	Accounts []SystemAccountResponse `json:"accounts"`
+}
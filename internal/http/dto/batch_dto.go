@@ -0,0 +1,44 @@
+package dto
+
+import "fmt"
+
+const (
+	BatchModeAtomic     = "atomic"
+	BatchModeBestEffort = "best_effort"
+	MaxBatchSize        = 1000
+)
+
+// BatchUpdateRequest carries many balance operations in one call. In atomic
+// mode they all commit or none does; in best_effort mode each one succeeds or
+// fails on its own.
+type BatchUpdateRequest struct {
+	Mode       string                       `json:"mode"`
+	Operations []UpdateWalletBalanceRequest `json:"operations"`
+}
+
+// Validate checks the envelope only; the operations are validated one by one
+// so that best_effort mode can report them separately.
+func (r *BatchUpdateRequest) Validate() error {
+	if r.Mode != BatchModeAtomic && r.Mode != BatchModeBestEffort {
+		return fmt.Errorf("mode must be %s or %s", BatchModeAtomic, BatchModeBestEffort)
+	}
+	if len(r.Operations) == 0 {
+		return fmt.Errorf("operations must not be empty")
+	}
+	if len(r.Operations) > MaxBatchSize {
+		return fmt.Errorf("at most %d operations per batch", MaxBatchSize)
+	}
+	return nil
+}
+
+type BatchItemResult struct {
+	Index    int    `json:"index"`
+	WalletID string `json:"walletId"`
+	Status   int    `json:"status"`
+	Error    string `json:"error,omitempty"`
+}
+
+type BatchUpdateResponse struct {
+	Mode    string            `json:"mode"`
+	Results []BatchItemResult `json:"results"`
+}
@@ -0,0 +1,95 @@
+package dto
+
+import (
+	"fmt"
+	"time"
+)
+
+// MaxExchangeRates caps how many rates one request may set.
+const MaxExchangeRates = 1000
+
+// ExchangeRate is one directional rate; Rate is a decimal string such as
+// "92.5", so that it is never rounded through a float.
+type ExchangeRate struct {
+	From      string     `json:"from"`
+	To        string     `json:"to"`
+	Rate      string     `json:"rate"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
+
+type SetExchangeRatesRequest struct {
+	Rates []ExchangeRate `json:"rates"`
+}
+
+func (r *SetExchangeRatesRequest) Validate() error {
+	if len(r.Rates) == 0 {
+		return fmt.Errorf("rates must not be empty")
+	}
+	if len(r.Rates) > MaxExchangeRates {
+		return fmt.Errorf("at most %d rates are allowed", MaxExchangeRates)
+	}
+	seen := make(map[string]bool, len(r.Rates))
+	for i, rate := range r.Rates {
+		if rate.From == "" || rate.To == "" {
+			return fmt.Errorf("rates[%d]: from and to are required", i)
+		}
+		if err := validateCurrencyCode(rate.From); err != nil {
+			return fmt.Errorf("rates[%d]: %w", i, err)
+		}
+		if err := validateCurrencyCode(rate.To); err != nil {
+			return fmt.Errorf("rates[%d]: %w", i, err)
+		}
+		if rate.From == rate.To {
+			return fmt.Errorf("rates[%d]: from and to must differ", i)
+		}
+		if rate.Rate == "" {
+			return fmt.Errorf("rates[%d]: rate is required", i)
+		}
+		pair := rate.From + "/" + rate.To
+		if seen[pair] {
+			return fmt.Errorf("rates[%d]: %s is listed twice", i, pair)
+		}
+		seen[pair] = true
+	}
+	return nil
+}
+
+type ExchangeRatesResponse struct {
+	Rates []ExchangeRate `json:"rates"`
+}
+
+type CreateQuoteRequest struct {
+	FromWalletID string `json:"fromWalletId"`
+	ToWalletID   string `json:"toWalletId"`
+	Amount       int64  `json:"amount"`
+}
+
+func (r *CreateQuoteRequest) Validate() error {
+	if r.FromWalletID == "" {
+		return fmt.Errorf("fromWalletId is required")
+	}
+	if r.ToWalletID == "" {
+		return fmt.Errorf("toWalletId is required")
+	}
+	if r.FromWalletID == r.ToWalletID {
+		return fmt.Errorf("fromWalletId and toWalletId must differ")
+	}
+	if r.Amount <= 0 {
+		return fmt.Errorf("amount must be positive")
+	}
+	return nil
+}
+
+// QuoteResponse describes a quote; ExecutedAt is set once it is executed.
+type QuoteResponse struct {
+	QuoteID         string     `json:"quoteId"`
+	FromWalletID    string     `json:"fromWalletId"`
+	ToWalletID      string     `json:"toWalletId"`
+	FromCurrency    string     `json:"fromCurrency"`
+	ToCurrency      string     `json:"toCurrency"`
+	Amount          int64      `json:"amount"`
+	ConvertedAmount int64      `json:"convertedAmount"`
+	Rate            string     `json:"rate"`
+	ExpiresAt       time.Time  `json:"expiresAt"`
+	ExecutedAt      *time.Time `json:"executedAt,omitempty"`
+}
@@ -0,0 +1,59 @@
+package dto
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	DefaultHoldTTLSeconds = 7 * 24 * 60 * 60
+	MaxHoldTTLSeconds     = 30 * 24 * 60 * 60
+)
+
+type CreateHoldRequest struct {
+	Amount     int64  `json:"amount"`
+	Currency   string `json:"currency,omitempty"`
+	TTLSeconds int64  `json:"ttlSeconds,omitempty"`
+}
+
+func (r *CreateHoldRequest) Validate() error {
+	if r.Amount <= 0 {
+		return fmt.Errorf("amount must be positive")
+	}
+	if r.TTLSeconds < 0 || r.TTLSeconds > MaxHoldTTLSeconds {
+		return fmt.Errorf("ttlSeconds must be between 1 and %d", MaxHoldTTLSeconds)
+	}
+	return validateCurrencyCode(r.Currency)
+}
+
+// TTL returns the requested hold lifetime, DefaultHoldTTLSeconds if omitted.
+func (r *CreateHoldRequest) TTL() time.Duration {
+	if r.TTLSeconds == 0 {
+		return DefaultHoldTTLSeconds * time.Second
+	}
+	return time.Duration(r.TTLSeconds) * time.Second
+}
+
+// CaptureHoldRequest captures Amount of the hold; an omitted amount captures
+// all of it.
+type CaptureHoldRequest struct {
+	Amount int64 `json:"amount,omitempty"`
+}
+
+func (r *CaptureHoldRequest) Validate() error {
+	if r.Amount < 0 {
+		return fmt.Errorf("amount must not be negative")
+	}
+	return nil
+}
+
+type HoldResponse struct {
+	HoldID         string    `json:"holdId"`
+	WalletID       string    `json:"walletId"`
+	Amount         int64     `json:"amount"`
+	CapturedAmount int64     `json:"capturedAmount"`
+	Status         string    `json:"status"`
+	ExpiresAt      time.Time `json:"expiresAt"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
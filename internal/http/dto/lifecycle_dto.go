@@ -0,0 +1,54 @@
+package dto
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CreateWalletRequest struct {
+	WalletID string `json:"walletId"`
+	Currency string `json:"currency"`
+	WalletMetadata
+}
+
+func (r *CreateWalletRequest) Validate() error {
+	if r.WalletID != "" {
+		if _, err := uuid.Parse(r.WalletID); err != nil {
+			return fmt.Errorf("walletId must be a UUID")
+		}
+	}
+	if err := r.WalletMetadata.Validate(); err != nil {
+		return err
+	}
+	return validateCurrencyCode(r.Currency)
+}
+
+type SetCreditLimitRequest struct {
+	CreditLimit *int64 `json:"creditLimit"`
+}
+
+func (r *SetCreditLimitRequest) Validate() error {
+	if r.CreditLimit == nil {
+		return fmt.Errorf("creditLimit is required")
+	}
+	if *r.CreditLimit < 0 {
+		return fmt.Errorf("creditLimit must not be negative")
+	}
+	return nil
+}
+
+type WalletResponse struct {
+	WalletID         string    `json:"walletId"`
+	Balance          int64     `json:"balance"`
+	CreditLimit      int64     `json:"creditLimit"`
+	Currency         string    `json:"currency"`
+	CurrencyExponent int       `json:"currencyExponent"`
+	Status           string    `json:"status"`
+	OwnerID          string    `json:"ownerId"`
+	Name             string    `json:"name"`
+	Labels           []string  `json:"labels"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,32 @@
+package dto
+
+import "fmt"
+
+// WalletLimitsRequest replaces all limit rules of a wallet; an omitted or
+// null rule is removed.
+type WalletLimitsRequest struct {
+	MaxWithdrawalAmount24h *int64 `json:"maxWithdrawalAmount24h"`
+	MaxWithdrawalsPerHour  *int64 `json:"maxWithdrawalsPerHour"`
+	MaxBalance             *int64 `json:"maxBalance"`
+}
+
+func (r *WalletLimitsRequest) Validate() error {
+	rules := map[string]*int64{
+		"maxWithdrawalAmount24h": r.MaxWithdrawalAmount24h,
+		"maxWithdrawalsPerHour":  r.MaxWithdrawalsPerHour,
+		"maxBalance":             r.MaxBalance,
+	}
+	for name, v := range rules {
+		if v != nil && *v < 0 {
+			return fmt.Errorf("%s must not be negative", name)
+		}
+	}
+	return nil
+}
+
+type WalletLimitsResponse struct {
+	WalletID               string `json:"walletId"`
+	MaxWithdrawalAmount24h *int64 `json:"maxWithdrawalAmount24h"`
+	MaxWithdrawalsPerHour  *int64 `json:"maxWithdrawalsPerHour"`
+	MaxBalance             *int64 `json:"maxBalance"`
+}
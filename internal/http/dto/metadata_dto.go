@@ -0,0 +1,133 @@
+package dto
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxMetadataLength = 255
+	maxLabels         = 20
+	maxLabelLength    = 64
+
+	DefaultWalletsLimit = 50
+	MaxWalletsLimit     = 500
+	DefaultWalletsSort  = "-created_at"
+)
+
+// WalletMetadata are the descriptive wallet fields accepted on creation and
+// by PUT /api/v1/wallets/{id}/metadata.
+type WalletMetadata struct {
+	OwnerID string   `json:"ownerId,omitempty"`
+	Name    string   `json:"name,omitempty"`
+	Labels  []string `json:"labels,omitempty"`
+}
+
+func (m *WalletMetadata) Validate() error {
+	if len(m.OwnerID) > maxMetadataLength {
+		return fmt.Errorf("ownerId must be at most %d characters", maxMetadataLength)
+	}
+	if len(m.Name) > maxMetadataLength {
+		return fmt.Errorf("name must be at most %d characters", maxMetadataLength)
+	}
+	if len(m.Labels) > maxLabels {
+		return fmt.Errorf("at most %d labels per wallet", maxLabels)
+	}
+	return validateLabels(m.Labels)
+}
+
+func validateLabels(labels []string) error {
+	for _, label := range labels {
+		if label == "" || len(label) > maxLabelLength {
+			return fmt.Errorf("labels must be 1 to %d characters long", maxLabelLength)
+		}
+	}
+	return nil
+}
+
+// ListWalletsRequest selects a page of wallets. Sort is a column name and
+// Desc its direction; Cursor is nil on the first page.
+type ListWalletsRequest struct {
+	OwnerID string
+	Labels  []string
+	Sort    string
+	Desc    bool
+	Limit   int
+	Cursor  *WalletsCursor
+}
+
+// Parse reads the "sort" query parameter (created_at or balance, prefixed
+// with "-" for descending order, -created_at by default) and the optional
+// "cursor" returned with the previous page.
+func (r *ListWalletsRequest) Parse(sort, cursor string) error {
+	if sort == "" {
+		sort = DefaultWalletsSort
+	}
+	r.Sort, r.Desc = strings.TrimPrefix(sort, "-"), strings.HasPrefix(sort, "-")
+	if cursor == "" {
+		return nil
+	}
+	c, err := DecodeWalletsCursor(cursor)
+	if err != nil {
+		return err
+	}
+	if c.Sort != r.SortParam() {
+		return fmt.Errorf("cursor belongs to another sort order")
+	}
+	r.Cursor = &c
+	return nil
+}
+
+// SortParam renders Sort and Desc back as the "sort" query parameter.
+func (r *ListWalletsRequest) SortParam() string {
+	if r.Desc {
+		return "-" + r.Sort
+	}
+	return r.Sort
+}
+
+func (r *ListWalletsRequest) Validate() error {
+	if r.Sort != "created_at" && r.Sort != "balance" {
+		return fmt.Errorf("sort must be created_at or balance, optionally prefixed with -")
+	}
+	if r.Limit <= 0 || r.Limit > MaxWalletsLimit {
+		return fmt.Errorf("limit must be between 1 and %d", MaxWalletsLimit)
+	}
+	if len(r.OwnerID) > maxMetadataLength {
+		return fmt.Errorf("owner_id must be at most %d characters", maxMetadataLength)
+	}
+	return validateLabels(r.Labels)
+}
+
+// WalletsCursor is the position after the last wallet of a page. Clients get
+// it as an opaque string.
+type WalletsCursor struct {
+	Sort      string    `json:"s"`
+	Balance   int64     `json:"b,omitempty"`
+	CreatedAt time.Time `json:"c"`
+	ID        uuid.UUID `json:"i"`
+}
+
+func (c WalletsCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeWalletsCursor(raw string) (WalletsCursor, error) {
+	var c WalletsCursor
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil || json.Unmarshal(data, &c) != nil || c.ID == uuid.Nil {
+		return WalletsCursor{}, fmt.Errorf("invalid cursor")
+	}
+	return c, nil
+}
+
+type ListWalletsResponse struct {
+	Wallets    []WalletResponse `json:"wallets"`
+	NextCursor string           `json:"nextCursor,omitempty"`
+}
@@ -0,0 +1,29 @@
+package dto
+
+import (
+	"fmt"
+	"time"
+)
+
+// ReverseOperationRequest reverses Amount of the operation; an omitted amount
+// reverses everything not reversed yet.
+type ReverseOperationRequest struct {
+	Amount int64 `json:"amount,omitempty"`
+}
+
+func (r *ReverseOperationRequest) Validate() error {
+	if r.Amount < 0 {
+		return fmt.Errorf("amount must not be negative")
+	}
+	return nil
+}
+
+type ReversalResponse struct {
+	ReversalID   string    `json:"reversalId"`
+	OperationID  string    `json:"operationId"`
+	WalletID     string    `json:"walletId"`
+	Type         string    `json:"type"`
+	Amount       int64     `json:"amount"`
+	BalanceAfter int64     `json:"balanceAfter"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
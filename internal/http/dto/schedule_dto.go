@@ -0,0 +1,102 @@
+package dto
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultSchedulesLimit = 50
+	MaxSchedulesLimit     = 500
+)
+
+// CreateScheduleRequest schedules a DEPOSIT into WalletID or a TRANSFER from
+// WalletID to ToWalletID, either once at RunAt or on the Cron spec.
+type CreateScheduleRequest struct {
+	WalletID      string     `json:"walletId"`
+	ToWalletID    string     `json:"toWalletId,omitempty"`
+	OperationType string     `json:"operationType"`
+	Amount        int64      `json:"amount"`
+	Currency      string     `json:"currency,omitempty"`
+	RunAt         *time.Time `json:"runAt,omitempty"`
+	Cron          string     `json:"cron,omitempty"`
+}
+
+func (r *CreateScheduleRequest) Validate() error {
+	if _, err := uuid.Parse(r.WalletID); err != nil {
+		return fmt.Errorf("walletId must be a UUID")
+	}
+	switch r.OperationType {
+	case "DEPOSIT":
+		if r.ToWalletID != "" {
+			return fmt.Errorf("toWalletId is only allowed for TRANSFER")
+		}
+	case "TRANSFER":
+		if _, err := uuid.Parse(r.ToWalletID); err != nil {
+			return fmt.Errorf("toWalletId must be a UUID")
+		}
+		if r.ToWalletID == r.WalletID {
+			return fmt.Errorf("walletId and toWalletId must differ")
+		}
+	default:
+		return fmt.Errorf("operationType must be DEPOSIT or TRANSFER")
+	}
+	if r.Amount <= 0 {
+		return fmt.Errorf("amount must be positive")
+	}
+	if (r.RunAt == nil) == (r.Cron == "") {
+		return fmt.Errorf("exactly one of runAt and cron is required")
+	}
+	if r.RunAt != nil && !r.RunAt.After(time.Now()) {
+		return fmt.Errorf("runAt must be in the future")
+	}
+	if len(r.Cron) > 255 {
+		return fmt.Errorf("cron must be at most 255 characters")
+	}
+	return validateCurrencyCode(r.Currency)
+}
+
+type ListSchedulesRequest struct {
+	WalletID string
+	Limit    int
+	Offset   int
+}
+
+func (r *ListSchedulesRequest) Validate() error {
+	if r.WalletID == "" {
+		return fmt.Errorf("walletId is required")
+	}
+	if r.Limit <= 0 || r.Limit > MaxSchedulesLimit {
+		return fmt.Errorf("limit must be between 1 and %d", MaxSchedulesLimit)
+	}
+	if r.Offset < 0 {
+		return fmt.Errorf("offset must not be negative")
+	}
+	return nil
+}
+
+type ScheduleResponse struct {
+	ScheduleID      string     `json:"scheduleId"`
+	WalletID        string     `json:"walletId"`
+	ToWalletID      string     `json:"toWalletId,omitempty"`
+	OperationType   string     `json:"operationType"`
+	Amount          int64      `json:"amount"`
+	Currency        string     `json:"currency,omitempty"`
+	Cron            string     `json:"cron,omitempty"`
+	Status          string     `json:"status"`
+	NextRunAt       *time.Time `json:"nextRunAt,omitempty"`
+	RunCount        int64      `json:"runCount"`
+	LastRunAt       *time.Time `json:"lastRunAt,omitempty"`
+	LastOperationID string     `json:"lastOperationId,omitempty"`
+	LastError       string     `json:"lastError,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+}
+
+type ListSchedulesResponse struct {
+	WalletID  string             `json:"walletId"`
+	Schedules []ScheduleResponse `json:"schedules"`
+	Limit     int                `json:"limit"`
+	Offset    int                `json:"offset"`
+}
@@ -0,0 +1,74 @@
+package dto
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	StatementFormatCSV   = "csv"
+	StatementFormatJSONL = "jsonl"
+)
+
+// Record kinds of a statement line.
+const (
+	StatementOpening   = "OPENING_BALANCE"
+	StatementOperation = "OPERATION"
+	StatementClosing   = "CLOSING_BALANCE"
+)
+
+// StatementCSVHeader names the columns of a CSV statement.
+var StatementCSVHeader = []string{"record", "date", "operationId", "type", "amount", "balance", "currency"}
+
+// GetWalletStatementRequest covers the ledger rows created in [From, To).
+type GetWalletStatementRequest struct {
+	WalletID string
+	From     time.Time
+	To       time.Time
+	Format   string
+}
+
+// Parse reads the "from" and "to" RFC 3339 query parameters and the optional
+// "format", csv by default.
+func (r *GetWalletStatementRequest) Parse(from, to, format string) error {
+	var err error
+	if r.From, err = time.Parse(time.RFC3339, from); err != nil {
+		return fmt.Errorf("from must be an RFC 3339 timestamp")
+	}
+	if r.To, err = time.Parse(time.RFC3339, to); err != nil {
+		return fmt.Errorf("to must be an RFC 3339 timestamp")
+	}
+	r.Format = format
+	if r.Format == "" {
+		r.Format = StatementFormatCSV
+	}
+	return nil
+}
+
+func (r *GetWalletStatementRequest) Validate() error {
+	if r.WalletID == "" {
+		return fmt.Errorf("walletId is required")
+	}
+	if !r.From.Before(r.To) {
+		return fmt.Errorf("from must be before to")
+	}
+	if r.Format != StatementFormatCSV && r.Format != StatementFormatJSONL {
+		return fmt.Errorf("format must be %s or %s", StatementFormatCSV, StatementFormatJSONL)
+	}
+	return nil
+}
+
+// StatementLine is one line of a JSON Lines statement. Amount is signed:
+// negative for debits. WalletID and CurrencyExponent are set on the opening
+// line only.
+type StatementLine struct {
+	Record           string    `json:"record"`
+	Date             time.Time `json:"date"`
+	WalletID         string    `json:"walletId,omitempty"`
+	OperationID      string    `json:"operationId,omitempty"`
+	Type             string    `json:"type,omitempty"`
+	Amount           int64     `json:"amount,omitempty"`
+	Balance          int64     `json:"balance"`
+	Currency         string    `json:"currency"`
+	CurrencyExponent *int      `json:"currencyExponent,omitempty"`
+}
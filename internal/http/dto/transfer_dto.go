@@ -0,0 +1,34 @@
+package dto
+
+import "fmt"
+
+type CreateTransferRequest struct {
+	FromWalletID string `json:"fromWalletId"`
+	ToWalletID   string `json:"toWalletId"`
+	Amount       int64  `json:"amount"`
+	Currency     string `json:"currency,omitempty"`
+}
+
+func (r *CreateTransferRequest) Validate() error {
+	if r.FromWalletID == "" {
+		return fmt.Errorf("fromWalletId is required")
+	}
+	if r.ToWalletID == "" {
+		return fmt.Errorf("toWalletId is required")
+	}
+	if r.FromWalletID == r.ToWalletID {
+		return fmt.Errorf("fromWalletId and toWalletId must differ")
+	}
+	if r.Amount <= 0 {
+		return fmt.Errorf("amount must be positive")
+	}
+	return validateCurrencyCode(r.Currency)
+}
+
+type CreateTransferResponse struct {
+	TransferID   string `json:"transferId"`
+	FromWalletID string `json:"fromWalletId"`
+	ToWalletID   string `json:"toWalletId"`
+	Amount       int64  `json:"amount"`
+	Currency     string `json:"currency,omitempty"`
+}
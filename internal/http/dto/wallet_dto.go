@@ -1,7 +1,12 @@
 // Package wallet dto schema
 package dto
 
-import "fmt"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
 
 type OperationType string
 
@@ -14,6 +19,7 @@ type UpdateWalletBalanceRequest struct {
 	WalletID      string        `json:"walletId"`
 	OperationType OperationType `json:"operationType"`
 	Amount        int64         `json:"amount"`
+	Currency      string        `json:"currency,omitempty"`
 }
 
 func (r *UpdateWalletBalanceRequest) Validate() error {
@@ -26,21 +32,82 @@ func (r *UpdateWalletBalanceRequest) Validate() error {
 	if r.Amount <= 0 {
 		return fmt.Errorf("amount must be positive")
 	}
+	return validateCurrencyCode(r.Currency)
+}
+
+// Fingerprint identifies the request payload so that a reused
+// Idempotency-Key with a different payload can be detected.
+func (r *UpdateWalletBalanceRequest) Fingerprint() string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%d|%s", r.WalletID, r.OperationType, r.Amount, r.Currency)))
+	return hex.EncodeToString(sum[:])
+}
+
+const (
+	IdempotencyKeyHeader    = "Idempotency-Key"
+	maxIdempotencyKeyLength = 255
+)
+
+func ValidateIdempotencyKey(key string) error {
+	if len(key) > maxIdempotencyKeyLength {
+		return fmt.Errorf("%s must be at most %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLength)
+	}
 	return nil
 }
 
+// UpdateWalletBalanceResponse reports the balance after the operation. Fee is
+// the total charged on top of the amount and Fees its breakdown by rule.
 type UpdateWalletBalanceResponse struct {
-	WalletID string `json:"walletId"`
-	Balance  int64  `json:"balance"`
+	WalletID         string        `json:"walletId"`
+	Balance          int64         `json:"balance"`
+	Available        int64         `json:"available"`
+	Currency         string        `json:"currency"`
+	CurrencyExponent int           `json:"currencyExponent"`
+	Fee              int64         `json:"fee,omitempty"`
+	Fees             []FeeResponse `json:"fees,omitempty"`
+}
+
+type FeeResponse struct {
+	Rule   string `json:"rule"`
+	Amount int64  `json:"amount"`
 }
 
 type GetWalletBalanceResponse struct {
-	WalletID string `json:"walletId"`
-	Balance  int64  `json:"balance"`
+	WalletID         string `json:"walletId"`
+	Balance          int64  `json:"balance"`
+	Available        int64  `json:"available"`
+	CreditLimit      int64  `json:"creditLimit"`
+	RemainingCredit  int64  `json:"remainingCredit"`
+	Currency         string `json:"currency"`
+	CurrencyExponent int    `json:"currencyExponent"`
+}
+
+// GetWalletBalanceAtResponse reports a historical balance. Holds are not
+// tracked over time, so there is no available amount.
+type GetWalletBalanceAtResponse struct {
+	WalletID         string    `json:"walletId"`
+	Balance          int64     `json:"balance"`
+	Currency         string    `json:"currency"`
+	CurrencyExponent int       `json:"currencyExponent"`
+	At               time.Time `json:"at"`
 }
 
 type GetWalletBalanceRequest struct {
 	WalletID string
+	// At asks for the balance as of this moment; zero means the current one.
+	At time.Time
+}
+
+// ParseAt reads the optional RFC 3339 "at" query parameter.
+func (r *GetWalletBalanceRequest) ParseAt(raw string) error {
+	if raw == "" {
+		return nil
+	}
+	at, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return fmt.Errorf("at must be an RFC 3339 timestamp")
+	}
+	r.At = at
+	return nil
 }
 
 func (r *GetWalletBalanceRequest) Validate() error {
@@ -49,3 +116,66 @@ func (r *GetWalletBalanceRequest) Validate() error {
 	}
 	return nil
 }
+
+const (
+	DefaultTransactionsLimit = 50
+	MaxTransactionsLimit     = 500
+)
+
+type GetWalletTransactionsRequest struct {
+	WalletID string
+	Limit    int
+	Offset   int
+}
+
+func (r *GetWalletTransactionsRequest) Validate() error {
+	if r.WalletID == "" {
+		return fmt.Errorf("walletId is required")
+	}
+	if r.Limit <= 0 || r.Limit > MaxTransactionsLimit {
+		return fmt.Errorf("limit must be between 1 and %d", MaxTransactionsLimit)
+	}
+	if r.Offset < 0 {
+		return fmt.Errorf("offset must not be negative")
+	}
+	return nil
+}
+
+type TransactionResponse struct {
+	ID           string    `json:"id"`
+	Type         string    `json:"type"`
+	Amount       int64     `json:"amount"`
+	BalanceAfter int64     `json:"balanceAfter"`
+	CreatedAt    time.Time `json:"createdAt"`
+
+	TransferID           string `json:"transferId,omitempty"`
+	CounterpartyWalletID string `json:"counterpartyWalletId,omitempty"`
+	HoldID               string `json:"holdId,omitempty"`
+	ReversalOf           string `json:"reversalOf,omitempty"`
+	FeeFor               string `json:"feeFor,omitempty"`
+	FXRate               string `json:"fxRate,omitempty"`
+}
+
+type GetWalletTransactionsResponse struct {
+	WalletID     string                `json:"walletId"`
+	Transactions []TransactionResponse `json:"transactions"`
+	Limit        int                   `json:"limit"`
+	Offset       int                   `json:"offset"`
+}
+
+// validateCurrencyCode checks the shape of an optional ISO 4217 code; whether
+// the code is known is decided by the service.
+func validateCurrencyCode(code string) error {
+	if code == "" {
+		return nil
+	}
+	if len(code) != 3 {
+		return fmt.Errorf("currency must be a 3-letter ISO 4217 code")
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return fmt.Errorf("currency must be a 3-letter ISO 4217 code")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"test-psql/internal/http/dto"
+	"test-psql/pkg/logger"
+)
+
+func (h *WalletHandler) GetSystemAccounts(w http.ResponseWriter, r *http.Request) {
+	logger.Info("GET /api/v1/system-accounts")
+	if r.Method != http.MethodGet {
+		logger.Error(fmt.Sprintf("method not allowed: %s", r.Method))
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	balances, err := h.service.GetSystemAccounts(ctx)
+	if err != nil {
+		writeServiceError(ctx, w, err, "get system accounts")
+		return
+	}
+
+	response := dto.GetSystemAccountsResponse{
+		Accounts: make([]dto.SystemAccountResponse, 0, len(balances)),
+	}
+	for _, b := range balances {
+		response.Accounts = append(response.Accounts, dto.SystemAccountResponse{
+			Account:  b.Account,
+			Currency: b.Currency,
+			Balance:  b.Balance,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+	logger.Info(fmt.Sprintf("system accounts retrieved: count=%d", len(balances)))
+}
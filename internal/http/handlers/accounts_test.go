@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"test-psql/internal/http/dto"
+	"test-psql/internal/models"
+)
+
+func TestWalletHandler_GetSystemAccounts(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		svc := &mockWalletService{systemAccounts: []models.SystemAccountBalance{
+			{Account: models.SystemAccountExternalCash, Currency: "RUB", Balance: -1500},
+		}}
+		h := NewWalletHandler(svc, 30*time.Second)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/system-accounts", nil)
+		rec := httptest.NewRecorder()
+
+		h.GetSystemAccounts(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("got status %d, want 200", rec.Code)
+		}
+		var res dto.GetSystemAccountsResponse
+		if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		want := dto.SystemAccountResponse{Account: "external_cash", Currency: "RUB", Balance: -1500}
+		if len(res.Accounts) != 1 || res.Accounts[0] != want {
+			t.Errorf("unexpected response: %+v", res)
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		h := NewWalletHandler(&mockWalletService{}, 30*time.Second)
+		rec := httptest.NewRecorder()
+
+		h.GetSystemAccounts(rec, httptest.NewRequest(http.MethodGet, "/api/v1/system-accounts", nil))
+
+		if body := rec.Body.String(); body != "{\"accounts\":[]}\n" {
+			t.Errorf("got body %q", body)
+		}
+	})
+
+	t.Run("service error", func(t *testing.T) {
+		h := NewWalletHandler(&mockWalletService{systemErr: errors.New("db error")}, 30*time.Second)
+		rec := httptest.NewRecorder()
+
+		h.GetSystemAccounts(rec, httptest.NewRequest(http.MethodGet, "/api/v1/system-accounts", nil))
+
+		if rec.Code != http.StatusInternalServerError {
+			t.Errorf("got status %d, want 500", rec.Code)
+		}
+	})
+}
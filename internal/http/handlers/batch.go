@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"test-psql/internal/http/dto"
+	"test-psql/internal/models"
+	"test-psql/pkg/logger"
+)
+
+func (h *WalletHandler) BatchUpdateWalletBalance(w http.ResponseWriter, r *http.Request) {
+	logger.Info("POST /api/v1/wallet/batch")
+	if r.Method != http.MethodPost {
+		logger.Error(fmt.Sprintf("method not allowed: %s", r.Method))
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	var req dto.BatchUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error(fmt.Sprintf("invalid request body: %v", err))
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		logger.Error(fmt.Sprintf("validation error: %v", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := dto.BatchUpdateResponse{
+		Mode:    req.Mode,
+		Results: make([]dto.BatchItemResult, len(req.Operations)),
+	}
+	// В best_effort невалидные операции получают свой 400,
+	// в atomic любая из них отклоняет весь батч
+	ops := make([]models.Operation, 0, len(req.Operations))
+	index := make([]int, 0, len(req.Operations))
+	for i, item := range req.Operations {
+		response.Results[i] = dto.BatchItemResult{Index: i, WalletID: item.WalletID, Status: http.StatusOK}
+		if err := item.Validate(); err != nil {
+			if req.Mode == dto.BatchModeAtomic {
+				logger.Error(fmt.Sprintf("validation error: operation %d: %v", i, err))
+				http.Error(w, fmt.Sprintf("operation %d: %v", i, err), http.StatusBadRequest)
+				return
+			}
+			response.Results[i].Status = http.StatusBadRequest
+			response.Results[i].Error = err.Error()
+			continue
+		}
+		ops = append(ops, models.Operation{
+			Type:     string(item.OperationType),
+			WalletID: item.WalletID,
+			Amount:   item.Amount,
+			Currency: item.Currency,
+		})
+		index = append(index, i)
+	}
+
+	if req.Mode == dto.BatchModeAtomic {
+		if err := h.service.ApplyBatch(ctx, ops); err != nil {
+			writeServiceError(ctx, w, err, "batch update")
+			return
+		}
+	} else {
+		for j, err := range h.service.UpdateBalances(ctx, ops) {
+			if err == nil {
+				continue
+			}
+			res := &response.Results[index[j]]
+			res.Status = serviceErrorStatus(err)
+			if ctx.Err() == context.DeadlineExceeded {
+				res.Status = http.StatusRequestTimeout
+			}
+			res.Error = err.Error()
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+	logger.Info(fmt.Sprintf("batch applied: mode=%s ops=%d", req.Mode, len(req.Operations)))
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"test-psql/internal/http/dto"
+	"test-psql/internal/models"
+)
+
+func TestWalletHandler_BatchUpdateWalletBalance(t *testing.T) {
+	const (
+		walletA = "550e8400-e29b-41d4-a716-446655440000"
+		walletB = "550e8400-e29b-41d4-a716-446655440001"
+	)
+	operations := fmt.Sprintf(`[
+		{"walletId":%q,"operationType":"DEPOSIT","amount":100},
+		{"walletId":%q,"operationType":"WITHDRAW","amount":0},
+		{"walletId":%q,"operationType":"WITHDRAW","amount":50}
+	]`, walletA, walletA, walletB)
+
+	do := func(svc *mockWalletService, body string) *httptest.ResponseRecorder {
+		h := NewWalletHandler(svc, 30*time.Second)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/wallet/batch", bytes.NewReader([]byte(body)))
+		rec := httptest.NewRecorder()
+		h.BatchUpdateWalletBalance(rec, req)
+		return rec
+	}
+
+	t.Run("best_effort reports each operation", func(t *testing.T) {
+		svc := &mockWalletService{batchErrs: []error{nil, models.ErrInsufficientBalance}}
+		rec := do(svc, `{"mode":"best_effort","operations":`+operations+`}`)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("got status %d, want 200", rec.Code)
+		}
+		var res dto.BatchUpdateResponse
+		if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		want := []int{http.StatusOK, http.StatusBadRequest, http.StatusConflict}
+		if len(res.Results) != len(want) {
+			t.Fatalf("got %d results, want %d", len(res.Results), len(want))
+		}
+		for i, status := range want {
+			if res.Results[i].Index != i || res.Results[i].Status != status {
+				t.Errorf("result %d: got %+v, want status %d", i, res.Results[i], status)
+			}
+		}
+		if len(svc.gotOps) != 2 || svc.gotOps[1].WalletID != walletB {
+			t.Errorf("invalid operation must not reach the service: %+v", svc.gotOps)
+		}
+	})
+
+	t.Run("atomic rejects batch with invalid operation", func(t *testing.T) {
+		svc := &mockWalletService{}
+		rec := do(svc, `{"mode":"atomic","operations":`+operations+`}`)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("got status %d, want 400", rec.Code)
+		}
+		if !strings.Contains(rec.Body.String(), "operation 1") {
+			t.Errorf("error must name the operation: %q", rec.Body.String())
+		}
+		if svc.gotOps != nil {
+			t.Error("service must not be called")
+		}
+	})
+
+	t.Run("atomic failure", func(t *testing.T) {
+		svc := &mockWalletService{batchErr: fmt.Errorf("operation 1: %w", models.ErrInsufficientBalance)}
+		body := fmt.Sprintf(`{"mode":"atomic","operations":[
+			{"walletId":%q,"operationType":"DEPOSIT","amount":100},
+			{"walletId":%q,"operationType":"WITHDRAW","amount":50}
+		]}`, walletA, walletB)
+		rec := do(svc, body)
+
+		if rec.Code != http.StatusConflict {
+			t.Errorf("got status %d, want 409", rec.Code)
+		}
+		if len(svc.gotOps) != 2 || svc.gotOps[0].Type != "DEPOSIT" || svc.gotOps[1].Amount != 50 {
+			t.Errorf("unexpected ops: %+v", svc.gotOps)
+		}
+	})
+
+	badRequests := map[string]string{
+		"unknown mode":  `{"mode":"all","operations":[{"walletId":"a","operationType":"DEPOSIT","amount":1}]}`,
+		"no operations": `{"mode":"atomic","operations":[]}`,
+		"invalid body":  `{"mode":`,
+	}
+	for name, body := range badRequests {
+		t.Run(name, func(t *testing.T) {
+			if rec := do(&mockWalletService{}, body); rec.Code != http.StatusBadRequest {
+				t.Errorf("got status %d, want 400", rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"test-psql/internal/models"
+	"test-psql/pkg/logger"
+)
+
+// writeServiceError writes an error returned by the service with the status
+// from serviceErrorStatus; a request that ran out of time gets 408.
+func writeServiceError(ctx context.Context, w http.ResponseWriter, err error, action string) {
+	if ctx.Err() == context.DeadlineExceeded {
+		logger.Error("request timeout")
+		http.Error(w, "Request timeout", http.StatusRequestTimeout)
+		return
+	}
+	logger.Error(fmt.Sprintf("%s failed: %v", action, err))
+	var full *models.QueueFullError
+	if errors.As(err, &full) {
+		w.Header().Set("Retry-After", retryAfterSeconds(full.RetryAfter))
+	}
+	http.Error(w, err.Error(), serviceErrorStatus(err))
+}
+
+// retryAfterSeconds formats d as a Retry-After value: whole seconds, rounded
+// up and at least one.
+func retryAfterSeconds(d time.Duration) string {
+	seconds := int64((d + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return strconv.FormatInt(seconds, 10)
+}
+
+// serviceErrorStatus maps an error returned by the service to an HTTP status.
+func serviceErrorStatus(err error) int {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, models.ErrWalletNotFound),
+		errors.Is(err, models.ErrHoldNotFound),
+		errors.Is(err, models.ErrOperationNotFound),
+		errors.Is(err, models.ErrQuoteNotFound),
+		errors.Is(err, models.ErrScheduleNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, models.ErrInsufficientBalance),
+		errors.Is(err, models.ErrWalletExists),
+		errors.Is(err, models.ErrWalletNotEmpty),
+		errors.Is(err, models.ErrWalletHasHolds),
+		errors.Is(err, models.ErrWalletHasSchedules),
+		errors.Is(err, models.ErrInvalidTransition),
+		errors.Is(err, models.ErrHoldNotActive),
+		errors.Is(err, models.ErrHoldExpired),
+		errors.Is(err, models.ErrCreditLimitInUse),
+		errors.Is(err, models.ErrAlreadyReversed),
+		errors.Is(err, models.ErrQuoteExpired),
+		errors.Is(err, models.ErrQuoteExecuted),
+		errors.Is(err, models.ErrScheduleTransition),
+		errors.Is(err, models.ErrIdempotencyKeyInUse):
+		status = http.StatusConflict
+	case errors.Is(err, models.ErrWalletFrozen):
+		status = http.StatusLocked
+	case errors.Is(err, models.ErrWalletClosed):
+		status = http.StatusGone
+	case errors.Is(err, models.ErrQueueFull),
+		errors.Is(err, models.ErrQueueClosed):
+		status = http.StatusServiceUnavailable
+	case errors.Is(err, models.ErrSameWalletTransfer),
+		errors.Is(err, models.ErrUnknownCurrency):
+		status = http.StatusBadRequest
+	case errors.Is(err, models.ErrIdempotencyKeyReused),
+		errors.Is(err, models.ErrCurrencyMismatch),
+		errors.Is(err, models.ErrCaptureExceedsHold),
+		errors.Is(err, models.ErrLimitExceeded),
+		errors.Is(err, models.ErrNotReversible),
+		errors.Is(err, models.ErrReversalExceedsOp),
+		errors.Is(err, models.ErrRateNotFound),
+		errors.Is(err, models.ErrSameCurrency),
+		errors.Is(err, models.ErrConversionTooSmall),
+		errors.Is(err, models.ErrBalanceUnknown):
+		status = http.StatusUnprocessableEntity
+	}
+	return status
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"test-psql/internal/http/dto"
+	"test-psql/internal/models"
+	"test-psql/pkg/logger"
+)
+
+func (h *WalletHandler) GetExchangeRates(w http.ResponseWriter, r *http.Request) {
+	logger.Info("GET /api/v1/admin/fx/rates")
+	if r.Method != http.MethodGet {
+		logger.Error(fmt.Sprintf("method not allowed: %s", r.Method))
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	rates, err := h.service.GetExchangeRates(ctx)
+	if err != nil {
+		writeServiceError(ctx, w, err, "get exchange rates")
+		return
+	}
+
+	writeExchangeRates(w, rates)
+	logger.Info(fmt.Sprintf("exchange rates retrieved: count=%d", len(rates)))
+}
+
+func (h *WalletHandler) SetExchangeRates(w http.ResponseWriter, r *http.Request) {
+	logger.Info("PUT /api/v1/admin/fx/rates")
+	if r.Method != http.MethodPut {
+		logger.Error(fmt.Sprintf("method not allowed: %s", r.Method))
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	var req dto.SetExchangeRatesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error(fmt.Sprintf("invalid request body: %v", err))
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		logger.Error(fmt.Sprintf("validation error: %v", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rates := make([]models.ExchangeRate, 0, len(req.Rates))
+	for i, item := range req.Rates {
+		rate := models.ExchangeRate{FromCurrency: item.From, ToCurrency: item.To, Rate: models.Rate(item.Rate)}
+		// Формат курса и коды валют проверяются по модели, чтобы ответить 400
+		if err := rate.Validate(); err != nil {
+			logger.Error(fmt.Sprintf("validation error: %v", err))
+			http.Error(w, fmt.Sprintf("rates[%d]: %v", i, err), http.StatusBadRequest)
+			return
+		}
+		rates = append(rates, rate)
+	}
+
+	rates, err := h.service.SetExchangeRates(ctx, rates)
+	if err != nil {
+		writeServiceError(ctx, w, err, "set exchange rates")
+		return
+	}
+
+	writeExchangeRates(w, rates)
+	logger.Info(fmt.Sprintf("exchange rates set: count=%d", len(rates)))
+}
+
+func (h *WalletHandler) CreateQuote(w http.ResponseWriter, r *http.Request) {
+	logger.Info("POST /api/v1/fx/quotes")
+	if r.Method != http.MethodPost {
+		logger.Error(fmt.Sprintf("method not allowed: %s", r.Method))
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	var req dto.CreateQuoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field == "amount" {
+			logger.Error(fmt.Sprintf("invalid amount type: %v", err))
+			http.Error(w, "amount must be a number", http.StatusBadRequest)
+			return
+		}
+		logger.Error(fmt.Sprintf("invalid request body: %v", err))
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		logger.Error(fmt.Sprintf("validation error: %v", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	quote, err := h.service.CreateQuote(ctx, req.FromWalletID, req.ToWalletID, req.Amount)
+	if err != nil {
+		writeServiceError(ctx, w, err, "create quote")
+		return
+	}
+
+	writeQuote(w, http.StatusCreated, quote)
+	logger.Info(fmt.Sprintf("quote created: id=%s rate=%s amount=%d converted=%d", quote.ID, quote.Rate, quote.Amount, quote.ConvertedAmount))
+}
+
+func (h *WalletHandler) ExecuteQuote(w http.ResponseWriter, r *http.Request) {
+	logger.Info("POST /api/v1/fx/quotes/{id}/execute")
+	if r.Method != http.MethodPost {
+		logger.Error(fmt.Sprintf("method not allowed: %s", r.Method))
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	quote, err := h.service.ExecuteQuote(ctx, r.PathValue("id"))
+	if err != nil {
+		writeServiceError(ctx, w, err, "execute quote")
+		return
+	}
+
+	writeQuote(w, http.StatusOK, quote)
+	logger.Info(fmt.Sprintf("quote executed: id=%s from=%s to=%s", quote.ID, quote.FromWalletID, quote.ToWalletID))
+}
+
+func writeExchangeRates(w http.ResponseWriter, rates []models.ExchangeRate) {
+	response := dto.ExchangeRatesResponse{Rates: make([]dto.ExchangeRate, 0, len(rates))}
+	for _, rate := range rates {
+		updatedAt := rate.UpdatedAt
+		response.Rates = append(response.Rates, dto.ExchangeRate{
+			From:      rate.FromCurrency,
+			To:        rate.ToCurrency,
+			Rate:      string(rate.Rate),
+			UpdatedAt: &updatedAt,
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+func writeQuote(w http.ResponseWriter, status int, quote models.FXQuote) {
+	response := dto.QuoteResponse{
+		QuoteID:         quote.ID.String(),
+		FromWalletID:    quote.FromWalletID.String(),
+		ToWalletID:      quote.ToWalletID.String(),
+		FromCurrency:    quote.FromCurrency,
+		ToCurrency:      quote.ToCurrency,
+		Amount:          quote.Amount,
+		ConvertedAmount: quote.ConvertedAmount,
+		Rate:            string(quote.Rate),
+		ExpiresAt:       quote.ExpiresAt,
+		ExecutedAt:      quote.ExecutedAt,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"test-psql/internal/http/dto"
+	"test-psql/internal/models"
+)
+
+func TestWalletHandler_SetExchangeRates(t *testing.T) {
+	do := func(svc *mockWalletService, body string) *httptest.ResponseRecorder {
+		h := NewWalletHandler(svc, 30*time.Second)
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/fx/rates", bytes.NewReader([]byte(body)))
+		rec := httptest.NewRecorder()
+		h.SetExchangeRates(rec, req)
+		return rec
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		svc := &mockWalletService{}
+		rec := do(svc, `{"rates": [{"from": "USD", "to": "RUB", "rate": "92.500"}, {"from": "RUB", "to": "USD", "rate": "0.010846"}]}`)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("got status %d, want 200: %s", rec.Code, rec.Body)
+		}
+		if len(svc.gotRates) != 2 || svc.gotRates[0].Rate != "92.5" {
+			t.Errorf("unexpected rates: %+v", svc.gotRates)
+		}
+		var res dto.ExchangeRatesResponse
+		if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Rates) != 2 || res.Rates[1].Rate != "0.010846" {
+			t.Errorf("unexpected response: %+v", res)
+		}
+	})
+
+	badRequests := map[string]string{
+		"empty":          `{"rates": []}`,
+		"rate as number": `{"rates": [{"from": "USD", "to": "RUB", "rate": 92.5}]}`,
+		"bad rate":       `{"rates": [{"from": "USD", "to": "RUB", "rate": "1e3"}]}`,
+		"zero rate":      `{"rates": [{"from": "USD", "to": "RUB", "rate": "0"}]}`,
+		"same currency":  `{"rates": [{"from": "USD", "to": "USD", "rate": "1"}]}`,
+		"unknown code":   `{"rates": [{"from": "USD", "to": "XXX", "rate": "1"}]}`,
+		"duplicate pair": `{"rates": [{"from": "USD", "to": "RUB", "rate": "1"}, {"from": "USD", "to": "RUB", "rate": "2"}]}`,
+	}
+	for name, body := range badRequests {
+		t.Run(name, func(t *testing.T) {
+			svc := &mockWalletService{}
+			if rec := do(svc, body); rec.Code != http.StatusBadRequest {
+				t.Errorf("got status %d, want 400", rec.Code)
+			}
+			if svc.gotRates != nil {
+				t.Error("service must not be called")
+			}
+		})
+	}
+}
+
+func TestWalletHandler_Quotes(t *testing.T) {
+	quote := models.FXQuote{
+		ID:              uuid.New(),
+		FromWalletID:    uuid.New(),
+		ToWalletID:      uuid.New(),
+		FromCurrency:    "USD",
+		ToCurrency:      "RUB",
+		Amount:          1000,
+		ConvertedAmount: 92500,
+		Rate:            "92.5",
+		ExpiresAt:       time.Now().Add(30 * time.Second),
+	}
+
+	t.Run("create", func(t *testing.T) {
+		svc := &mockWalletService{quote: quote}
+		h := NewWalletHandler(svc, 30*time.Second)
+		body := `{"fromWalletId": "` + quote.FromWalletID.String() + `", "toWalletId": "` + quote.ToWalletID.String() + `", "amount": 1000}`
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/fx/quotes", bytes.NewReader([]byte(body)))
+		rec := httptest.NewRecorder()
+		h.CreateQuote(rec, req)
+
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("got status %d, want 201", rec.Code)
+		}
+		var res dto.QuoteResponse
+		if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		if res.QuoteID != quote.ID.String() || res.Rate != "92.5" || res.ConvertedAmount != 92500 || res.ExecutedAt != nil {
+			t.Errorf("unexpected response: %+v", res)
+		}
+	})
+
+	t.Run("create without rate", func(t *testing.T) {
+		h := NewWalletHandler(&mockWalletService{quoteErr: models.ErrRateNotFound}, 30*time.Second)
+		body := `{"fromWalletId": "a", "toWalletId": "b", "amount": 1000}`
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/fx/quotes", bytes.NewReader([]byte(body)))
+		rec := httptest.NewRecorder()
+		h.CreateQuote(rec, req)
+
+		if rec.Code != http.StatusUnprocessableEntity {
+			t.Errorf("got status %d, want 422", rec.Code)
+		}
+	})
+
+	executed := quote
+	now := time.Now()
+	executed.ExecutedAt = &now
+	cases := []struct {
+		name   string
+		svc    *mockWalletService
+		status int
+	}{
+		{"execute", &mockWalletService{quote: executed}, http.StatusOK},
+		{"unknown quote", &mockWalletService{quoteErr: models.ErrQuoteNotFound}, http.StatusNotFound},
+		{"expired quote", &mockWalletService{quoteErr: models.ErrQuoteExpired}, http.StatusConflict},
+		{"executed twice", &mockWalletService{quoteErr: models.ErrQuoteExecuted}, http.StatusConflict},
+		{"insufficient balance", &mockWalletService{quoteErr: models.ErrInsufficientBalance}, http.StatusConflict},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewWalletHandler(tc.svc, 30*time.Second)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/fx/quotes/"+quote.ID.String()+"/execute", nil)
+			req.SetPathValue("id", quote.ID.String())
+			rec := httptest.NewRecorder()
+			h.ExecuteQuote(rec, req)
+
+			if rec.Code != tc.status {
+				t.Fatalf("got status %d, want %d", rec.Code, tc.status)
+			}
+			if tc.svc.gotQuoteID != quote.ID.String() {
+				t.Errorf("got quote id %q", tc.svc.gotQuoteID)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"test-psql/internal/http/dto"
+	"test-psql/internal/models"
+	"test-psql/pkg/logger"
+)
+
+func (h *WalletHandler) CreateHold(w http.ResponseWriter, r *http.Request) {
+	logger.Info("POST /api/v1/wallets/{id}/holds")
+	if r.Method != http.MethodPost {
+		logger.Error(fmt.Sprintf("method not allowed: %s", r.Method))
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	walletID := r.PathValue("id")
+	if walletID == "" {
+		http.Error(w, "walletId is required", http.StatusBadRequest)
+		return
+	}
+
+	var req dto.CreateHoldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field == "amount" {
+			logger.Error(fmt.Sprintf("invalid amount type: %v", err))
+			http.Error(w, "amount must be a number", http.StatusBadRequest)
+			return
+		}
+		logger.Error(fmt.Sprintf("invalid request body: %v", err))
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		logger.Error(fmt.Sprintf("validation error: %v", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	hold, err := h.service.CreateHold(ctx, walletID, req.Amount, req.Currency, req.TTL())
+	if err != nil {
+		writeServiceError(ctx, w, err, "create hold")
+		return
+	}
+
+	writeHold(w, http.StatusCreated, hold)
+	logger.Info(fmt.Sprintf("hold created: holdId=%s walletId=%s amount=%d", hold.ID, walletID, hold.Amount))
+}
+
+func (h *WalletHandler) CaptureHold(w http.ResponseWriter, r *http.Request) {
+	logger.Info("POST /api/v1/wallets/{id}/holds/{holdId}/capture")
+	if r.Method != http.MethodPost {
+		logger.Error(fmt.Sprintf("method not allowed: %s", r.Method))
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	// Тело необязательно: без amount холд списывается целиком
+	var req dto.CaptureHoldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		logger.Error(fmt.Sprintf("invalid request body: %v", err))
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		logger.Error(fmt.Sprintf("validation error: %v", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	hold, err := h.service.CaptureHold(ctx, r.PathValue("id"), r.PathValue("holdId"), req.Amount)
+	if err != nil {
+		writeServiceError(ctx, w, err, "capture hold")
+		return
+	}
+
+	writeHold(w, http.StatusOK, hold)
+	logger.Info(fmt.Sprintf("hold captured: holdId=%s amount=%d", hold.ID, hold.CapturedAmount))
+}
+
+func (h *WalletHandler) VoidHold(w http.ResponseWriter, r *http.Request) {
+	logger.Info("POST /api/v1/wallets/{id}/holds/{holdId}/void")
+	if r.Method != http.MethodPost {
+		logger.Error(fmt.Sprintf("method not allowed: %s", r.Method))
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	hold, err := h.service.VoidHold(ctx, r.PathValue("id"), r.PathValue("holdId"))
+	if err != nil {
+		writeServiceError(ctx, w, err, "void hold")
+		return
+	}
+
+	writeHold(w, http.StatusOK, hold)
+	logger.Info(fmt.Sprintf("hold voided: holdId=%s", hold.ID))
+}
+
+func writeHold(w http.ResponseWriter, status int, hold models.Hold) {
+	response := dto.HoldResponse{
+		HoldID:         hold.ID.String(),
+		WalletID:       hold.WalletID.String(),
+		Amount:         hold.Amount,
+		CapturedAmount: hold.CapturedAmount,
+		Status:         string(hold.Status),
+		ExpiresAt:      hold.ExpiresAt,
+		CreatedAt:      hold.CreatedAt,
+		UpdatedAt:      hold.UpdatedAt,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
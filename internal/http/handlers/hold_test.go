@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"test-psql/internal/http/dto"
+	"test-psql/internal/models"
+)
+
+func TestWalletHandler_CreateHold(t *testing.T) {
+	const walletID = "550e8400-e29b-41d4-a716-446655440000"
+
+	t.Run("ok", func(t *testing.T) {
+		hold := models.Hold{ID: uuid.New(), WalletID: uuid.MustParse(walletID), Amount: 500, Status: models.HoldStatusActive}
+		svc := &mockWalletService{hold: hold}
+		h := NewWalletHandler(svc, 30*time.Second)
+		body := []byte(`{"amount":500,"currency":"RUB","ttlSeconds":60}`)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/wallets/"+walletID+"/holds", bytes.NewReader(body))
+		req.SetPathValue("id", walletID)
+		rec := httptest.NewRecorder()
+
+		h.CreateHold(rec, req)
+
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("got status %d, want 201", rec.Code)
+		}
+		if svc.gotWalletID != walletID || svc.gotAmount != 500 || svc.gotCurrency != "RUB" {
+			t.Errorf("unexpected call: walletId=%q amount=%d currency=%q", svc.gotWalletID, svc.gotAmount, svc.gotCurrency)
+		}
+		if svc.gotTTL != time.Minute {
+			t.Errorf("got ttl %s, want 1m", svc.gotTTL)
+		}
+		var res dto.HoldResponse
+		if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		if res.HoldID != hold.ID.String() || res.Status != "ACTIVE" {
+			t.Errorf("unexpected response: %+v", res)
+		}
+	})
+
+	t.Run("default ttl", func(t *testing.T) {
+		svc := &mockWalletService{}
+		h := NewWalletHandler(svc, 30*time.Second)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/wallets/"+walletID+"/holds", bytes.NewReader([]byte(`{"amount":1}`)))
+		req.SetPathValue("id", walletID)
+		rec := httptest.NewRecorder()
+
+		h.CreateHold(rec, req)
+
+		if svc.gotTTL != dto.DefaultHoldTTLSeconds*time.Second {
+			t.Errorf("got ttl %s", svc.gotTTL)
+		}
+	})
+
+	badBodies := map[string]string{
+		"zero amount":   `{"amount":0}`,
+		"string amount": `{"amount":"10"}`,
+		"ttl too long":  `{"amount":10,"ttlSeconds":99999999}`,
+		"bad currency":  `{"amount":10,"currency":"rub"}`,
+	}
+	for name, body := range badBodies {
+		t.Run(name, func(t *testing.T) {
+			h := NewWalletHandler(&mockWalletService{}, 30*time.Second)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/wallets/"+walletID+"/holds", bytes.NewReader([]byte(body)))
+			req.SetPathValue("id", walletID)
+			rec := httptest.NewRecorder()
+
+			h.CreateHold(rec, req)
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("got status %d, want 400", rec.Code)
+			}
+		})
+	}
+
+	t.Run("insufficient balance", func(t *testing.T) {
+		h := NewWalletHandler(&mockWalletService{holdErr: models.ErrInsufficientBalance}, 30*time.Second)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/wallets/"+walletID+"/holds", bytes.NewReader([]byte(`{"amount":10}`)))
+		req.SetPathValue("id", walletID)
+		rec := httptest.NewRecorder()
+
+		h.CreateHold(rec, req)
+
+		if rec.Code != http.StatusConflict {
+			t.Errorf("got status %d, want 409", rec.Code)
+		}
+	})
+}
+
+func TestWalletHandler_CaptureHold(t *testing.T) {
+	const walletID = "550e8400-e29b-41d4-a716-446655440000"
+	holdID := uuid.New().String()
+
+	t.Run("partial", func(t *testing.T) {
+		svc := &mockWalletService{hold: models.Hold{Status: models.HoldStatusCaptured, CapturedAmount: 200}}
+		h := NewWalletHandler(svc, 30*time.Second)
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{"amount":200}`)))
+		req.SetPathValue("id", walletID)
+		req.SetPathValue("holdId", holdID)
+		rec := httptest.NewRecorder()
+
+		h.CaptureHold(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("got status %d, want 200", rec.Code)
+		}
+		if svc.gotHoldID != holdID || svc.gotAmount != 200 {
+			t.Errorf("unexpected call: holdId=%q amount=%d", svc.gotHoldID, svc.gotAmount)
+		}
+	})
+
+	t.Run("empty body captures all", func(t *testing.T) {
+		svc := &mockWalletService{gotAmount: -1}
+		h := NewWalletHandler(svc, 30*time.Second)
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.SetPathValue("id", walletID)
+		req.SetPathValue("holdId", holdID)
+		rec := httptest.NewRecorder()
+
+		h.CaptureHold(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("got status %d, want 200", rec.Code)
+		}
+		if svc.gotAmount != 0 {
+			t.Errorf("got amount %d, want 0", svc.gotAmount)
+		}
+	})
+
+	errCases := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"not found", models.ErrHoldNotFound, http.StatusNotFound},
+		{"not active", models.ErrHoldNotActive, http.StatusConflict},
+		{"expired", models.ErrHoldExpired, http.StatusConflict},
+		{"exceeds hold", models.ErrCaptureExceedsHold, http.StatusUnprocessableEntity},
+		{"frozen", models.ErrWalletFrozen, http.StatusLocked},
+	}
+	for _, tc := range errCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewWalletHandler(&mockWalletService{holdErr: tc.err}, 30*time.Second)
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req.SetPathValue("id", walletID)
+			req.SetPathValue("holdId", holdID)
+			rec := httptest.NewRecorder()
+
+			h.CaptureHold(rec, req)
+
+			if rec.Code != tc.want {
+				t.Errorf("got status %d, want %d", rec.Code, tc.want)
+			}
+		})
+	}
+}
+
+func TestWalletHandler_VoidHold(t *testing.T) {
+	const walletID = "550e8400-e29b-41d4-a716-446655440000"
+	holdID := uuid.New().String()
+
+	svc := &mockWalletService{hold: models.Hold{Status: models.HoldStatusVoided}}
+	h := NewWalletHandler(svc, 30*time.Second)
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.SetPathValue("id", walletID)
+	req.SetPathValue("holdId", holdID)
+	rec := httptest.NewRecorder()
+
+	h.VoidHold(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want 200", rec.Code)
+	}
+	if svc.gotWalletID != walletID || svc.gotHoldID != holdID {
+		t.Errorf("unexpected call: walletId=%q holdId=%q", svc.gotWalletID, svc.gotHoldID)
+	}
+	var res dto.HoldResponse
+	if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != "VOIDED" {
+		t.Errorf("got status %q, want VOIDED", res.Status)
+	}
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"test-psql/internal/http/dto"
+	"test-psql/internal/models"
+	"test-psql/pkg/logger"
+)
+
+func (h *WalletHandler) CreateWallet(w http.ResponseWriter, r *http.Request) {
+	logger.Info("POST /api/v1/wallets")
+	if r.Method != http.MethodPost {
+		logger.Error(fmt.Sprintf("method not allowed: %s", r.Method))
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	// Тело необязательно: без walletId идентификатор генерирует сервер,
+	// без currency используется валюта по умолчанию
+	var req dto.CreateWalletRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		logger.Error(fmt.Sprintf("invalid request body: %v", err))
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		logger.Error(fmt.Sprintf("validation error: %v", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	wallet, err := h.service.CreateWallet(ctx, req.WalletID, req.Currency, models.WalletMetadata{
+		OwnerID: req.OwnerID,
+		Name:    req.Name,
+		Labels:  req.Labels,
+	})
+	if err != nil {
+		writeServiceError(ctx, w, err, "create wallet")
+		return
+	}
+
+	writeWallet(w, http.StatusCreated, wallet)
+	logger.Info(fmt.Sprintf("wallet created: walletId=%s", wallet.ID))
+}
+
+func (h *WalletHandler) FreezeWallet(w http.ResponseWriter, r *http.Request) {
+	h.changeWalletStatus(w, r, "freeze", h.service.FreezeWallet)
+}
+
+func (h *WalletHandler) UnfreezeWallet(w http.ResponseWriter, r *http.Request) {
+	h.changeWalletStatus(w, r, "unfreeze", h.service.UnfreezeWallet)
+}
+
+func (h *WalletHandler) CloseWallet(w http.ResponseWriter, r *http.Request) {
+	h.changeWalletStatus(w, r, "close", h.service.CloseWallet)
+}
+
+func (h *WalletHandler) changeWalletStatus(w http.ResponseWriter, r *http.Request, action string,
+	change func(ctx context.Context, walletID string) (models.Wallet, error)) {
+	logger.Info(fmt.Sprintf("POST /api/v1/wallets/{id}/%s", action))
+	if r.Method != http.MethodPost {
+		logger.Error(fmt.Sprintf("method not allowed: %s", r.Method))
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	req := dto.GetWalletBalanceRequest{WalletID: r.PathValue("id")}
+	if err := req.Validate(); err != nil {
+		logger.Error(fmt.Sprintf("validation error: %v", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	wallet, err := change(ctx, req.WalletID)
+	if err != nil {
+		writeServiceError(ctx, w, err, action+" wallet")
+		return
+	}
+
+	writeWallet(w, http.StatusOK, wallet)
+	logger.Info(fmt.Sprintf("wallet %s: walletId=%s status=%s", action, wallet.ID, wallet.Status))
+}
+
+func (h *WalletHandler) SetCreditLimit(w http.ResponseWriter, r *http.Request) {
+	logger.Info("PUT /api/v1/admin/wallets/{id}/credit-limit")
+	if r.Method != http.MethodPut {
+		logger.Error(fmt.Sprintf("method not allowed: %s", r.Method))
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	walletReq := dto.GetWalletBalanceRequest{WalletID: r.PathValue("id")}
+	if err := walletReq.Validate(); err != nil {
+		logger.Error(fmt.Sprintf("validation error: %v", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req dto.SetCreditLimitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field == "creditLimit" {
+			logger.Error(fmt.Sprintf("invalid creditLimit type: %v", err))
+			http.Error(w, "creditLimit must be a number", http.StatusBadRequest)
+			return
+		}
+		logger.Error(fmt.Sprintf("invalid request body: %v", err))
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		logger.Error(fmt.Sprintf("validation error: %v", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	wallet, err := h.service.SetCreditLimit(ctx, walletReq.WalletID, *req.CreditLimit)
+	if err != nil {
+		writeServiceError(ctx, w, err, "set credit limit")
+		return
+	}
+
+	writeWallet(w, http.StatusOK, wallet)
+	logger.Info(fmt.Sprintf("credit limit set: walletId=%s limit=%d", wallet.ID, wallet.CreditLimit))
+}
+
+func writeWallet(w http.ResponseWriter, status int, wallet models.Wallet) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(walletResponse(wallet))
+}
+
+func walletResponse(wallet models.Wallet) dto.WalletResponse {
+	labels := []string(wallet.Labels)
+	if labels == nil {
+		labels = []string{}
+	}
+	return dto.WalletResponse{
+		WalletID:         wallet.ID.String(),
+		Balance:          wallet.Balance,
+		CreditLimit:      wallet.CreditLimit,
+		Currency:         wallet.Currency,
+		CurrencyExponent: wallet.CurrencyExponent,
+		Status:           string(wallet.Status),
+		OwnerID:          wallet.OwnerID,
+		Name:             wallet.Name,
+		Labels:           labels,
+		CreatedAt:        wallet.CreatedAt,
+		UpdatedAt:        wallet.UpdatedAt,
+	}
+}
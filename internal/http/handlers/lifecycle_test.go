@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"test-psql/internal/models"
+)
+
+func TestWalletHandler_CreateWallet(t *testing.T) {
+	t.Run("server generated id", func(t *testing.T) {
+		id := uuid.New()
+		svc := &mockWalletService{wallet: models.Wallet{ID: id, Status: models.WalletStatusActive}}
+		h := NewWalletHandler(svc, 30*time.Second)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/wallets", nil)
+		rec := httptest.NewRecorder()
+
+		h.CreateWallet(rec, req)
+
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("got status %d, want 201", rec.Code)
+		}
+		if svc.gotWalletID != "" {
+			t.Errorf("got walletId %q, want empty", svc.gotWalletID)
+		}
+		var res struct {
+			WalletID string `json:"walletId"`
+			Status   string `json:"status"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		if res.WalletID != id.String() || res.Status != "ACTIVE" {
+			t.Errorf("unexpected response: %+v", res)
+		}
+	})
+
+	t.Run("client supplied id", func(t *testing.T) {
+		svc := &mockWalletService{}
+		h := NewWalletHandler(svc, 30*time.Second)
+		body := []byte(`{"walletId":"550e8400-e29b-41d4-a716-446655440009","currency":"USD"}`)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/wallets", bytes.NewReader(body))
+		rec := httptest.NewRecorder()
+
+		h.CreateWallet(rec, req)
+
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("got status %d, want 201", rec.Code)
+		}
+		if svc.gotWalletID != "550e8400-e29b-41d4-a716-446655440009" {
+			t.Errorf("got walletId %q", svc.gotWalletID)
+		}
+		if svc.gotCurrency != "USD" {
+			t.Errorf("got currency %q, want USD", svc.gotCurrency)
+		}
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		h := NewWalletHandler(&mockWalletService{}, 30*time.Second)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/wallets", bytes.NewReader([]byte(`{"walletId":"abc"}`)))
+		rec := httptest.NewRecorder()
+
+		h.CreateWallet(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("got status %d, want 400", rec.Code)
+		}
+	})
+
+	t.Run("already exists", func(t *testing.T) {
+		h := NewWalletHandler(&mockWalletService{walletErr: models.ErrWalletExists}, 30*time.Second)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/wallets", nil)
+		rec := httptest.NewRecorder()
+
+		h.CreateWallet(rec, req)
+
+		if rec.Code != http.StatusConflict {
+			t.Errorf("got status %d, want 409", rec.Code)
+		}
+	})
+}
+
+func TestWalletHandler_ChangeWalletStatus(t *testing.T) {
+	const walletID = "550e8400-e29b-41d4-a716-446655440000"
+	handlers := map[string]func(h *WalletHandler) http.HandlerFunc{
+		"freeze":   func(h *WalletHandler) http.HandlerFunc { return h.FreezeWallet },
+		"unfreeze": func(h *WalletHandler) http.HandlerFunc { return h.UnfreezeWallet },
+		"close":    func(h *WalletHandler) http.HandlerFunc { return h.CloseWallet },
+	}
+	for action, handler := range handlers {
+		t.Run(action, func(t *testing.T) {
+			svc := &mockWalletService{wallet: models.Wallet{ID: uuid.MustParse(walletID)}}
+			h := NewWalletHandler(svc, 30*time.Second)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/wallets/"+walletID+"/"+action, nil)
+			req.SetPathValue("id", walletID)
+			rec := httptest.NewRecorder()
+
+			handler(h)(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Errorf("got status %d, want 200", rec.Code)
+			}
+			if svc.gotWalletID != walletID {
+				t.Errorf("got walletId %q, want %q", svc.gotWalletID, walletID)
+			}
+		})
+	}
+
+	errCases := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"not found", models.ErrWalletNotFound, http.StatusNotFound},
+		{"invalid transition", models.ErrInvalidTransition, http.StatusConflict},
+		{"not empty", models.ErrWalletNotEmpty, http.StatusConflict},
+		{"active holds", models.ErrWalletHasHolds, http.StatusConflict},
+		{"active schedules", models.ErrWalletHasSchedules, http.StatusConflict},
+	}
+	for _, tc := range errCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewWalletHandler(&mockWalletService{walletErr: tc.err}, 30*time.Second)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/wallets/"+walletID+"/close", nil)
+			req.SetPathValue("id", walletID)
+			rec := httptest.NewRecorder()
+
+			h.CloseWallet(rec, req)
+
+			if rec.Code != tc.want {
+				t.Errorf("got status %d, want %d", rec.Code, tc.want)
+			}
+		})
+	}
+}
+
+func TestWalletHandler_SetCreditLimit(t *testing.T) {
+	const walletID = "550e8400-e29b-41d4-a716-446655440000"
+
+	t.Run("ok", func(t *testing.T) {
+		svc := &mockWalletService{wallet: models.Wallet{ID: uuid.MustParse(walletID), CreditLimit: 5000}}
+		h := NewWalletHandler(svc, 30*time.Second)
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/wallets/"+walletID+"/credit-limit", bytes.NewReader([]byte(`{"creditLimit":5000}`)))
+		req.SetPathValue("id", walletID)
+		rec := httptest.NewRecorder()
+
+		h.SetCreditLimit(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("got status %d, want 200", rec.Code)
+		}
+		if svc.gotWalletID != walletID || svc.gotCreditLimit != 5000 {
+			t.Errorf("unexpected call: walletId=%q limit=%d", svc.gotWalletID, svc.gotCreditLimit)
+		}
+		var res struct {
+			CreditLimit int64 `json:"creditLimit"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		if res.CreditLimit != 5000 {
+			t.Errorf("got creditLimit %d, want 5000", res.CreditLimit)
+		}
+	})
+
+	badBodies := map[string]string{
+		"missing":  `{}`,
+		"negative": `{"creditLimit":-1}`,
+		"string":   `{"creditLimit":"100"}`,
+	}
+	for name, body := range badBodies {
+		t.Run(name, func(t *testing.T) {
+			h := NewWalletHandler(&mockWalletService{}, 30*time.Second)
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/wallets/"+walletID+"/credit-limit", bytes.NewReader([]byte(body)))
+			req.SetPathValue("id", walletID)
+			rec := httptest.NewRecorder()
+
+			h.SetCreditLimit(rec, req)
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("got status %d, want 400", rec.Code)
+			}
+		})
+	}
+
+	t.Run("limit in use", func(t *testing.T) {
+		h := NewWalletHandler(&mockWalletService{walletErr: models.ErrCreditLimitInUse}, 30*time.Second)
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/wallets/"+walletID+"/credit-limit", bytes.NewReader([]byte(`{"creditLimit":0}`)))
+		req.SetPathValue("id", walletID)
+		rec := httptest.NewRecorder()
+
+		h.SetCreditLimit(rec, req)
+
+		if rec.Code != http.StatusConflict {
+			t.Errorf("got status %d, want 409", rec.Code)
+		}
+	})
+}
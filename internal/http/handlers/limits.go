@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"test-psql/internal/http/dto"
+	"test-psql/internal/models"
+	"test-psql/pkg/logger"
+)
+
+func (h *WalletHandler) GetWalletLimits(w http.ResponseWriter, r *http.Request) {
+	logger.Info("GET /api/v1/admin/wallets/{id}/limits")
+	if r.Method != http.MethodGet {
+		logger.Error(fmt.Sprintf("method not allowed: %s", r.Method))
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	req := dto.GetWalletBalanceRequest{WalletID: r.PathValue("id")}
+	if err := req.Validate(); err != nil {
+		logger.Error(fmt.Sprintf("validation error: %v", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limits, err := h.service.GetWalletLimits(ctx, req.WalletID)
+	if err != nil {
+		writeServiceError(ctx, w, err, "get wallet limits")
+		return
+	}
+
+	writeWalletLimits(w, limits)
+	logger.Info(fmt.Sprintf("wallet limits retrieved: walletId=%s", req.WalletID))
+}
+
+func (h *WalletHandler) SetWalletLimits(w http.ResponseWriter, r *http.Request) {
+	logger.Info("PUT /api/v1/admin/wallets/{id}/limits")
+	if r.Method != http.MethodPut {
+		logger.Error(fmt.Sprintf("method not allowed: %s", r.Method))
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	walletID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		logger.Error(fmt.Sprintf("validation error: %v", err))
+		http.Error(w, "walletId must be a UUID", http.StatusBadRequest)
+		return
+	}
+
+	var req dto.WalletLimitsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error(fmt.Sprintf("invalid request body: %v", err))
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		logger.Error(fmt.Sprintf("validation error: %v", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limits, err := h.service.SetWalletLimits(ctx, models.WalletLimits{
+		WalletID:               walletID,
+		MaxWithdrawalAmount24h: req.MaxWithdrawalAmount24h,
+		MaxWithdrawalsPerHour:  req.MaxWithdrawalsPerHour,
+		MaxBalance:             req.MaxBalance,
+	})
+	if err != nil {
+		writeServiceError(ctx, w, err, "set wallet limits")
+		return
+	}
+
+	writeWalletLimits(w, limits)
+	logger.Info(fmt.Sprintf("wallet limits set: walletId=%s", walletID))
+}
+
+func writeWalletLimits(w http.ResponseWriter, limits models.WalletLimits) {
+	response := dto.WalletLimitsResponse{
+		WalletID:               limits.WalletID.String(),
+		MaxWithdrawalAmount24h: limits.MaxWithdrawalAmount24h,
+		MaxWithdrawalsPerHour:  limits.MaxWithdrawalsPerHour,
+		MaxBalance:             limits.MaxBalance,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"test-psql/internal/http/dto"
+	"test-psql/internal/models"
+)
+
+func TestWalletHandler_WalletLimits(t *testing.T) {
+	const walletID = "550e8400-e29b-41d4-a716-446655440000"
+
+	t.Run("set", func(t *testing.T) {
+		svc := &mockWalletService{}
+		h := NewWalletHandler(svc, 30*time.Second)
+		body := []byte(`{"maxWithdrawalAmount24h":50000,"maxWithdrawalsPerHour":20}`)
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/wallets/"+walletID+"/limits", bytes.NewReader(body))
+		req.SetPathValue("id", walletID)
+		rec := httptest.NewRecorder()
+
+		h.SetWalletLimits(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("got status %d, want 200", rec.Code)
+		}
+		got := svc.gotLimits
+		if got.WalletID.String() != walletID || *got.MaxWithdrawalAmount24h != 50000 || *got.MaxWithdrawalsPerHour != 20 || got.MaxBalance != nil {
+			t.Errorf("unexpected limits: %+v", got)
+		}
+		var res dto.WalletLimitsResponse
+		if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		if res.MaxBalance != nil || res.MaxWithdrawalsPerHour == nil {
+			t.Errorf("unexpected response: %+v", res)
+		}
+	})
+
+	badRequests := map[string]struct{ id, body string }{
+		"negative":     {walletID, `{"maxBalance":-5}`},
+		"invalid id":   {"abc", `{}`},
+		"invalid body": {walletID, `{"maxBalance":"x"}`},
+	}
+	for name, tc := range badRequests {
+		t.Run(name, func(t *testing.T) {
+			h := NewWalletHandler(&mockWalletService{}, 30*time.Second)
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/wallets/"+tc.id+"/limits", bytes.NewReader([]byte(tc.body)))
+			req.SetPathValue("id", tc.id)
+			rec := httptest.NewRecorder()
+
+			h.SetWalletLimits(rec, req)
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("got status %d, want 400", rec.Code)
+			}
+		})
+	}
+
+	t.Run("get unknown wallet", func(t *testing.T) {
+		h := NewWalletHandler(&mockWalletService{walletErr: models.ErrWalletNotFound}, 30*time.Second)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/wallets/"+walletID+"/limits", nil)
+		req.SetPathValue("id", walletID)
+		rec := httptest.NewRecorder()
+
+		h.GetWalletLimits(rec, req)
+
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("got status %d, want 404", rec.Code)
+		}
+	})
+
+	t.Run("limit exceeded on update", func(t *testing.T) {
+		err := fmt.Errorf("%w: %s (limit 20)", models.ErrLimitExceeded, models.LimitRuleMaxWithdrawalsPerHour)
+		h := NewWalletHandler(&mockWalletService{updateBalanceErr: err}, 30*time.Second)
+		body := []byte(`{"walletId":"` + walletID + `","operationType":"WITHDRAW","amount":10}`)
+		rec := httptest.NewRecorder()
+
+		h.UpdateWalletBalance(rec, httptest.NewRequest(http.MethodPost, "/api/v1/wallet", bytes.NewReader(body)))
+
+		if rec.Code != http.StatusUnprocessableEntity {
+			t.Errorf("got status %d, want 422", rec.Code)
+		}
+		if !bytes.Contains(rec.Body.Bytes(), []byte("limit_exceeded: max_withdrawals_per_hour")) {
+			t.Errorf("got body %q", rec.Body.String())
+		}
+	})
+}
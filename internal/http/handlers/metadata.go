@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"test-psql/internal/http/dto"
+	"test-psql/internal/models"
+	"test-psql/pkg/logger"
+)
+
+func (h *WalletHandler) SetWalletMetadata(w http.ResponseWriter, r *http.Request) {
+	logger.Info("PUT /api/v1/wallets/{id}/metadata")
+	if r.Method != http.MethodPut {
+		logger.Error(fmt.Sprintf("method not allowed: %s", r.Method))
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	var req dto.WalletMetadata
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error(fmt.Sprintf("invalid request body: %v", err))
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		logger.Error(fmt.Sprintf("validation error: %v", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	wallet, err := h.service.SetWalletMetadata(ctx, r.PathValue("id"), models.WalletMetadata{
+		OwnerID: req.OwnerID,
+		Name:    req.Name,
+		Labels:  req.Labels,
+	})
+	if err != nil {
+		writeServiceError(ctx, w, err, "set wallet metadata")
+		return
+	}
+
+	writeWallet(w, http.StatusOK, wallet)
+	logger.Info(fmt.Sprintf("wallet metadata set: walletId=%s", wallet.ID))
+}
+
+func (h *WalletHandler) ListWallets(w http.ResponseWriter, r *http.Request) {
+	logger.Info("GET /api/v1/wallets")
+	if r.Method != http.MethodGet {
+		logger.Error(fmt.Sprintf("method not allowed: %s", r.Method))
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	query := r.URL.Query()
+	req := dto.ListWalletsRequest{OwnerID: query.Get("owner_id"), Labels: query["label"]}
+	var err error
+	if req.Limit, err = queryInt(r, "limit", dto.DefaultWalletsLimit); err != nil {
+		logger.Error(fmt.Sprintf("validation error: %v", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := req.Parse(query.Get("sort"), query.Get("cursor")); err != nil {
+		logger.Error(fmt.Sprintf("validation error: %v", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		logger.Error(fmt.Sprintf("validation error: %v", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Запрашиваем на один кошелёк больше, чтобы понять, есть ли следующая страница
+	q := models.WalletListQuery{
+		OwnerID: req.OwnerID,
+		Labels:  req.Labels,
+		Sort:    req.Sort,
+		Desc:    req.Desc,
+		Limit:   req.Limit + 1,
+	}
+	if c := req.Cursor; c != nil {
+		q.After = &models.WalletCursor{Balance: c.Balance, CreatedAt: c.CreatedAt, ID: c.ID}
+	}
+	wallets, err := h.service.ListWallets(ctx, q)
+	if err != nil {
+		writeServiceError(ctx, w, err, "list wallets")
+		return
+	}
+
+	response := dto.ListWalletsResponse{Wallets: make([]dto.WalletResponse, 0, len(wallets))}
+	if len(wallets) > req.Limit {
+		wallets = wallets[:req.Limit]
+		last := models.CursorOf(wallets[len(wallets)-1], req.Sort)
+		response.NextCursor = dto.WalletsCursor{Sort: req.SortParam(), Balance: last.Balance, CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	for _, wallet := range wallets {
+		response.Wallets = append(response.Wallets, walletResponse(wallet))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+	logger.Info(fmt.Sprintf("wallets listed: count=%d", len(response.Wallets)))
+}
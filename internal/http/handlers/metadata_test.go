@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"test-psql/internal/http/dto"
+	"test-psql/internal/models"
+)
+
+func TestWalletHandler_ListWallets(t *testing.T) {
+	created := time.Date(2026, 10, 1, 12, 0, 0, 123456000, time.UTC)
+	wallets := []models.Wallet{
+		{ID: uuid.New(), Balance: 300, CreatedAt: created, WalletMetadata: models.WalletMetadata{OwnerID: "c1", Labels: models.Labels{"vip"}}},
+		{ID: uuid.New(), Balance: 200, CreatedAt: created.Add(-time.Second)},
+		{ID: uuid.New(), Balance: 100, CreatedAt: created.Add(-2 * time.Second)},
+	}
+	do := func(svc *mockWalletService, query string) *httptest.ResponseRecorder {
+		h := NewWalletHandler(svc, 30*time.Second)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets?"+query, nil)
+		rec := httptest.NewRecorder()
+		h.ListWallets(rec, req)
+		return rec
+	}
+
+	t.Run("first page", func(t *testing.T) {
+		svc := &mockWalletService{wallets: wallets}
+		rec := do(svc, "owner_id=c1&label=vip&label=eu&sort=-balance&limit=2")
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("got status %d, want 200", rec.Code)
+		}
+		q := svc.gotQuery
+		if q.OwnerID != "c1" || len(q.Labels) != 2 || q.Sort != models.WalletSortBalance || !q.Desc || q.Limit != 3 || q.After != nil {
+			t.Errorf("unexpected query: %+v", q)
+		}
+		var res dto.ListWalletsResponse
+		if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Wallets) != 2 || res.Wallets[0].OwnerID != "c1" || res.Wallets[0].Labels[0] != "vip" || res.Wallets[1].Labels == nil {
+			t.Fatalf("unexpected wallets: %+v", res.Wallets)
+		}
+		cursor, err := dto.DecodeWalletsCursor(res.NextCursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cursor.ID != wallets[1].ID || cursor.Balance != 200 || cursor.Sort != "-balance" {
+			t.Errorf("unexpected cursor: %+v", cursor)
+		}
+	})
+
+	t.Run("next page", func(t *testing.T) {
+		cursor := dto.WalletsCursor{Sort: "-created_at", CreatedAt: created, ID: wallets[0].ID}.Encode()
+		svc := &mockWalletService{wallets: wallets[1:]}
+		rec := do(svc, "cursor="+cursor)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("got status %d, want 200", rec.Code)
+		}
+		after := svc.gotQuery.After
+		if after == nil || after.ID != wallets[0].ID || !after.CreatedAt.Equal(created) {
+			t.Errorf("unexpected cursor: %+v", after)
+		}
+		if strings.Contains(rec.Body.String(), "nextCursor") {
+			t.Error("last page must not have a next cursor")
+		}
+	})
+
+	badRequests := map[string]string{
+		"unknown sort":         "sort=name",
+		"limit too large":      "limit=501",
+		"garbled cursor":       "cursor=abc",
+		"cursor of other sort": "sort=balance&cursor=" + dto.WalletsCursor{Sort: "-created_at", ID: uuid.New()}.Encode(),
+		"empty label":          "label=",
+	}
+	for name, query := range badRequests {
+		t.Run(name, func(t *testing.T) {
+			if rec := do(&mockWalletService{}, query); rec.Code != http.StatusBadRequest {
+				t.Errorf("got status %d, want 400", rec.Code)
+			}
+		})
+	}
+}
+
+func TestWalletHandler_SetWalletMetadata(t *testing.T) {
+	const walletID = "550e8400-e29b-41d4-a716-446655440000"
+	do := func(svc *mockWalletService, body string) *httptest.ResponseRecorder {
+		h := NewWalletHandler(svc, 30*time.Second)
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/wallets/"+walletID+"/metadata", bytes.NewReader([]byte(body)))
+		req.SetPathValue("id", walletID)
+		rec := httptest.NewRecorder()
+		h.SetWalletMetadata(rec, req)
+		return rec
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		svc := &mockWalletService{wallet: models.Wallet{ID: uuid.MustParse(walletID)}}
+		rec := do(svc, `{"ownerId":"c1","name":"Main","labels":["vip"]}`)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("got status %d, want 200", rec.Code)
+		}
+		got := svc.gotMetadata
+		if svc.gotWalletID != walletID || got.OwnerID != "c1" || got.Name != "Main" || len(got.Labels) != 1 {
+			t.Errorf("unexpected metadata: %+v", got)
+		}
+	})
+
+	t.Run("unknown wallet", func(t *testing.T) {
+		if rec := do(&mockWalletService{walletErr: models.ErrWalletNotFound}, `{}`); rec.Code != http.StatusNotFound {
+			t.Errorf("got status %d, want 404", rec.Code)
+		}
+	})
+
+	t.Run("label too long", func(t *testing.T) {
+		body := `{"labels":["` + strings.Repeat("x", 65) + `"]}`
+		if rec := do(&mockWalletService{}, body); rec.Code != http.StatusBadRequest {
+			t.Errorf("got status %d, want 400", rec.Code)
+		}
+	})
+}
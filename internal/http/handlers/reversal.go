@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"test-psql/internal/http/dto"
+	"test-psql/pkg/logger"
+)
+
+func (h *WalletHandler) ReverseOperation(w http.ResponseWriter, r *http.Request) {
+	logger.Info("POST /api/v1/operations/{id}/reverse")
+	if r.Method != http.MethodPost {
+		logger.Error(fmt.Sprintf("method not allowed: %s", r.Method))
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	// Тело необязательно: без amount операция отменяется целиком
+	var req dto.ReverseOperationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		logger.Error(fmt.Sprintf("invalid request body: %v", err))
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		logger.Error(fmt.Sprintf("validation error: %v", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reversal, err := h.service.ReverseOperation(ctx, r.PathValue("id"), req.Amount)
+	if err != nil {
+		writeServiceError(ctx, w, err, "reverse operation")
+		return
+	}
+
+	response := dto.ReversalResponse{
+		ReversalID:   reversal.ID.String(),
+		OperationID:  reversal.ReversalOf.String(),
+		WalletID:     reversal.WalletID.String(),
+		Type:         reversal.Type,
+		Amount:       reversal.Amount,
+		BalanceAfter: reversal.BalanceAfter,
+		CreatedAt:    reversal.CreatedAt,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+	logger.Info(fmt.Sprintf("operation reversed: operationId=%s reversalId=%s amount=%d", response.OperationID, response.ReversalID, reversal.Amount))
+}
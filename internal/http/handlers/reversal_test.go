@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"test-psql/internal/http/dto"
+	"test-psql/internal/models"
+)
+
+func TestWalletHandler_ReverseOperation(t *testing.T) {
+	operationID := uuid.New()
+
+	do := func(svc *mockWalletService, body string) *httptest.ResponseRecorder {
+		h := NewWalletHandler(svc, 30*time.Second)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/operations/"+operationID.String()+"/reverse", bytes.NewReader([]byte(body)))
+		req.SetPathValue("id", operationID.String())
+		rec := httptest.NewRecorder()
+		h.ReverseOperation(rec, req)
+		return rec
+	}
+
+	t.Run("full reversal without body", func(t *testing.T) {
+		svc := &mockWalletService{reversal: models.Transaction{
+			ID:           uuid.New(),
+			WalletID:     uuid.New(),
+			Type:         "REVERSAL_OUT",
+			Amount:       300,
+			BalanceAfter: 700,
+			ReversalOf:   &operationID,
+		}}
+		rec := do(svc, "")
+
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("got status %d, want 201", rec.Code)
+		}
+		if svc.gotOperationID != operationID.String() || svc.gotAmount != 0 {
+			t.Errorf("got operation %s amount %d", svc.gotOperationID, svc.gotAmount)
+		}
+		var res dto.ReversalResponse
+		if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		if res.OperationID != operationID.String() || res.Type != "REVERSAL_OUT" || res.Amount != 300 || res.BalanceAfter != 700 {
+			t.Errorf("unexpected response: %+v", res)
+		}
+	})
+
+	t.Run("partial reversal", func(t *testing.T) {
+		svc := &mockWalletService{reversal: models.Transaction{ReversalOf: &operationID}}
+		if rec := do(svc, `{"amount":120}`); rec.Code != http.StatusCreated {
+			t.Fatalf("got status %d, want 201", rec.Code)
+		}
+		if svc.gotAmount != 120 {
+			t.Errorf("got amount %d, want 120", svc.gotAmount)
+		}
+	})
+
+	cases := map[string]struct {
+		body string
+		err  error
+		want int
+	}{
+		"negative amount":        {`{"amount":-1}`, nil, http.StatusBadRequest},
+		"unknown operation":      {"", models.ErrOperationNotFound, http.StatusNotFound},
+		"already reversed":       {"", models.ErrAlreadyReversed, http.StatusConflict},
+		"over original amount":   {`{"amount":500}`, models.ErrReversalExceedsOp, http.StatusUnprocessableEntity},
+		"transfer":               {"", models.ErrNotReversible, http.StatusUnprocessableEntity},
+		"deposit would overdraw": {"", models.ErrInsufficientBalance, http.StatusConflict},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if rec := do(&mockWalletService{reversalErr: tc.err}, tc.body); rec.Code != tc.want {
+				t.Errorf("got status %d, want %d", rec.Code, tc.want)
+			}
+		})
+	}
+}
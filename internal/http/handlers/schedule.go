@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"test-psql/internal/http/dto"
+	"test-psql/internal/models"
+	"test-psql/pkg/logger"
+)
+
+func (h *WalletHandler) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	logger.Info("POST /api/v1/schedules")
+	if r.Method != http.MethodPost {
+		logger.Error(fmt.Sprintf("method not allowed: %s", r.Method))
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	var req dto.CreateScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field == "amount" {
+			logger.Error(fmt.Sprintf("invalid amount type: %v", err))
+			http.Error(w, "amount must be a number", http.StatusBadRequest)
+			return
+		}
+		logger.Error(fmt.Sprintf("invalid request body: %v", err))
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		logger.Error(fmt.Sprintf("validation error: %v", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Cron != "" {
+		// Разбор спецификации проверяется здесь, чтобы ответить 400
+		if _, err := models.ParseCron(req.Cron); err != nil {
+			logger.Error(fmt.Sprintf("validation error: %v", err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	sched := models.Schedule{
+		WalletID:      uuid.MustParse(req.WalletID),
+		OperationType: req.OperationType,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		Cron:          req.Cron,
+	}
+	if req.ToWalletID != "" {
+		toID := uuid.MustParse(req.ToWalletID)
+		sched.ToWalletID = &toID
+	}
+	var runAt time.Time
+	if req.RunAt != nil {
+		runAt = *req.RunAt
+	}
+
+	sched, err := h.service.CreateSchedule(ctx, sched, runAt)
+	if err != nil {
+		writeServiceError(ctx, w, err, "create schedule")
+		return
+	}
+
+	writeSchedule(w, http.StatusCreated, sched)
+	logger.Info(fmt.Sprintf("schedule created: id=%s walletId=%s nextRunAt=%s", sched.ID, sched.WalletID, sched.NextRunAt))
+}
+
+func (h *WalletHandler) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	logger.Info("GET /api/v1/schedules/{id}")
+	if r.Method != http.MethodGet {
+		logger.Error(fmt.Sprintf("method not allowed: %s", r.Method))
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	sched, err := h.service.GetSchedule(ctx, r.PathValue("id"))
+	if err != nil {
+		writeServiceError(ctx, w, err, "get schedule")
+		return
+	}
+
+	writeSchedule(w, http.StatusOK, sched)
+	logger.Info(fmt.Sprintf("schedule retrieved: id=%s", sched.ID))
+}
+
+func (h *WalletHandler) ListSchedules(w http.ResponseWriter, r *http.Request) {
+	logger.Info("GET /api/v1/wallets/{id}/schedules")
+	if r.Method != http.MethodGet {
+		logger.Error(fmt.Sprintf("method not allowed: %s", r.Method))
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	req := dto.ListSchedulesRequest{WalletID: r.PathValue("id")}
+	var err error
+	if req.Limit, err = queryInt(r, "limit", dto.DefaultSchedulesLimit); err != nil {
+		logger.Error(fmt.Sprintf("validation error: %v", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Offset, err = queryInt(r, "offset", 0); err != nil {
+		logger.Error(fmt.Sprintf("validation error: %v", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		logger.Error(fmt.Sprintf("validation error: %v", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	schedules, err := h.service.ListSchedules(ctx, req.WalletID, req.Limit, req.Offset)
+	if err != nil {
+		writeServiceError(ctx, w, err, "list schedules")
+		return
+	}
+
+	response := dto.ListSchedulesResponse{
+		WalletID:  req.WalletID,
+		Schedules: make([]dto.ScheduleResponse, 0, len(schedules)),
+		Limit:     req.Limit,
+		Offset:    req.Offset,
+	}
+	for _, sched := range schedules {
+		response.Schedules = append(response.Schedules, scheduleResponse(sched))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+	logger.Info(fmt.Sprintf("schedules retrieved: walletId=%s count=%d", req.WalletID, len(schedules)))
+}
+
+func (h *WalletHandler) PauseSchedule(w http.ResponseWriter, r *http.Request) {
+	h.changeScheduleStatus(w, r, "pause", h.service.PauseSchedule)
+}
+
+func (h *WalletHandler) ResumeSchedule(w http.ResponseWriter, r *http.Request) {
+	h.changeScheduleStatus(w, r, "resume", h.service.ResumeSchedule)
+}
+
+func (h *WalletHandler) CancelSchedule(w http.ResponseWriter, r *http.Request) {
+	h.changeScheduleStatus(w, r, "cancel", h.service.CancelSchedule)
+}
+
+func (h *WalletHandler) changeScheduleStatus(w http.ResponseWriter, r *http.Request, action string,
+	change func(ctx context.Context, scheduleID string) (models.Schedule, error)) {
+	logger.Info(fmt.Sprintf("POST /api/v1/schedules/{id}/%s", action))
+	if r.Method != http.MethodPost {
+		logger.Error(fmt.Sprintf("method not allowed: %s", r.Method))
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	sched, err := change(ctx, r.PathValue("id"))
+	if err != nil {
+		writeServiceError(ctx, w, err, action+" schedule")
+		return
+	}
+
+	writeSchedule(w, http.StatusOK, sched)
+	logger.Info(fmt.Sprintf("schedule %s: id=%s status=%s", action, sched.ID, sched.Status))
+}
+
+func scheduleResponse(sched models.Schedule) dto.ScheduleResponse {
+	response := dto.ScheduleResponse{
+		ScheduleID:    sched.ID.String(),
+		WalletID:      sched.WalletID.String(),
+		OperationType: sched.OperationType,
+		Amount:        sched.Amount,
+		Currency:      sched.Currency,
+		Cron:          sched.Cron,
+		Status:        string(sched.Status),
+		NextRunAt:     sched.NextRunAt,
+		RunCount:      sched.RunCount,
+		LastRunAt:     sched.LastRunAt,
+		LastError:     sched.LastError,
+		CreatedAt:     sched.CreatedAt,
+	}
+	if sched.ToWalletID != nil {
+		response.ToWalletID = sched.ToWalletID.String()
+	}
+	if sched.LastOperationID != nil {
+		response.LastOperationID = sched.LastOperationID.String()
+	}
+	return response
+}
+
+func writeSchedule(w http.ResponseWriter, status int, sched models.Schedule) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(scheduleResponse(sched))
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"test-psql/internal/http/dto"
+	"test-psql/internal/models"
+)
+
+func TestWalletHandler_CreateSchedule(t *testing.T) {
+	walletID, toWalletID := uuid.New(), uuid.New()
+	do := func(svc *mockWalletService, body map[string]any) *httptest.ResponseRecorder {
+		h := NewWalletHandler(svc, 30*time.Second)
+		data, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/schedules", bytes.NewReader(data))
+		rec := httptest.NewRecorder()
+		h.CreateSchedule(rec, req)
+		return rec
+	}
+
+	t.Run("recurring transfer", func(t *testing.T) {
+		next := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+		svc := &mockWalletService{schedule: models.Schedule{
+			ID:            uuid.New(),
+			WalletID:      walletID,
+			ToWalletID:    &toWalletID,
+			OperationType: "TRANSFER",
+			Amount:        500000,
+			Cron:          "@monthly",
+			Status:        models.ScheduleStatusActive,
+			NextRunAt:     &next,
+		}}
+		rec := do(svc, map[string]any{
+			"walletId":      walletID.String(),
+			"toWalletId":    toWalletID.String(),
+			"operationType": "TRANSFER",
+			"amount":        500000,
+			"cron":          "@monthly",
+		})
+
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("got status %d, want 201: %s", rec.Code, rec.Body)
+		}
+		got := svc.gotSchedule
+		if got.WalletID != walletID || got.ToWalletID == nil || *got.ToWalletID != toWalletID || got.Cron != "@monthly" || !svc.gotRunAt.IsZero() {
+			t.Errorf("unexpected schedule: %+v", got)
+		}
+		var res dto.ScheduleResponse
+		if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		if res.Status != "ACTIVE" || res.NextRunAt == nil || !res.NextRunAt.Equal(next) || res.ToWalletID != toWalletID.String() {
+			t.Errorf("unexpected response: %+v", res)
+		}
+	})
+
+	t.Run("one-off deposit", func(t *testing.T) {
+		runAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+		svc := &mockWalletService{}
+		rec := do(svc, map[string]any{
+			"walletId":      walletID.String(),
+			"operationType": "DEPOSIT",
+			"amount":        1000,
+			"runAt":         runAt.Format(time.RFC3339),
+		})
+
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("got status %d, want 201: %s", rec.Code, rec.Body)
+		}
+		if !svc.gotRunAt.Equal(runAt) || svc.gotSchedule.ToWalletID != nil {
+			t.Errorf("unexpected schedule: %+v at %s", svc.gotSchedule, svc.gotRunAt)
+		}
+	})
+
+	future := time.Now().Add(time.Hour).Format(time.RFC3339)
+	badRequests := map[string]map[string]any{
+		"runAt and cron":    {"walletId": walletID.String(), "operationType": "DEPOSIT", "amount": 1, "cron": "@daily", "runAt": future},
+		"neither":           {"walletId": walletID.String(), "operationType": "DEPOSIT", "amount": 1},
+		"past runAt":        {"walletId": walletID.String(), "operationType": "DEPOSIT", "amount": 1, "runAt": "2020-01-01T00:00:00Z"},
+		"bad cron":          {"walletId": walletID.String(), "operationType": "DEPOSIT", "amount": 1, "cron": "61 * * * *"},
+		"deposit recipient": {"walletId": walletID.String(), "toWalletId": toWalletID.String(), "operationType": "DEPOSIT", "amount": 1, "cron": "@daily"},
+		"transfer to self":  {"walletId": walletID.String(), "toWalletId": walletID.String(), "operationType": "TRANSFER", "amount": 1, "cron": "@daily"},
+		"withdraw":          {"walletId": walletID.String(), "operationType": "WITHDRAW", "amount": 1, "cron": "@daily"},
+		"bad wallet":        {"walletId": "abc", "operationType": "DEPOSIT", "amount": 1, "cron": "@daily"},
+	}
+	for name, body := range badRequests {
+		t.Run(name, func(t *testing.T) {
+			svc := &mockWalletService{}
+			if rec := do(svc, body); rec.Code != http.StatusBadRequest {
+				t.Errorf("got status %d, want 400", rec.Code)
+			}
+			if svc.gotSchedule.OperationType != "" {
+				t.Error("service must not be called")
+			}
+		})
+	}
+}
+
+func TestWalletHandler_ListSchedules(t *testing.T) {
+	walletID := uuid.New()
+	lastOp := uuid.New()
+	svc := &mockWalletService{schedules: []models.Schedule{
+		{ID: uuid.New(), WalletID: walletID, OperationType: "DEPOSIT", Status: models.ScheduleStatusCompleted, RunCount: 1, LastOperationID: &lastOp},
+	}}
+	h := NewWalletHandler(svc, 30*time.Second)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets/"+walletID.String()+"/schedules?limit=10&offset=5", nil)
+	req.SetPathValue("id", walletID.String())
+	rec := httptest.NewRecorder()
+	h.ListSchedules(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want 200", rec.Code)
+	}
+	if svc.gotWalletID != walletID.String() || svc.gotLimit != 10 || svc.gotOffset != 5 {
+		t.Errorf("unexpected query: %s %d %d", svc.gotWalletID, svc.gotLimit, svc.gotOffset)
+	}
+	var res dto.ListSchedulesResponse
+	if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Schedules) != 1 || res.Schedules[0].LastOperationID != lastOp.String() || res.Schedules[0].NextRunAt != nil {
+		t.Errorf("unexpected response: %+v", res)
+	}
+}
+
+func TestWalletHandler_ChangeScheduleStatus(t *testing.T) {
+	scheduleID := uuid.New().String()
+	cases := []struct {
+		name   string
+		call   func(h *WalletHandler) http.HandlerFunc
+		err    error
+		status int
+	}{
+		{"pause", func(h *WalletHandler) http.HandlerFunc { return h.PauseSchedule }, nil, http.StatusOK},
+		{"resume", func(h *WalletHandler) http.HandlerFunc { return h.ResumeSchedule }, nil, http.StatusOK},
+		{"cancel", func(h *WalletHandler) http.HandlerFunc { return h.CancelSchedule }, nil, http.StatusOK},
+		{"cancel finished", func(h *WalletHandler) http.HandlerFunc { return h.CancelSchedule }, models.ErrScheduleTransition, http.StatusConflict},
+		{"unknown schedule", func(h *WalletHandler) http.HandlerFunc { return h.PauseSchedule }, models.ErrScheduleNotFound, http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &mockWalletService{scheduleErr: tc.err}
+			h := NewWalletHandler(svc, 30*time.Second)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/schedules/"+scheduleID, nil)
+			req.SetPathValue("id", scheduleID)
+			rec := httptest.NewRecorder()
+			tc.call(h)(rec, req)
+
+			if rec.Code != tc.status {
+				t.Errorf("got status %d, want %d", rec.Code, tc.status)
+			}
+			if svc.gotScheduleID != scheduleID {
+				t.Errorf("got schedule id %q", svc.gotScheduleID)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"test-psql/internal/http/dto"
+	"test-psql/internal/models"
+	"test-psql/pkg/logger"
+)
+
+// GetWalletStatement streams the statement as it is read from the ledger.
+// The status and headers are sent with the opening balance; an error after
+// that ends the body early, without the closing balance line.
+func (h *WalletHandler) GetWalletStatement(w http.ResponseWriter, r *http.Request) {
+	logger.Info("GET /api/v1/wallets/{id}/statement")
+	if r.Method != http.MethodGet {
+		logger.Error(fmt.Sprintf("method not allowed: %s", r.Method))
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	req := dto.GetWalletStatementRequest{WalletID: r.PathValue("id")}
+	query := r.URL.Query()
+	if err := req.Parse(query.Get("from"), query.Get("to"), query.Get("format")); err != nil {
+		logger.Error(fmt.Sprintf("validation error: %v", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		logger.Error(fmt.Sprintf("validation error: %v", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Выписка может идти дольше REQUEST_TIMEOUT и WriteTimeout сервера,
+	// поэтому ограничена только отменой запроса клиентом
+	ctx := r.Context()
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	var out statementWriter
+	if req.Format == dto.StatementFormatJSONL {
+		out = &jsonlStatement{enc: json.NewEncoder(w)}
+	} else {
+		out = &csvStatement{w: csv.NewWriter(w)}
+	}
+
+	started := false
+	open := func(st models.Statement) error {
+		started = true
+		w.Header().Set("Content-Type", out.contentType())
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="statement-%s.%s"`, st.WalletID, req.Format))
+		w.WriteHeader(http.StatusOK)
+		return out.opening(st)
+	}
+	rows := 0
+	row := func(tx models.Transaction) error {
+		rows++
+		return out.operation(tx)
+	}
+
+	st, err := h.service.StreamStatement(ctx, req.WalletID, req.From, req.To, open, row)
+	if err == nil {
+		err = out.closing(st)
+	}
+	if err != nil {
+		if started {
+			logger.Error(fmt.Sprintf("statement aborted after %d rows: %v", rows, err))
+			return
+		}
+		writeServiceError(ctx, w, err, "get statement")
+		return
+	}
+	logger.Info(fmt.Sprintf("statement sent: walletId=%s rows=%d", req.WalletID, rows))
+}
+
+// statementWriter renders statement lines in one of the export formats.
+type statementWriter interface {
+	contentType() string
+	opening(st models.Statement) error
+	operation(tx models.Transaction) error
+	closing(st models.Statement) error
+}
+
+type csvStatement struct {
+	w        *csv.Writer
+	currency string
+}
+
+func (s *csvStatement) contentType() string { return "text/csv; charset=utf-8" }
+
+func (s *csvStatement) opening(st models.Statement) error {
+	s.currency = st.Currency
+	if err := s.w.Write(dto.StatementCSVHeader); err != nil {
+		return err
+	}
+	return s.write(dto.StatementOpening, st.From, "", "", "", st.OpeningBalance)
+}
+
+func (s *csvStatement) operation(tx models.Transaction) error {
+	amount := strconv.FormatInt(tx.SignedAmount(), 10)
+	return s.write(dto.StatementOperation, tx.CreatedAt, tx.ID.String(), tx.Type, amount, tx.BalanceAfter)
+}
+
+func (s *csvStatement) closing(st models.Statement) error {
+	if err := s.write(dto.StatementClosing, st.To, "", "", "", st.ClosingBalance); err != nil {
+		return err
+	}
+	s.w.Flush()
+	return s.w.Error()
+}
+
+func (s *csvStatement) write(record string, date time.Time, id, txType, amount string, balance int64) error {
+	return s.w.Write([]string{
+		record,
+		date.UTC().Format(time.RFC3339Nano),
+		id,
+		txType,
+		amount,
+		strconv.FormatInt(balance, 10),
+		s.currency,
+	})
+}
+
+type jsonlStatement struct {
+	enc      *json.Encoder
+	currency string
+}
+
+func (s *jsonlStatement) contentType() string { return "application/jsonl" }
+
+func (s *jsonlStatement) opening(st models.Statement) error {
+	s.currency = st.Currency
+	exponent := st.CurrencyExponent
+	return s.enc.Encode(dto.StatementLine{
+		Record:           dto.StatementOpening,
+		Date:             st.From,
+		WalletID:         st.WalletID.String(),
+		Balance:          st.OpeningBalance,
+		Currency:         st.Currency,
+		CurrencyExponent: &exponent,
+	})
+}
+
+func (s *jsonlStatement) operation(tx models.Transaction) error {
+	return s.enc.Encode(dto.StatementLine{
+		Record:      dto.StatementOperation,
+		Date:        tx.CreatedAt,
+		OperationID: tx.ID.String(),
+		Type:        tx.Type,
+		Amount:      tx.SignedAmount(),
+		Balance:     tx.BalanceAfter,
+		Currency:    s.currency,
+	})
+}
+
+func (s *jsonlStatement) closing(st models.Statement) error {
+	return s.enc.Encode(dto.StatementLine{
+		Record:   dto.StatementClosing,
+		Date:     st.To,
+		Balance:  st.ClosingBalance,
+		Currency: s.currency,
+	})
+}
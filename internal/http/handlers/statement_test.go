@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"test-psql/internal/http/dto"
+	"test-psql/internal/models"
+)
+
+func TestWalletHandler_GetWalletStatement(t *testing.T) {
+	walletID := uuid.New()
+	from := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	newService := func() *mockWalletService {
+		return &mockWalletService{
+			statement: models.Statement{WalletID: walletID, Currency: "RUB", CurrencyExponent: 2, From: from, To: to, OpeningBalance: 1000},
+			txs: []models.Transaction{
+				{ID: uuid.New(), Type: "DEPOSIT", Amount: 500, BalanceAfter: 1500, CreatedAt: from.Add(time.Hour)},
+				{ID: uuid.New(), Type: "WITHDRAW", Amount: 200, BalanceAfter: 1300, CreatedAt: from.Add(2 * time.Hour)},
+			},
+		}
+	}
+	do := func(svc *mockWalletService, query string) *httptest.ResponseRecorder {
+		h := NewWalletHandler(svc, 30*time.Second)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets/"+walletID.String()+"/statement?"+query, nil)
+		req.SetPathValue("id", walletID.String())
+		rec := httptest.NewRecorder()
+		h.GetWalletStatement(rec, req)
+		return rec
+	}
+	const period = "from=2026-09-01T00:00:00Z&to=2026-10-01T00:00:00Z"
+
+	t.Run("csv", func(t *testing.T) {
+		svc := newService()
+		rec := do(svc, period)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("got status %d, want 200", rec.Code)
+		}
+		if !svc.gotFrom.Equal(from) || !svc.gotTo.Equal(to) {
+			t.Errorf("got period %s - %s", svc.gotFrom, svc.gotTo)
+		}
+		records, err := csv.NewReader(rec.Body).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := [][]string{
+			dto.StatementCSVHeader,
+			{dto.StatementOpening, "2026-09-01T00:00:00Z", "", "", "", "1000", "RUB"},
+			{dto.StatementOperation, "2026-09-01T01:00:00Z", svc.txs[0].ID.String(), "DEPOSIT", "500", "1500", "RUB"},
+			{dto.StatementOperation, "2026-09-01T02:00:00Z", svc.txs[1].ID.String(), "WITHDRAW", "-200", "1300", "RUB"},
+			{dto.StatementClosing, "2026-10-01T00:00:00Z", "", "", "", "1300", "RUB"},
+		}
+		if len(records) != len(want) {
+			t.Fatalf("got %d records, want %d: %v", len(records), len(want), records)
+		}
+		for i := range want {
+			for j := range want[i] {
+				if records[i][j] != want[i][j] {
+					t.Errorf("record %d: got %v, want %v", i, records[i], want[i])
+					break
+				}
+			}
+		}
+	})
+
+	t.Run("jsonl", func(t *testing.T) {
+		rec := do(newService(), period+"&format=jsonl")
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("got status %d, want 200", rec.Code)
+		}
+		var lines []dto.StatementLine
+		scanner := bufio.NewScanner(rec.Body)
+		for scanner.Scan() {
+			var line dto.StatementLine
+			if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+				t.Fatal(err)
+			}
+			lines = append(lines, line)
+		}
+		if len(lines) != 4 {
+			t.Fatalf("got %d lines, want 4", len(lines))
+		}
+		if lines[0].Record != dto.StatementOpening || lines[0].CurrencyExponent == nil || *lines[0].CurrencyExponent != 2 {
+			t.Errorf("unexpected opening line: %+v", lines[0])
+		}
+		if lines[2].Amount != -200 || lines[2].Balance != 1300 {
+			t.Errorf("unexpected operation line: %+v", lines[2])
+		}
+		if lines[3].Record != dto.StatementClosing || lines[3].Balance != 1300 {
+			t.Errorf("unexpected closing line: %+v", lines[3])
+		}
+	})
+
+	t.Run("unknown wallet", func(t *testing.T) {
+		rec := do(&mockWalletService{txsErr: models.ErrWalletNotFound}, period)
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("got status %d, want 404", rec.Code)
+		}
+	})
+
+	badRequests := map[string]string{
+		"missing from":   "to=2026-10-01T00:00:00Z",
+		"invalid to":     "from=2026-09-01T00:00:00Z&to=october",
+		"empty period":   "from=2026-10-01T00:00:00Z&to=2026-10-01T00:00:00Z",
+		"unknown format": period + "&format=xlsx",
+	}
+	for name, query := range badRequests {
+		t.Run(name, func(t *testing.T) {
+			if rec := do(newService(), query); rec.Code != http.StatusBadRequest {
+				t.Errorf("got status %d, want 400", rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"test-psql/internal/http/dto"
+	"test-psql/internal/models"
+	"test-psql/pkg/logger"
+)
+
+func (h *WalletHandler) CreateTransfer(w http.ResponseWriter, r *http.Request) {
+	logger.Info("POST /api/v1/transfers")
+	if r.Method != http.MethodPost {
+		logger.Error(fmt.Sprintf("method not allowed: %s", r.Method))
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	var req dto.CreateTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field == "amount" {
+			logger.Error(fmt.Sprintf("invalid amount type: %v", err))
+			http.Error(w, "amount must be a number", http.StatusBadRequest)
+			return
+		}
+		logger.Error(fmt.Sprintf("invalid request body: %v", err))
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		logger.Error(fmt.Sprintf("validation error: %v", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	transferID, err := h.service.Transfer(ctx, models.Operation{
+		WalletID:   req.FromWalletID,
+		ToWalletID: req.ToWalletID,
+		Amount:     req.Amount,
+		Currency:   req.Currency,
+	})
+	if err != nil {
+		writeServiceError(ctx, w, err, "transfer")
+		return
+	}
+
+	response := dto.CreateTransferResponse{
+		TransferID:   transferID.String(),
+		FromWalletID: req.FromWalletID,
+		ToWalletID:   req.ToWalletID,
+		Amount:       req.Amount,
+		Currency:     req.Currency,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+	logger.Info(fmt.Sprintf("transfer completed: id=%s from=%s to=%s amount=%d", transferID, req.FromWalletID, req.ToWalletID, req.Amount))
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"test-psql/internal/models"
+)
+
+func TestWalletHandler_CreateTransfer(t *testing.T) {
+	validBody := []byte(`{"fromWalletId":"550e8400-e29b-41d4-a716-446655440000","toWalletId":"550e8400-e29b-41d4-a716-446655440001","amount":500}`)
+
+	t.Run("ok", func(t *testing.T) {
+		id := uuid.New()
+		h := NewWalletHandler(&mockWalletService{transferID: id}, 30*time.Second)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/transfers", bytes.NewReader(validBody))
+		rec := httptest.NewRecorder()
+
+		h.CreateTransfer(rec, req)
+
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("got status %d, want 201", rec.Code)
+		}
+		var res struct {
+			TransferID string `json:"transferId"`
+			Amount     int64  `json:"amount"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		if res.TransferID != id.String() || res.Amount != 500 {
+			t.Errorf("unexpected response: %+v", res)
+		}
+	})
+
+	t.Run("same wallet", func(t *testing.T) {
+		h := NewWalletHandler(&mockWalletService{}, 30*time.Second)
+		body := []byte(`{"fromWalletId":"550e8400-e29b-41d4-a716-446655440000","toWalletId":"550e8400-e29b-41d4-a716-446655440000","amount":500}`)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/transfers", bytes.NewReader(body))
+		rec := httptest.NewRecorder()
+
+		h.CreateTransfer(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("got status %d, want 400", rec.Code)
+		}
+	})
+
+	errCases := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"insufficient balance", models.ErrInsufficientBalance, http.StatusConflict},
+		{"wallet not found", models.ErrWalletNotFound, http.StatusNotFound},
+		{"service error", errors.New("db error"), http.StatusInternalServerError},
+	}
+	for _, tc := range errCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewWalletHandler(&mockWalletService{transferErr: tc.err}, 30*time.Second)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/transfers", bytes.NewReader(validBody))
+			rec := httptest.NewRecorder()
+
+			h.CreateTransfer(rec, req)
+
+			if rec.Code != tc.want {
+				t.Errorf("got status %d, want %d", rec.Code, tc.want)
+			}
+		})
+	}
+}
@@ -7,15 +7,53 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"test-psql/internal/http/dto"
+	"test-psql/internal/models"
 	"test-psql/pkg/logger"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type walletService interface {
-	UpdateBalance(ctx context.Context, walletID string, operationType string, amount int64) error
-	GetBalance(ctx context.Context, walletID string) (int64, error)
+	UpdateBalance(ctx context.Context, op models.Operation) ([]models.FeeCharge, error)
+	UpdateBalances(ctx context.Context, ops []models.Operation) []error
+	ApplyBatch(ctx context.Context, ops []models.Operation) error
+	GetBalance(ctx context.Context, walletID string) (models.Balance, error)
+	GetBalanceAt(ctx context.Context, walletID string, at time.Time) (models.Balance, error)
+	GetTransactions(ctx context.Context, walletID string, limit, offset int) ([]models.Transaction, error)
+	StreamStatement(ctx context.Context, walletID string, from, to time.Time,
+		open func(models.Statement) error, row func(models.Transaction) error) (models.Statement, error)
+	GetIdempotencyKey(ctx context.Context, key string) (*models.IdempotencyKey, error)
+	GetOperationFee(ctx context.Context, operationID uuid.UUID) (int64, error)
+	SaveIdempotentResponse(ctx context.Context, key, requestHash string, statusCode int, body []byte) (*models.IdempotencyKey, error)
+	Transfer(ctx context.Context, op models.Operation) (uuid.UUID, error)
+	CreateWallet(ctx context.Context, walletID, currency string, meta models.WalletMetadata) (models.Wallet, error)
+	SetWalletMetadata(ctx context.Context, walletID string, meta models.WalletMetadata) (models.Wallet, error)
+	ListWallets(ctx context.Context, q models.WalletListQuery) ([]models.Wallet, error)
+	FreezeWallet(ctx context.Context, walletID string) (models.Wallet, error)
+	UnfreezeWallet(ctx context.Context, walletID string) (models.Wallet, error)
+	CloseWallet(ctx context.Context, walletID string) (models.Wallet, error)
+	SetCreditLimit(ctx context.Context, walletID string, limit int64) (models.Wallet, error)
+	GetWalletLimits(ctx context.Context, walletID string) (models.WalletLimits, error)
+	SetWalletLimits(ctx context.Context, limits models.WalletLimits) (models.WalletLimits, error)
+	CreateHold(ctx context.Context, walletID string, amount int64, currency string, ttl time.Duration) (models.Hold, error)
+	CaptureHold(ctx context.Context, walletID, holdID string, amount int64) (models.Hold, error)
+	VoidHold(ctx context.Context, walletID, holdID string) (models.Hold, error)
+	ReverseOperation(ctx context.Context, operationID string, amount int64) (models.Transaction, error)
+	GetExchangeRates(ctx context.Context) ([]models.ExchangeRate, error)
+	SetExchangeRates(ctx context.Context, rates []models.ExchangeRate) ([]models.ExchangeRate, error)
+	CreateQuote(ctx context.Context, fromWalletID, toWalletID string, amount int64) (models.FXQuote, error)
+	ExecuteQuote(ctx context.Context, quoteID string) (models.FXQuote, error)
+	CreateSchedule(ctx context.Context, sched models.Schedule, runAt time.Time) (models.Schedule, error)
+	GetSchedule(ctx context.Context, scheduleID string) (models.Schedule, error)
+	ListSchedules(ctx context.Context, walletID string, limit, offset int) ([]models.Schedule, error)
+	PauseSchedule(ctx context.Context, scheduleID string) (models.Schedule, error)
+	ResumeSchedule(ctx context.Context, scheduleID string) (models.Schedule, error)
+	CancelSchedule(ctx context.Context, scheduleID string) (models.Schedule, error)
+	GetSystemAccounts(ctx context.Context) ([]models.SystemAccountBalance, error)
 }
 
 type WalletHandler struct {
@@ -60,38 +98,167 @@ func (h *WalletHandler) UpdateWalletBalance(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	if err := h.service.UpdateBalance(ctx, req.WalletID, string(req.OperationType), req.Amount); err != nil {
-		if ctx.Err() == context.DeadlineExceeded {
-			logger.Error("request timeout")
-			http.Error(w, "Request timeout", http.StatusRequestTimeout)
+	idempotencyKey := r.Header.Get(dto.IdempotencyKeyHeader)
+	if err := dto.ValidateIdempotencyKey(idempotencyKey); err != nil {
+		logger.Error(fmt.Sprintf("validation error: %v", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	requestHash := req.Fingerprint()
+
+	if idempotencyKey != "" {
+		stored, err := h.service.GetIdempotencyKey(ctx, idempotencyKey)
+		if err != nil {
+			if ctx.Err() == context.DeadlineExceeded {
+				logger.Error("request timeout")
+				http.Error(w, "Request timeout", http.StatusRequestTimeout)
+				return
+			}
+			logger.Error(fmt.Sprintf("get idempotency key failed: %v", err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if stored != nil && stored.RequestHash != requestHash {
+			logger.Error(fmt.Sprintf("idempotency key reused: key=%s", idempotencyKey))
+			http.Error(w, models.ErrIdempotencyKeyReused.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		if stored != nil && stored.StatusCode != 0 {
+			writeIdempotentReplay(w, stored)
+			logger.Info(fmt.Sprintf("idempotent replay: key=%s status=%d", idempotencyKey, stored.StatusCode))
+			return
+		}
+		if stored != nil && stored.TransactionID == nil {
+			// Ключ занят запросом, операция которого ещё не записана
+			logger.Error(fmt.Sprintf("idempotency key in progress: key=%s", idempotencyKey))
+			http.Error(w, models.ErrIdempotencyKeyInUse.Error(), http.StatusConflict)
+			return
+		}
+		if stored != nil {
+			// Операция применена, но ответ на неё не сохранён (таймаут,
+			// сбой): ответ собирается заново по записанной операции
+			fee, err := h.service.GetOperationFee(ctx, *stored.TransactionID)
+			if err != nil {
+				writeServiceError(ctx, w, err, "get operation fee")
+				return
+			}
+			logger.Info(fmt.Sprintf("idempotent response rebuilt: key=%s", idempotencyKey))
+			h.writeBalanceResponse(ctx, w, req.WalletID, idempotencyKey, requestHash, fee, nil)
 			return
 		}
-		logger.Error(fmt.Sprintf("update balance failed: %v", err))
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
 	}
 
-	balance, err := h.service.GetBalance(ctx, req.WalletID)
+	op := models.Operation{
+		Type:           string(req.OperationType),
+		WalletID:       req.WalletID,
+		Amount:         req.Amount,
+		Currency:       req.Currency,
+		IdempotencyKey: idempotencyKey,
+		RequestHash:    requestHash,
+	}
+	fees, err := h.service.UpdateBalance(ctx, op)
 	if err != nil {
-		if ctx.Err() == context.DeadlineExceeded {
-			logger.Error("request timeout")
-			http.Error(w, "Request timeout", http.StatusRequestTimeout)
+		if idempotencyKey != "" && h.saveIdempotentError(ctx, w, idempotencyKey, requestHash, err) {
 			return
 		}
-		logger.Error(fmt.Sprintf("get balance failed: %v", err))
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeServiceError(ctx, w, err, "update balance")
+		return
+	}
+	h.writeBalanceResponse(ctx, w, req.WalletID, idempotencyKey, requestHash, models.TotalFees(fees), fees)
+}
+
+// writeBalanceResponse answers an applied operation with the wallet's balance
+// and stores the answer under the idempotency key, if there is one. A
+// response that could not be stored is a server error: the operation is
+// applied, and a retry with the key rebuilds the response.
+func (h *WalletHandler) writeBalanceResponse(ctx context.Context, w http.ResponseWriter, walletID, idempotencyKey, requestHash string, fee int64, fees []models.FeeCharge) {
+	balance, err := h.service.GetBalance(ctx, walletID)
+	if err != nil {
+		writeServiceError(ctx, w, err, "get balance")
 		return
 	}
 
 	response := dto.UpdateWalletBalanceResponse{
-		WalletID: req.WalletID,
-		Balance:  balance,
+		WalletID:         walletID,
+		Balance:          balance.Balance,
+		Available:        balance.Available,
+		Currency:         balance.Currency,
+		CurrencyExponent: balance.CurrencyExponent,
+		Fee:              fee,
+	}
+	for _, fee := range fees {
+		response.Fees = append(response.Fees, dto.FeeResponse{Rule: fee.Rule, Amount: fee.Amount})
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
-	logger.Info(fmt.Sprintf("balance updated: walletId=%s balance=%d", req.WalletID, balance))
+	status := http.StatusOK
+	body, err := json.Marshal(response)
+	if err != nil {
+		logger.Error(fmt.Sprintf("encode response failed: %v", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	body = append(body, '\n')
+
+	if idempotencyKey != "" {
+		// Первый сохранённый ответ побеждает: дубликаты из того же батча
+		// получают тот же ответ, что и исходный запрос
+		stored, err := h.service.SaveIdempotentResponse(ctx, idempotencyKey, requestHash, status, body)
+		switch {
+		case err != nil:
+			writeServiceError(ctx, w, err, "save idempotent response")
+			return
+		case stored.RequestHash != requestHash:
+			logger.Error(fmt.Sprintf("idempotency key reused: key=%s", idempotencyKey))
+			http.Error(w, models.ErrIdempotencyKeyReused.Error(), http.StatusUnprocessableEntity)
+			return
+		case stored.StatusCode != 0:
+			status, body = stored.StatusCode, stored.ResponseBody
+		}
+	}
+
+	writeIdempotentResponse(w, status, body)
+	logger.Info(fmt.Sprintf("balance updated: walletId=%s balance=%d", walletID, balance.Balance))
+}
+
+// saveIdempotentError stores a client error of the service under the key so a
+// retry gets the same answer instead of running the operation again. Time
+// outs, server errors and key conflicts are not final and are not stored. It
+// reports whether it wrote the response.
+func (h *WalletHandler) saveIdempotentError(ctx context.Context, w http.ResponseWriter, key, requestHash string, err error) bool {
+	status := serviceErrorStatus(err)
+	if ctx.Err() != nil || status < 400 || status >= 500 || errors.Is(err, models.ErrIdempotencyKeyReused) {
+		return false
+	}
+	logger.Error(fmt.Sprintf("update balance failed: %v", err))
+	stored, saveErr := h.service.SaveIdempotentResponse(ctx, key, requestHash, status, []byte(err.Error()+"\n"))
+	if saveErr != nil {
+		logger.Error(fmt.Sprintf("save idempotent response failed: %v", saveErr))
+		return false
+	}
+	if stored.RequestHash != requestHash || stored.StatusCode == 0 {
+		return false
+	}
+	// Если ключ успел сохранить другой ответ, отдаётся он
+	writeIdempotentResponse(w, stored.StatusCode, stored.ResponseBody)
+	return true
+}
+
+// writeIdempotentResponse writes a stored response: a JSON body for a success
+// and the plain text of the error otherwise.
+func writeIdempotentResponse(w http.ResponseWriter, status int, body []byte) {
+	if status >= 400 {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+func writeIdempotentReplay(w http.ResponseWriter, stored *models.IdempotencyKey) {
+	w.Header().Set("Idempotent-Replayed", "true")
+	writeIdempotentResponse(w, stored.StatusCode, stored.ResponseBody)
 }
 
 func (h *WalletHandler) GetWalletBalance(w http.ResponseWriter, r *http.Request) {
@@ -109,31 +276,155 @@ func (h *WalletHandler) GetWalletBalance(w http.ResponseWriter, r *http.Request)
 	walletID := strings.TrimSuffix(path, "/")
 
 	req := dto.GetWalletBalanceRequest{WalletID: walletID}
+	if err := req.ParseAt(r.URL.Query().Get("at")); err != nil {
+		logger.Error(fmt.Sprintf("validation error: %v", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err := req.Validate(); err != nil {
 		logger.Error(fmt.Sprintf("validation error: %v", err))
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
+	if !req.At.IsZero() {
+		h.getWalletBalanceAt(ctx, w, req)
+		return
+	}
+
 	balance, err := h.service.GetBalance(ctx, req.WalletID)
+	if err != nil {
+		writeServiceError(ctx, w, err, "get balance")
+		return
+	}
+
+	response := dto.GetWalletBalanceResponse{
+		WalletID:         req.WalletID,
+		Balance:          balance.Balance,
+		Available:        balance.Available,
+		CreditLimit:      balance.CreditLimit,
+		RemainingCredit:  balance.RemainingCredit(),
+		Currency:         balance.Currency,
+		CurrencyExponent: balance.CurrencyExponent,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+	logger.Info(fmt.Sprintf("balance retrieved: walletId=%s balance=%d %s", req.WalletID, balance.Balance, balance.Currency))
+}
+
+func (h *WalletHandler) getWalletBalanceAt(ctx context.Context, w http.ResponseWriter, req dto.GetWalletBalanceRequest) {
+	balance, err := h.service.GetBalanceAt(ctx, req.WalletID, req.At)
+	if err != nil {
+		writeServiceError(ctx, w, err, "get balance at")
+		return
+	}
+
+	response := dto.GetWalletBalanceAtResponse{
+		WalletID:         req.WalletID,
+		Balance:          balance.Balance,
+		Currency:         balance.Currency,
+		CurrencyExponent: balance.CurrencyExponent,
+		At:               req.At,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+	logger.Info(fmt.Sprintf("balance retrieved: walletId=%s at=%s balance=%d %s", req.WalletID, req.At.Format(time.RFC3339), balance.Balance, balance.Currency))
+}
+
+func (h *WalletHandler) GetWalletTransactions(w http.ResponseWriter, r *http.Request) {
+	logger.Info("GET /api/v1/wallets/{id}/transactions")
+	if r.Method != http.MethodGet {
+		logger.Error(fmt.Sprintf("method not allowed: %s", r.Method))
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	req := dto.GetWalletTransactionsRequest{WalletID: r.PathValue("id")}
+	var err error
+	if req.Limit, err = queryInt(r, "limit", dto.DefaultTransactionsLimit); err != nil {
+		logger.Error(fmt.Sprintf("validation error: %v", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Offset, err = queryInt(r, "offset", 0); err != nil {
+		logger.Error(fmt.Sprintf("validation error: %v", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		logger.Error(fmt.Sprintf("validation error: %v", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	txs, err := h.service.GetTransactions(ctx, req.WalletID, req.Limit, req.Offset)
 	if err != nil {
 		if ctx.Err() == context.DeadlineExceeded {
 			logger.Error("request timeout")
 			http.Error(w, "Request timeout", http.StatusRequestTimeout)
 			return
 		}
-		logger.Error(fmt.Sprintf("get balance failed: %v", err))
+		logger.Error(fmt.Sprintf("get transactions failed: %v", err))
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	response := dto.GetWalletBalanceResponse{
-		WalletID: req.WalletID,
-		Balance:  balance,
+	response := dto.GetWalletTransactionsResponse{
+		WalletID:     req.WalletID,
+		Transactions: make([]dto.TransactionResponse, 0, len(txs)),
+		Limit:        req.Limit,
+		Offset:       req.Offset,
+	}
+	for _, tx := range txs {
+		item := dto.TransactionResponse{
+			ID:           tx.ID.String(),
+			Type:         tx.Type,
+			Amount:       tx.Amount,
+			BalanceAfter: tx.BalanceAfter,
+			CreatedAt:    tx.CreatedAt,
+		}
+		if tx.TransferID != nil {
+			item.TransferID = tx.TransferID.String()
+		}
+		if tx.CounterpartyWalletID != nil {
+			item.CounterpartyWalletID = tx.CounterpartyWalletID.String()
+		}
+		if tx.HoldID != nil {
+			item.HoldID = tx.HoldID.String()
+		}
+		if tx.ReversalOf != nil {
+			item.ReversalOf = tx.ReversalOf.String()
+		}
+		if tx.FeeFor != nil {
+			item.FeeFor = tx.FeeFor.String()
+		}
+		if tx.FXRate != nil {
+			item.FXRate = string(*tx.FXRate)
+		}
+		response.Transactions = append(response.Transactions, item)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
-	logger.Info(fmt.Sprintf("balance retrieved: walletId=%s balance=%d", req.WalletID, balance))
+	logger.Info(fmt.Sprintf("transactions retrieved: walletId=%s count=%d", req.WalletID, len(txs)))
+}
+
+func queryInt(r *http.Request, name string, def int) (int, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return def, nil
+	}
+	val, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number", name)
+	}
+	return val, nil
 }
@@ -5,24 +5,281 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
+
+	"github.com/google/uuid"
+
+	"test-psql/internal/http/dto"
+	"test-psql/internal/models"
 )
 
 type mockWalletService struct {
 	updateBalanceErr error
+	updateCalls      int
+	gotOp            models.Operation
+	storedKey        *models.IdempotencyKey
+	saveErr          error
+	operationFee     int64
 	getBalanceVal    int64
 	getBalanceErr    error
+	gotAt            time.Time
+	creditLimit      int64
+	gotCreditLimit   int64
+	limits           models.WalletLimits
+	gotLimits        models.WalletLimits
+	txs              []models.Transaction
+	txsErr           error
+	gotLimit         int
+	gotOffset        int
+	transferID       uuid.UUID
+	transferErr      error
+	wallet           models.Wallet
+	walletErr        error
+	gotWalletID      string
+	gotCurrency      string
+	hold             models.Hold
+	holdErr          error
+	gotHoldID        string
+	gotAmount        int64
+	gotTTL           time.Duration
+	systemAccounts   []models.SystemAccountBalance
+	systemErr        error
+	batchErrs        []error
+	batchErr         error
+	gotOps           []models.Operation
+	reversal         models.Transaction
+	reversalErr      error
+	gotOperationID   string
+	statement        models.Statement
+	fees             []models.FeeCharge
+	gotMetadata      models.WalletMetadata
+	wallets          []models.Wallet
+	gotQuery         models.WalletListQuery
+	gotFrom          time.Time
+	gotTo            time.Time
+	rates            []models.ExchangeRate
+	gotRates         []models.ExchangeRate
+	quote            models.FXQuote
+	quoteErr         error
+	gotQuoteID       string
+	schedule         models.Schedule
+	schedules        []models.Schedule
+	scheduleErr      error
+	gotSchedule      models.Schedule
+	gotRunAt         time.Time
+	gotScheduleID    string
+}
+
+func (m *mockWalletService) UpdateBalance(ctx context.Context, op models.Operation) ([]models.FeeCharge, error) {
+	m.updateCalls++
+	m.gotOp = op
+	if m.updateBalanceErr != nil {
+		return nil, m.updateBalanceErr
+	}
+	return m.fees, nil
+}
+
+func (m *mockWalletService) UpdateBalances(ctx context.Context, ops []models.Operation) []error {
+	m.gotOps = ops
+	errs := make([]error, len(ops))
+	copy(errs, m.batchErrs)
+	return errs
+}
+
+func (m *mockWalletService) ApplyBatch(ctx context.Context, ops []models.Operation) error {
+	m.gotOps = ops
+	return m.batchErr
+}
+
+func (m *mockWalletService) ReverseOperation(ctx context.Context, operationID string, amount int64) (models.Transaction, error) {
+	m.gotOperationID, m.gotAmount = operationID, amount
+	return m.reversal, m.reversalErr
+}
+
+func (m *mockWalletService) GetBalance(ctx context.Context, walletID string) (models.Balance, error) {
+	return models.Balance{
+		Balance:          m.getBalanceVal,
+		Available:        m.getBalanceVal + m.creditLimit,
+		CreditLimit:      m.creditLimit,
+		Currency:         "RUB",
+		CurrencyExponent: 2,
+	}, m.getBalanceErr
+}
+
+func (m *mockWalletService) GetBalanceAt(ctx context.Context, walletID string, at time.Time) (models.Balance, error) {
+	m.gotAt = at
+	return models.Balance{Balance: m.getBalanceVal, Currency: "RUB", CurrencyExponent: 2}, m.getBalanceErr
+}
+
+func (m *mockWalletService) GetTransactions(ctx context.Context, walletID string, limit, offset int) ([]models.Transaction, error) {
+	m.gotLimit, m.gotOffset = limit, offset
+	return m.txs, m.txsErr
+}
+
+func (m *mockWalletService) StreamStatement(ctx context.Context, walletID string, from, to time.Time,
+	open func(models.Statement) error, row func(models.Transaction) error) (models.Statement, error) {
+	m.gotWalletID, m.gotFrom, m.gotTo = walletID, from, to
+	if m.txsErr != nil {
+		return models.Statement{}, m.txsErr
+	}
+	st := m.statement
+	if err := open(st); err != nil {
+		return models.Statement{}, err
+	}
+	st.ClosingBalance = st.OpeningBalance
+	for _, tx := range m.txs {
+		if err := row(tx); err != nil {
+			return models.Statement{}, err
+		}
+		st.ClosingBalance = tx.BalanceAfter
+	}
+	return st, nil
+}
+
+func (m *mockWalletService) GetIdempotencyKey(ctx context.Context, key string) (*models.IdempotencyKey, error) {
+	return m.storedKey, nil
+}
+
+func (m *mockWalletService) GetOperationFee(ctx context.Context, operationID uuid.UUID) (int64, error) {
+	return m.operationFee, nil
+}
+
+func (m *mockWalletService) SaveIdempotentResponse(ctx context.Context, key, requestHash string, statusCode int, body []byte) (*models.IdempotencyKey, error) {
+	if m.saveErr != nil {
+		return nil, m.saveErr
+	}
+	if m.storedKey == nil || m.storedKey.StatusCode == 0 {
+		m.storedKey = &models.IdempotencyKey{Key: key, RequestHash: requestHash, StatusCode: statusCode, ResponseBody: body}
+	}
+	return m.storedKey, nil
+}
+
+func (m *mockWalletService) Transfer(ctx context.Context, op models.Operation) (uuid.UUID, error) {
+	m.gotOp = op
+	return m.transferID, m.transferErr
+}
+
+func (m *mockWalletService) CreateWallet(ctx context.Context, walletID, currency string, meta models.WalletMetadata) (models.Wallet, error) {
+	m.gotWalletID = walletID
+	m.gotCurrency = currency
+	m.gotMetadata = meta
+	return m.wallet, m.walletErr
+}
+
+func (m *mockWalletService) SetWalletMetadata(ctx context.Context, walletID string, meta models.WalletMetadata) (models.Wallet, error) {
+	m.gotWalletID, m.gotMetadata = walletID, meta
+	return m.wallet, m.walletErr
+}
+
+func (m *mockWalletService) ListWallets(ctx context.Context, q models.WalletListQuery) ([]models.Wallet, error) {
+	m.gotQuery = q
+	return m.wallets, m.walletErr
+}
+
+func (m *mockWalletService) FreezeWallet(ctx context.Context, walletID string) (models.Wallet, error) {
+	m.gotWalletID = walletID
+	return m.wallet, m.walletErr
 }
 
-func (m *mockWalletService) UpdateBalance(ctx context.Context, walletID, operationType string, amount int64) error {
-	return m.updateBalanceErr
+func (m *mockWalletService) UnfreezeWallet(ctx context.Context, walletID string) (models.Wallet, error) {
+	m.gotWalletID = walletID
+	return m.wallet, m.walletErr
 }
 
-func (m *mockWalletService) GetBalance(ctx context.Context, walletID string) (int64, error) {
-	return m.getBalanceVal, m.getBalanceErr
+func (m *mockWalletService) CloseWallet(ctx context.Context, walletID string) (models.Wallet, error) {
+	m.gotWalletID = walletID
+	return m.wallet, m.walletErr
+}
+
+func (m *mockWalletService) SetCreditLimit(ctx context.Context, walletID string, limit int64) (models.Wallet, error) {
+	m.gotWalletID, m.gotCreditLimit = walletID, limit
+	return m.wallet, m.walletErr
+}
+
+func (m *mockWalletService) GetWalletLimits(ctx context.Context, walletID string) (models.WalletLimits, error) {
+	m.gotWalletID = walletID
+	return m.limits, m.walletErr
+}
+
+func (m *mockWalletService) SetWalletLimits(ctx context.Context, limits models.WalletLimits) (models.WalletLimits, error) {
+	m.gotLimits = limits
+	return limits, m.walletErr
+}
+
+func (m *mockWalletService) CreateHold(ctx context.Context, walletID string, amount int64, currency string, ttl time.Duration) (models.Hold, error) {
+	m.gotWalletID, m.gotAmount, m.gotCurrency, m.gotTTL = walletID, amount, currency, ttl
+	return m.hold, m.holdErr
+}
+
+func (m *mockWalletService) CaptureHold(ctx context.Context, walletID, holdID string, amount int64) (models.Hold, error) {
+	m.gotWalletID, m.gotHoldID, m.gotAmount = walletID, holdID, amount
+	return m.hold, m.holdErr
+}
+
+func (m *mockWalletService) VoidHold(ctx context.Context, walletID, holdID string) (models.Hold, error) {
+	m.gotWalletID, m.gotHoldID = walletID, holdID
+	return m.hold, m.holdErr
+}
+
+func (m *mockWalletService) GetExchangeRates(ctx context.Context) ([]models.ExchangeRate, error) {
+	return m.rates, nil
+}
+
+func (m *mockWalletService) SetExchangeRates(ctx context.Context, rates []models.ExchangeRate) ([]models.ExchangeRate, error) {
+	m.gotRates = rates
+	return rates, nil
+}
+
+func (m *mockWalletService) CreateQuote(ctx context.Context, fromWalletID, toWalletID string, amount int64) (models.FXQuote, error) {
+	m.gotWalletID = fromWalletID
+	m.gotAmount = amount
+	return m.quote, m.quoteErr
+}
+
+func (m *mockWalletService) ExecuteQuote(ctx context.Context, quoteID string) (models.FXQuote, error) {
+	m.gotQuoteID = quoteID
+	return m.quote, m.quoteErr
+}
+
+func (m *mockWalletService) CreateSchedule(ctx context.Context, sched models.Schedule, runAt time.Time) (models.Schedule, error) {
+	m.gotSchedule = sched
+	m.gotRunAt = runAt
+	return m.schedule, m.scheduleErr
+}
+
+func (m *mockWalletService) GetSchedule(ctx context.Context, scheduleID string) (models.Schedule, error) {
+	m.gotScheduleID = scheduleID
+	return m.schedule, m.scheduleErr
+}
+
+func (m *mockWalletService) ListSchedules(ctx context.Context, walletID string, limit, offset int) ([]models.Schedule, error) {
+	m.gotWalletID = walletID
+	m.gotLimit = limit
+	m.gotOffset = offset
+	return m.schedules, m.scheduleErr
+}
+
+func (m *mockWalletService) PauseSchedule(ctx context.Context, scheduleID string) (models.Schedule, error) {
+	m.gotScheduleID = scheduleID
+	return m.schedule, m.scheduleErr
+}
+
+func (m *mockWalletService) ResumeSchedule(ctx context.Context, scheduleID string) (models.Schedule, error) {
+	m.gotScheduleID = scheduleID
+	return m.schedule, m.scheduleErr
+}
+
+func (m *mockWalletService) CancelSchedule(ctx context.Context, scheduleID string) (models.Schedule, error) {
+	m.gotScheduleID = scheduleID
+	return m.schedule, m.scheduleErr
+}
+
+func (m *mockWalletService) GetSystemAccounts(ctx context.Context) ([]models.SystemAccountBalance, error) {
+	return m.systemAccounts, m.systemErr
 }
 
 func TestWalletHandler_UpdateWalletBalance(t *testing.T) {
@@ -57,6 +314,27 @@ func TestWalletHandler_UpdateWalletBalance(t *testing.T) {
 		}
 	})
 
+	t.Run("fee breakdown", func(t *testing.T) {
+		svc := &mockWalletService{getBalanceVal: 900, fees: []models.FeeCharge{{Rule: "flat", Amount: 30}, {Rule: "percent", Amount: 10}}}
+		h := NewWalletHandler(svc, 30*time.Second)
+		body := []byte(`{"walletId":"550e8400-e29b-41d4-a716-446655440000","operationType":"WITHDRAW","amount":1000}`)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/wallet", bytes.NewReader(body))
+		rec := httptest.NewRecorder()
+
+		h.UpdateWalletBalance(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("got status %d, want 200", rec.Code)
+		}
+		var res dto.UpdateWalletBalanceResponse
+		if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		if res.Fee != 40 || len(res.Fees) != 2 || res.Fees[0] != (dto.FeeResponse{Rule: "flat", Amount: 30}) {
+			t.Errorf("unexpected fees: %d %+v", res.Fee, res.Fees)
+		}
+	})
+
 	t.Run("invalid body", func(t *testing.T) {
 		h := NewWalletHandler(&mockWalletService{}, 30*time.Second)
 		req := httptest.NewRequest(http.MethodPost, "/api/v1/wallet", bytes.NewReader([]byte("{")))
@@ -84,6 +362,103 @@ func TestWalletHandler_UpdateWalletBalance(t *testing.T) {
 		}
 	})
 
+	statusCases := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"wallet not found", models.ErrWalletNotFound, http.StatusNotFound},
+		{"insufficient balance", models.ErrInsufficientBalance, http.StatusConflict},
+		{"wallet frozen", models.ErrWalletFrozen, http.StatusLocked},
+		{"wallet closed", models.ErrWalletClosed, http.StatusGone},
+		{"currency mismatch", models.ErrCurrencyMismatch, http.StatusUnprocessableEntity},
+		{"unknown currency", models.ErrUnknownCurrency, http.StatusBadRequest},
+		{"queue closed", models.ErrQueueClosed, http.StatusServiceUnavailable},
+	}
+	for _, tc := range statusCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewWalletHandler(&mockWalletService{updateBalanceErr: tc.err}, 30*time.Second)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/wallet", bytes.NewReader(validBody))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			h.UpdateWalletBalance(rec, req)
+
+			if rec.Code != tc.want {
+				t.Errorf("got status %d, want %d", rec.Code, tc.want)
+			}
+		})
+	}
+
+	t.Run("queue full", func(t *testing.T) {
+		err := fmt.Errorf("queue: %w", &models.QueueFullError{Depth: 120, RetryAfter: 2300 * time.Millisecond})
+		h := NewWalletHandler(&mockWalletService{updateBalanceErr: err}, 30*time.Second)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/wallet", bytes.NewReader(validBody))
+		rec := httptest.NewRecorder()
+
+		h.UpdateWalletBalance(rec, req)
+
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Fatalf("got status %d, want 503", rec.Code)
+		}
+		if got := rec.Header().Get("Retry-After"); got != "3" {
+			t.Errorf("got Retry-After %q, want 3", got)
+		}
+	})
+
+	t.Run("queue full retries after at least a second", func(t *testing.T) {
+		err := &models.QueueFullError{RetryAfter: 100 * time.Millisecond}
+		h := NewWalletHandler(&mockWalletService{updateBalanceErr: err}, 30*time.Second)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/wallet", bytes.NewReader(validBody))
+		rec := httptest.NewRecorder()
+
+		h.UpdateWalletBalance(rec, req)
+
+		if got := rec.Header().Get("Retry-After"); got != "1" {
+			t.Errorf("got Retry-After %q, want 1", got)
+		}
+	})
+
+	t.Run("currency passed to service", func(t *testing.T) {
+		svc := &mockWalletService{}
+		h := NewWalletHandler(svc, 30*time.Second)
+		body := []byte(`{"walletId":"550e8400-e29b-41d4-a716-446655440000","operationType":"DEPOSIT","amount":1000,"currency":"RUB"}`)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/wallet", bytes.NewReader(body))
+		rec := httptest.NewRecorder()
+
+		h.UpdateWalletBalance(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("got status %d, want 200", rec.Code)
+		}
+		if svc.gotOp.Currency != "RUB" {
+			t.Errorf("got currency %q, want RUB", svc.gotOp.Currency)
+		}
+		var res struct {
+			Currency         string `json:"currency"`
+			CurrencyExponent int    `json:"currencyExponent"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		if res.Currency != "RUB" || res.CurrencyExponent != 2 {
+			t.Errorf("unexpected currency in response: %+v", res)
+		}
+	})
+
+	t.Run("malformed currency", func(t *testing.T) {
+		h := NewWalletHandler(&mockWalletService{}, 30*time.Second)
+		body := []byte(`{"walletId":"550e8400-e29b-41d4-a716-446655440000","operationType":"DEPOSIT","amount":1000,"currency":"rub"}`)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/wallet", bytes.NewReader(body))
+		rec := httptest.NewRecorder()
+
+		h.UpdateWalletBalance(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("got status %d, want 400", rec.Code)
+		}
+	})
+
 	t.Run("method not allowed", func(t *testing.T) {
 		h := NewWalletHandler(&mockWalletService{}, 30*time.Second)
 		req := httptest.NewRequest(http.MethodGet, "/api/v1/wallet", nil)
@@ -114,6 +489,183 @@ func TestWalletHandler_UpdateWalletBalance(t *testing.T) {
 	})
 }
 
+func TestWalletHandler_UpdateWalletBalance_Idempotency(t *testing.T) {
+	body := []byte(`{"walletId":"550e8400-e29b-41d4-a716-446655440000","operationType":"DEPOSIT","amount":1000}`)
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/wallet", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", "key-1")
+		return req
+	}
+
+	t.Run("first request stores response", func(t *testing.T) {
+		svc := &mockWalletService{getBalanceVal: 1000}
+		h := NewWalletHandler(svc, 30*time.Second)
+		rec := httptest.NewRecorder()
+
+		h.UpdateWalletBalance(rec, newRequest())
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("got status %d, want 200", rec.Code)
+		}
+		if svc.gotOp.IdempotencyKey != "key-1" || svc.gotOp.RequestHash == "" {
+			t.Errorf("idempotency key not passed to service: %+v", svc.gotOp)
+		}
+		if svc.storedKey == nil || string(svc.storedKey.ResponseBody) != rec.Body.String() {
+			t.Errorf("response not stored: %+v", svc.storedKey)
+		}
+	})
+
+	t.Run("replay returns stored response", func(t *testing.T) {
+		svc := &mockWalletService{getBalanceVal: 1000}
+		h := NewWalletHandler(svc, 30*time.Second)
+		first := httptest.NewRecorder()
+		h.UpdateWalletBalance(first, newRequest())
+
+		svc.getBalanceVal = 5000
+		replay := httptest.NewRecorder()
+		h.UpdateWalletBalance(replay, newRequest())
+
+		if svc.updateCalls != 1 {
+			t.Errorf("got %d balance updates, want 1", svc.updateCalls)
+		}
+		if replay.Code != first.Code || replay.Body.String() != first.Body.String() {
+			t.Errorf("replay got %d %q, want %d %q", replay.Code, replay.Body.String(), first.Code, first.Body.String())
+		}
+		if replay.Header().Get("Idempotent-Replayed") != "true" {
+			t.Error("replay header not set")
+		}
+	})
+
+	t.Run("same key with different body", func(t *testing.T) {
+		svc := &mockWalletService{storedKey: &models.IdempotencyKey{Key: "key-1", RequestHash: "other", StatusCode: http.StatusOK}}
+		h := NewWalletHandler(svc, 30*time.Second)
+		rec := httptest.NewRecorder()
+
+		h.UpdateWalletBalance(rec, newRequest())
+
+		if rec.Code != http.StatusUnprocessableEntity {
+			t.Errorf("got status %d, want 422", rec.Code)
+		}
+		if svc.updateCalls != 0 {
+			t.Errorf("got %d balance updates, want 0", svc.updateCalls)
+		}
+	})
+
+	t.Run("error is stored and replayed", func(t *testing.T) {
+		svc := &mockWalletService{updateBalanceErr: models.ErrInsufficientBalance}
+		h := NewWalletHandler(svc, 30*time.Second)
+		first := httptest.NewRecorder()
+		h.UpdateWalletBalance(first, newRequest())
+
+		svc.updateBalanceErr = nil
+		replay := httptest.NewRecorder()
+		h.UpdateWalletBalance(replay, newRequest())
+
+		if first.Code != http.StatusConflict {
+			t.Fatalf("got status %d, want 409", first.Code)
+		}
+		if svc.updateCalls != 1 {
+			t.Errorf("got %d balance updates, want 1", svc.updateCalls)
+		}
+		if replay.Code != first.Code || replay.Body.String() != first.Body.String() {
+			t.Errorf("replay got %d %q, want %d %q", replay.Code, replay.Body.String(), first.Code, first.Body.String())
+		}
+	})
+
+	t.Run("server error is not stored", func(t *testing.T) {
+		svc := &mockWalletService{updateBalanceErr: models.ErrQueueFull}
+		h := NewWalletHandler(svc, 30*time.Second)
+		rec := httptest.NewRecorder()
+
+		h.UpdateWalletBalance(rec, newRequest())
+
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("got status %d, want 503", rec.Code)
+		}
+		if svc.storedKey != nil {
+			t.Errorf("error response stored: %+v", svc.storedKey)
+		}
+	})
+
+	t.Run("key in progress", func(t *testing.T) {
+		var req dto.UpdateWalletBalanceRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Fatal(err)
+		}
+		svc := &mockWalletService{storedKey: &models.IdempotencyKey{Key: "key-1", RequestHash: req.Fingerprint()}}
+		h := NewWalletHandler(svc, 30*time.Second)
+		rec := httptest.NewRecorder()
+
+		h.UpdateWalletBalance(rec, newRequest())
+
+		if rec.Code != http.StatusConflict {
+			t.Errorf("got status %d, want 409", rec.Code)
+		}
+		if svc.updateCalls != 0 {
+			t.Errorf("got %d balance updates, want 0", svc.updateCalls)
+		}
+	})
+
+	t.Run("applied operation without response is rebuilt", func(t *testing.T) {
+		var req dto.UpdateWalletBalanceRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Fatal(err)
+		}
+		opID := uuid.New()
+		svc := &mockWalletService{
+			storedKey:     &models.IdempotencyKey{Key: "key-1", RequestHash: req.Fingerprint(), TransactionID: &opID},
+			getBalanceVal: 1000,
+			operationFee:  15,
+		}
+		h := NewWalletHandler(svc, 30*time.Second)
+		rec := httptest.NewRecorder()
+
+		h.UpdateWalletBalance(rec, newRequest())
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("got status %d, want 200", rec.Code)
+		}
+		if svc.updateCalls != 0 {
+			t.Errorf("got %d balance updates, want 0", svc.updateCalls)
+		}
+		var res dto.UpdateWalletBalanceResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+			t.Fatal(err)
+		}
+		if res.Balance != 1000 || res.Fee != 15 {
+			t.Errorf("got balance %d fee %d, want 1000 and 15", res.Balance, res.Fee)
+		}
+		if svc.storedKey.StatusCode != http.StatusOK || string(svc.storedKey.ResponseBody) != rec.Body.String() {
+			t.Errorf("rebuilt response not stored: %+v", svc.storedKey)
+		}
+	})
+
+	t.Run("failed save is a server error", func(t *testing.T) {
+		svc := &mockWalletService{getBalanceVal: 1000, saveErr: errors.New("db down")}
+		h := NewWalletHandler(svc, 30*time.Second)
+		rec := httptest.NewRecorder()
+
+		h.UpdateWalletBalance(rec, newRequest())
+
+		if rec.Code != http.StatusInternalServerError {
+			t.Errorf("got status %d, want 500", rec.Code)
+		}
+	})
+
+	t.Run("conflict detected in batch", func(t *testing.T) {
+		svc := &mockWalletService{updateBalanceErr: models.ErrIdempotencyKeyReused}
+		h := NewWalletHandler(svc, 30*time.Second)
+		rec := httptest.NewRecorder()
+
+		h.UpdateWalletBalance(rec, newRequest())
+
+		if rec.Code != http.StatusUnprocessableEntity {
+			t.Errorf("got status %d, want 422", rec.Code)
+		}
+	})
+}
+
 func TestWalletHandler_GetWalletBalance(t *testing.T) {
 	t.Run("ok", func(t *testing.T) {
 		svc := &mockWalletService{getBalanceVal: 2000}
@@ -138,6 +690,19 @@ func TestWalletHandler_GetWalletBalance(t *testing.T) {
 		}
 	})
 
+	t.Run("wallet not found", func(t *testing.T) {
+		svc := &mockWalletService{getBalanceErr: models.ErrWalletNotFound}
+		h := NewWalletHandler(svc, 30*time.Second)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets/550e8400-e29b-41d4-a716-446655440009", nil)
+		rec := httptest.NewRecorder()
+
+		h.GetWalletBalance(rec, req)
+
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("got status %d, want 404", rec.Code)
+		}
+	})
+
 	t.Run("empty wallet id", func(t *testing.T) {
 		h := NewWalletHandler(&mockWalletService{}, 30*time.Second)
 		req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets/", nil)
@@ -162,4 +727,168 @@ func TestWalletHandler_GetWalletBalance(t *testing.T) {
 			t.Errorf("got status %d, want 500", rec.Code)
 		}
 	})
+
+	t.Run("at", func(t *testing.T) {
+		svc := &mockWalletService{getBalanceVal: 700}
+		h := NewWalletHandler(svc, 30*time.Second)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets/550e8400-e29b-41d4-a716-446655440000?at=2026-01-02T15:04:05%2B03:00", nil)
+		rec := httptest.NewRecorder()
+
+		h.GetWalletBalance(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("got status %d, want 200", rec.Code)
+		}
+		want := time.Date(2026, 1, 2, 12, 4, 5, 0, time.UTC)
+		if !svc.gotAt.Equal(want) {
+			t.Errorf("got at %s, want %s", svc.gotAt, want)
+		}
+		var res struct {
+			Balance   int64      `json:"balance"`
+			Available *int64     `json:"available"`
+			At        *time.Time `json:"at"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		if res.Balance != 700 || res.At == nil || res.Available != nil {
+			t.Errorf("unexpected response: %+v", res)
+		}
+	})
+
+	t.Run("credit", func(t *testing.T) {
+		cases := []struct {
+			balance, limit, remaining int64
+		}{
+			{balance: 300, limit: 1000, remaining: 1000},
+			{balance: -400, limit: 1000, remaining: 600},
+		}
+		for _, tc := range cases {
+			svc := &mockWalletService{getBalanceVal: tc.balance, creditLimit: tc.limit}
+			h := NewWalletHandler(svc, 30*time.Second)
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets/550e8400-e29b-41d4-a716-446655440000", nil)
+			rec := httptest.NewRecorder()
+
+			h.GetWalletBalance(rec, req)
+
+			var res struct {
+				Balance         int64 `json:"balance"`
+				CreditLimit     int64 `json:"creditLimit"`
+				RemainingCredit int64 `json:"remainingCredit"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+				t.Fatal(err)
+			}
+			if res.CreditLimit != tc.limit || res.RemainingCredit != tc.remaining {
+				t.Errorf("balance %d: got limit %d remaining %d, want %d %d",
+					tc.balance, res.CreditLimit, res.RemainingCredit, tc.limit, tc.remaining)
+			}
+		}
+	})
+
+	t.Run("at before the first posting", func(t *testing.T) {
+		svc := &mockWalletService{getBalanceErr: models.ErrBalanceUnknown}
+		h := NewWalletHandler(svc, 30*time.Second)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets/550e8400-e29b-41d4-a716-446655440000?at=2020-01-02T15:04:05Z", nil)
+		rec := httptest.NewRecorder()
+
+		h.GetWalletBalance(rec, req)
+
+		if rec.Code != http.StatusUnprocessableEntity {
+			t.Errorf("got status %d, want 422", rec.Code)
+		}
+	})
+
+	t.Run("invalid at", func(t *testing.T) {
+		svc := &mockWalletService{}
+		h := NewWalletHandler(svc, 30*time.Second)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets/550e8400-e29b-41d4-a716-446655440000?at=yesterday", nil)
+		rec := httptest.NewRecorder()
+
+		h.GetWalletBalance(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("got status %d, want 400", rec.Code)
+		}
+	})
+}
+
+func TestWalletHandler_GetWalletTransactions(t *testing.T) {
+	const walletID = "550e8400-e29b-41d4-a716-446655440000"
+
+	t.Run("ok", func(t *testing.T) {
+		svc := &mockWalletService{txs: []models.Transaction{
+			{Type: "WITHDRAW", Amount: 300, BalanceAfter: 700},
+			{Type: "DEPOSIT", Amount: 1000, BalanceAfter: 1000},
+		}}
+		h := NewWalletHandler(svc, 30*time.Second)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets/"+walletID+"/transactions?limit=2&offset=4", nil)
+		req.SetPathValue("id", walletID)
+		rec := httptest.NewRecorder()
+
+		h.GetWalletTransactions(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("got status %d, want 200", rec.Code)
+		}
+		if svc.gotLimit != 2 || svc.gotOffset != 4 {
+			t.Errorf("got limit=%d offset=%d, want 2 and 4", svc.gotLimit, svc.gotOffset)
+		}
+		var res struct {
+			Transactions []struct {
+				Type         string `json:"type"`
+				BalanceAfter int64  `json:"balanceAfter"`
+			} `json:"transactions"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Transactions) != 2 || res.Transactions[0].BalanceAfter != 700 {
+			t.Errorf("unexpected transactions: %+v", res.Transactions)
+		}
+	})
+
+	t.Run("default limit", func(t *testing.T) {
+		svc := &mockWalletService{}
+		h := NewWalletHandler(svc, 30*time.Second)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets/"+walletID+"/transactions", nil)
+		req.SetPathValue("id", walletID)
+		rec := httptest.NewRecorder()
+
+		h.GetWalletTransactions(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("got status %d, want 200", rec.Code)
+		}
+		if svc.gotLimit != 50 || svc.gotOffset != 0 {
+			t.Errorf("got limit=%d offset=%d, want 50 and 0", svc.gotLimit, svc.gotOffset)
+		}
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		h := NewWalletHandler(&mockWalletService{}, 30*time.Second)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets/"+walletID+"/transactions?limit=abc", nil)
+		req.SetPathValue("id", walletID)
+		rec := httptest.NewRecorder()
+
+		h.GetWalletTransactions(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("got status %d, want 400", rec.Code)
+		}
+	})
+
+	t.Run("service error", func(t *testing.T) {
+		svc := &mockWalletService{txsErr: errors.New("service error")}
+		h := NewWalletHandler(svc, 30*time.Second)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets/"+walletID+"/transactions", nil)
+		req.SetPathValue("id", walletID)
+		rec := httptest.NewRecorder()
+
+		h.GetWalletTransactions(rec, req)
+
+		if rec.Code != http.StatusInternalServerError {
+			t.Errorf("got status %d, want 500", rec.Code)
+		}
+	})
 }
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCron_Next(t *testing.T) {
+	at := func(s string) time.Time {
+		v, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	cases := []struct {
+		spec  string
+		after string
+		want  string
+	}{
+		{"0 9 1 * *", "2026-01-15 10:00", "2026-02-01 09:00"},
+		{"0 9 1 * *", "2026-02-01 09:00", "2026-03-01 09:00"},
+		{"*/15 * * * *", "2026-01-15 10:07", "2026-01-15 10:15"},
+		{"30 8-18/5 * * *", "2026-01-15 13:31", "2026-01-15 18:30"},
+		{"0 0 31 * *", "2026-04-01 00:00", "2026-05-31 00:00"},
+		{"0 12 * * 1-5", "2026-10-16 12:00", "2026-10-19 12:00"},
+		{"0 0 * * 7", "2026-10-17 00:00", "2026-10-18 00:00"},
+		{"0 0 13 * 5", "2026-10-10 00:00", "2026-10-13 00:00"},
+		{"0 0 13 * 5", "2026-10-13 00:00", "2026-10-16 00:00"},
+		{"@monthly", "2026-12-31 23:59", "2027-01-01 00:00"},
+		{"0 0 29 2 *", "2026-03-01 00:00", "2028-02-29 00:00"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.spec+" after "+tc.after, func(t *testing.T) {
+			c, err := ParseCron(tc.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, ok := c.Next(at(tc.after))
+			if !ok || !got.Equal(at(tc.want)) {
+				t.Errorf("got %s (%v), want %s", got, ok, tc.want)
+			}
+		})
+	}
+
+	c, err := ParseCron("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Next(at("2026-01-01 00:00")); ok {
+		t.Error("February 30 must never match")
+	}
+}
+
+func TestParseCron_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "a * * * *", "@often"} {
+		if _, err := ParseCron(spec); err == nil {
+			t.Errorf("ParseCron(%q) must fail", spec)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package models
+
+// DefaultCurrency is used for wallets created without an explicit currency.
+const DefaultCurrency = "RUB"
+
+// currencyExponents maps ISO 4217 codes to the number of digits in the minor
+// unit, e.g. 2 for USD cents and 0 for JPY.
+var currencyExponents = map[string]int{
+	"AED": 2, "AMD": 2, "AUD": 2, "AZN": 2, "BHD": 3, "BRL": 2, "BYN": 2,
+	"CAD": 2, "CHF": 2, "CLP": 0, "CNY": 2, "CZK": 2, "DKK": 2, "EUR": 2,
+	"GBP": 2, "GEL": 2, "HKD": 2, "HUF": 2, "IDR": 2, "ILS": 2, "INR": 2,
+	"ISK": 0, "JOD": 3, "JPY": 0, "KGS": 2, "KRW": 0, "KWD": 3, "KZT": 2,
+	"MXN": 2, "NOK": 2, "NZD": 2, "OMR": 3, "PLN": 2, "RUB": 2, "SAR": 2,
+	"SEK": 2, "SGD": 2, "THB": 2, "TJS": 2, "TND": 3, "TRY": 2, "UAH": 2,
+	"USD": 2, "UZS": 2, "VND": 0, "ZAR": 2,
+}
+
+// CurrencyExponent returns the minor-unit exponent of an ISO 4217 code.
+func CurrencyExponent(code string) (int, bool) {
+	exp, ok := currencyExponents[code]
+	return exp, ok
+}
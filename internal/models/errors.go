@@ -0,0 +1,72 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrWalletNotFound       = errors.New("wallet not found")
+	ErrInsufficientBalance  = errors.New("insufficient balance")
+	ErrSameWalletTransfer   = errors.New("cannot transfer to the same wallet")
+	ErrWalletExists         = errors.New("wallet already exists")
+	ErrWalletFrozen         = errors.New("wallet is frozen")
+	ErrWalletClosed         = errors.New("wallet is closed")
+	ErrWalletNotEmpty       = errors.New("wallet balance must be zero to close it")
+	ErrWalletHasHolds       = errors.New("wallet has active holds")
+	ErrWalletHasSchedules   = errors.New("wallet has active schedules")
+	ErrInvalidTransition    = errors.New("wallet status transition is not allowed")
+	ErrUnknownCurrency      = errors.New("unknown currency")
+	ErrCurrencyMismatch     = errors.New("currency does not match the wallet currency")
+	ErrHoldNotFound         = errors.New("hold not found")
+	ErrHoldNotActive        = errors.New("hold is not active")
+	ErrHoldExpired          = errors.New("hold has expired")
+	ErrCaptureExceedsHold   = errors.New("capture amount exceeds the held amount")
+	ErrOperationNotFound    = errors.New("operation not found")
+	ErrNotReversible        = errors.New("only DEPOSIT and WITHDRAW operations can be reversed")
+	ErrAlreadyReversed      = errors.New("operation is already fully reversed")
+	ErrReversalExceedsOp    = errors.New("reversal amount exceeds the amount left to reverse")
+	ErrRateNotFound         = errors.New("no exchange rate for the currency pair")
+	ErrSameCurrency         = errors.New("wallets have the same currency, use a transfer")
+	ErrConversionTooSmall   = errors.New("amount converts to less than one minor unit")
+	ErrQuoteNotFound        = errors.New("quote not found")
+	ErrQuoteExpired         = errors.New("quote has expired")
+	ErrQuoteExecuted        = errors.New("quote is already executed")
+	ErrScheduleNotFound     = errors.New("schedule not found")
+	ErrScheduleTransition   = errors.New("schedule status transition is not allowed")
+	ErrUnbalancedEntry      = errors.New("ledger entry does not balance")
+	ErrBalanceUnknown       = errors.New("balance is unknown before the wallet's first posting")
+	ErrCreditLimitInUse     = errors.New("credit limit is below the credit already in use")
+	ErrLimitExceeded        = errors.New("limit_exceeded")
+	ErrIdempotencyKeyReused = errors.New("idempotency key was already used with a different request")
+	ErrIdempotencyKeyInUse  = errors.New("request with this idempotency key is still in progress")
+	ErrQueueFull            = errors.New("operation queue is full")
+	ErrQueueClosed          = errors.New("operation queue is shutting down")
+)
+
+// IsTransient reports whether err says nothing about the operation itself:
+// the queue was full or shutting down, or the caller gave up waiting. Such an
+// operation may be submitted again as it is.
+func IsTransient(err error) bool {
+	return errors.Is(err, ErrQueueFull) ||
+		errors.Is(err, ErrQueueClosed) ||
+		errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
+
+// QueueFullError is returned when an operation could not be queued in time.
+// Depth is the number of operations waiting in the queue at that moment and
+// RetryAfter an estimate of how long it takes the queue to drain them.
+type QueueFullError struct {
+	Depth      int
+	RetryAfter time.Duration
+}
+
+func (e *QueueFullError) Error() string {
+	return ErrQueueFull.Error()
+}
+
+func (e *QueueFullError) Is(target error) bool {
+	return target == ErrQueueFull
+}
@@ -0,0 +1,190 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Fee rule types.
+const (
+	FeeTypeFlat    = "flat"
+	FeeTypePercent = "percent"
+	FeeTypeTiered  = "tiered"
+)
+
+// FeeRule charges a fee on every operation of type Operation in Currency (any
+// currency if empty). Amounts are in minor units and percentages in basis
+// points (1/100 of a percent).
+//
+//   - flat charges Amount.
+//   - percent charges BasisPoints of the operation amount.
+//   - tiered picks the first tier whose UpTo covers the operation amount (the
+//     last tier has no UpTo and covers the rest) and charges its Amount plus
+//     its BasisPoints of the operation amount.
+//
+// A non-zero Min or Max bounds the fee of percent and tiered rules.
+type FeeRule struct {
+	Name        string    `json:"name"`
+	Operation   string    `json:"operation"`
+	Currency    string    `json:"currency,omitempty"`
+	Type        string    `json:"type"`
+	Amount      int64     `json:"amount,omitempty"`
+	BasisPoints int64     `json:"bps,omitempty"`
+	Min         int64     `json:"min,omitempty"`
+	Max         int64     `json:"max,omitempty"`
+	Tiers       []FeeTier `json:"tiers,omitempty"`
+}
+
+type FeeTier struct {
+	UpTo        int64 `json:"upTo,omitempty"`
+	Amount      int64 `json:"amount,omitempty"`
+	BasisPoints int64 `json:"bps,omitempty"`
+}
+
+// FeeCharge is the fee one rule charges on one operation.
+type FeeCharge struct {
+	Rule   string `json:"rule"`
+	Amount int64  `json:"amount"`
+}
+
+// FeeSchedule is the set of fee rules loaded at startup. The zero value
+// charges nothing.
+type FeeSchedule struct {
+	Rules []FeeRule `json:"rules"`
+}
+
+// ParseFeeSchedule decodes and validates a JSON fee schedule.
+func ParseFeeSchedule(data []byte) (FeeSchedule, error) {
+	var s FeeSchedule
+	if err := json.Unmarshal(data, &s); err != nil {
+		return FeeSchedule{}, fmt.Errorf("invalid fee schedule: %w", err)
+	}
+	names := make(map[string]bool, len(s.Rules))
+	for i, rule := range s.Rules {
+		if err := rule.validate(); err != nil {
+			return FeeSchedule{}, fmt.Errorf("fee rule %d: %w", i, err)
+		}
+		if names[rule.Name] {
+			return FeeSchedule{}, fmt.Errorf("fee rule %d: duplicate name %q", i, rule.Name)
+		}
+		names[rule.Name] = true
+	}
+	return s, nil
+}
+
+func (r FeeRule) validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	// Комиссия списывается сверх суммы операции, поэтому пока
+	// поддерживаются только списания
+	if r.Operation != "WITHDRAW" {
+		return fmt.Errorf("operation must be WITHDRAW")
+	}
+	if r.Currency != "" {
+		if _, ok := CurrencyExponent(r.Currency); !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownCurrency, r.Currency)
+		}
+	}
+	if r.Amount < 0 || r.Min < 0 || r.Max < 0 || (r.Max != 0 && r.Max < r.Min) {
+		return fmt.Errorf("amount, min and max must not be negative and min must not exceed max")
+	}
+	if err := validateBasisPoints(r.BasisPoints); err != nil {
+		return err
+	}
+	switch r.Type {
+	case FeeTypeFlat, FeeTypePercent:
+		return nil
+	case FeeTypeTiered:
+		if len(r.Tiers) == 0 {
+			return fmt.Errorf("tiered rule needs tiers")
+		}
+		for i, tier := range r.Tiers {
+			if last := i == len(r.Tiers)-1; last != (tier.UpTo == 0) {
+				return fmt.Errorf("tier %d: only the last tier must omit upTo", i)
+			}
+			if tier.UpTo < 0 || (i > 0 && tier.UpTo != 0 && tier.UpTo <= r.Tiers[i-1].UpTo) {
+				return fmt.Errorf("tier %d: upTo must increase", i)
+			}
+			if tier.Amount < 0 {
+				return fmt.Errorf("tier %d: amount must not be negative", i)
+			}
+			if err := validateBasisPoints(tier.BasisPoints); err != nil {
+				return fmt.Errorf("tier %d: %w", i, err)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("type must be %s, %s or %s", FeeTypeFlat, FeeTypePercent, FeeTypeTiered)
+}
+
+func validateBasisPoints(bps int64) error {
+	if bps < 0 || bps > 10000 {
+		return fmt.Errorf("bps must be between 0 and 10000")
+	}
+	return nil
+}
+
+// Applies reports whether any rule charges operations of opType.
+func (s FeeSchedule) Applies(opType string) bool {
+	for _, rule := range s.Rules {
+		if rule.Operation == opType {
+			return true
+		}
+	}
+	return false
+}
+
+// Charges returns the non-zero fees the schedule charges on an operation of
+// opType for amount in currency, in rule order.
+func (s FeeSchedule) Charges(opType, currency string, amount int64) []FeeCharge {
+	var charges []FeeCharge
+	for _, rule := range s.Rules {
+		if rule.Operation != opType || (rule.Currency != "" && rule.Currency != currency) {
+			continue
+		}
+		if fee := rule.fee(amount); fee > 0 {
+			charges = append(charges, FeeCharge{Rule: rule.Name, Amount: fee})
+		}
+	}
+	return charges
+}
+
+func (r FeeRule) fee(amount int64) int64 {
+	var fee int64
+	switch r.Type {
+	case FeeTypeFlat:
+		return r.Amount
+	case FeeTypePercent:
+		fee = basisPoints(amount, r.BasisPoints)
+	case FeeTypeTiered:
+		for _, tier := range r.Tiers {
+			if tier.UpTo == 0 || amount <= tier.UpTo {
+				fee = tier.Amount + basisPoints(amount, tier.BasisPoints)
+				break
+			}
+		}
+	}
+	if fee < r.Min {
+		fee = r.Min
+	}
+	if r.Max != 0 && fee > r.Max {
+		fee = r.Max
+	}
+	return fee
+}
+
+// basisPoints returns bps/10000 of amount rounded half up. The amount is
+// split so that the product cannot overflow.
+func basisPoints(amount, bps int64) int64 {
+	return amount/10000*bps + (amount%10000*bps+5000)/10000
+}
+
+// TotalFees sums the amounts of charges.
+func TotalFees(charges []FeeCharge) int64 {
+	var total int64
+	for _, c := range charges {
+		total += c.Amount
+	}
+	return total
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFeeSchedule_Charges(t *testing.T) {
+	schedule, err := ParseFeeSchedule([]byte(`{"rules": [
+		{"name": "flat", "operation": "WITHDRAW", "currency": "RUB", "type": "flat", "amount": 3000},
+		{"name": "percent", "operation": "WITHDRAW", "type": "percent", "bps": 150, "min": 100, "max": 50000},
+		{"name": "tiered", "operation": "WITHDRAW", "currency": "USD", "type": "tiered", "tiers": [
+			{"upTo": 100000, "bps": 200},
+			{"upTo": 1000000, "amount": 500, "bps": 100},
+			{"bps": 50}
+		]}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		currency string
+		amount   int64
+		want     []FeeCharge
+	}{
+		{"percent above min", "RUB", 100000, []FeeCharge{{"flat", 3000}, {"percent", 1500}}},
+		{"percent raised to min", "RUB", 1000, []FeeCharge{{"flat", 3000}, {"percent", 100}}},
+		{"percent capped at max", "RUB", 10000000, []FeeCharge{{"flat", 3000}, {"percent", 50000}}},
+		{"percent rounds half up", "EUR", 10100, []FeeCharge{{"percent", 152}}},
+		{"first tier", "USD", 100000, []FeeCharge{{"percent", 1500}, {"tiered", 2000}}},
+		{"middle tier", "USD", 200000, []FeeCharge{{"percent", 3000}, {"tiered", 2500}}},
+		{"unbounded tier", "USD", 2000000, []FeeCharge{{"percent", 30000}, {"tiered", 10000}}},
+		{"no overflow", "EUR", math.MaxInt64, []FeeCharge{{"percent", 50000}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := schedule.Charges("WITHDRAW", tc.currency, tc.amount)
+			if len(got) != len(tc.want) {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("got %v, want %v", got, tc.want)
+				}
+			}
+		})
+	}
+
+	if schedule.Applies("DEPOSIT") || len(schedule.Charges("DEPOSIT", "RUB", 1000)) != 0 {
+		t.Error("deposits must not be charged")
+	}
+}
+
+func TestParseFeeSchedule_Invalid(t *testing.T) {
+	cases := map[string]string{
+		"not json":            `rules`,
+		"no name":             `{"rules": [{"operation": "WITHDRAW", "type": "flat", "amount": 1}]}`,
+		"duplicate name":      `{"rules": [{"name": "a", "operation": "WITHDRAW", "type": "flat"}, {"name": "a", "operation": "WITHDRAW", "type": "flat"}]}`,
+		"deposit":             `{"rules": [{"name": "a", "operation": "DEPOSIT", "type": "flat", "amount": 1}]}`,
+		"unknown type":        `{"rules": [{"name": "a", "operation": "WITHDRAW", "type": "free"}]}`,
+		"unknown currency":    `{"rules": [{"name": "a", "operation": "WITHDRAW", "currency": "XXX", "type": "flat"}]}`,
+		"bps over 100%":       `{"rules": [{"name": "a", "operation": "WITHDRAW", "type": "percent", "bps": 10001}]}`,
+		"min over max":        `{"rules": [{"name": "a", "operation": "WITHDRAW", "type": "percent", "bps": 1, "min": 10, "max": 5}]}`,
+		"no tiers":            `{"rules": [{"name": "a", "operation": "WITHDRAW", "type": "tiered"}]}`,
+		"bounded last tier":   `{"rules": [{"name": "a", "operation": "WITHDRAW", "type": "tiered", "tiers": [{"upTo": 10}]}]}`,
+		"tiers not ascending": `{"rules": [{"name": "a", "operation": "WITHDRAW", "type": "tiered", "tiers": [{"upTo": 10}, {"upTo": 5}, {}]}]}`,
+	}
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseFeeSchedule([]byte(data)); err == nil {
+				t.Error("want error")
+			}
+		})
+	}
+}
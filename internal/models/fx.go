@@ -0,0 +1,165 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// rateFormat limits rates to what the NUMERIC(24, 12) columns hold.
+var rateFormat = regexp.MustCompile(`^[0-9]{1,12}(\.[0-9]{1,12})?$`)
+
+// Rate is an exchange rate written as a decimal string: how many major units
+// of the target currency one major unit of the source currency buys.
+type Rate string
+
+// ParseRate validates a decimal rate and strips insignificant zeros.
+func ParseRate(s string) (Rate, error) {
+	if !rateFormat.MatchString(s) {
+		return "", fmt.Errorf("rate must be a decimal with up to 12 digits before and after the point")
+	}
+	r := normalizeRate(s)
+	if r == "0" {
+		return "", fmt.Errorf("rate must be positive")
+	}
+	return Rate(r), nil
+}
+
+func normalizeRate(s string) string {
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	if s = strings.TrimLeft(s, "0"); s == "" || s[0] == '.' {
+		s = "0" + s
+	}
+	return s
+}
+
+// Convert turns amount minor units of from into minor units of to at this
+// rate, rounding down to a whole minor unit of to.
+func (r Rate) Convert(amount int64, from, to string) (int64, error) {
+	fromExp, ok := CurrencyExponent(from)
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownCurrency, from)
+	}
+	toExp, ok := CurrencyExponent(to)
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownCurrency, to)
+	}
+	rate, ok := new(big.Rat).SetString(string(r))
+	if !ok || rate.Sign() <= 0 {
+		return 0, fmt.Errorf("invalid rate %q", r)
+	}
+
+	// amount * rate * 10^(toExp - fromExp), округление вниз
+	v := new(big.Rat).Mul(rate, new(big.Rat).SetInt64(amount))
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(toExp-fromExp))), nil)
+	if toExp >= fromExp {
+		v.Mul(v, new(big.Rat).SetInt(scale))
+	} else {
+		v.Quo(v, new(big.Rat).SetInt(scale))
+	}
+	converted := new(big.Int).Quo(v.Num(), v.Denom())
+	if !converted.IsInt64() {
+		return 0, fmt.Errorf("converted amount is too large")
+	}
+	return converted.Int64(), nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func (r Rate) Value() (driver.Value, error) {
+	return string(r), nil
+}
+
+func (r *Rate) Scan(src any) error {
+	switch v := src.(type) {
+	case string:
+		*r = Rate(normalizeRate(v))
+		return nil
+	case []byte:
+		*r = Rate(normalizeRate(string(v)))
+		return nil
+	}
+	return fmt.Errorf("cannot scan %T into Rate", src)
+}
+
+// ExchangeRate converts FromCurrency into ToCurrency. Rates are directional:
+// the reverse pair has its own row.
+type ExchangeRate struct {
+	FromCurrency string    `json:"from" db:"from_currency"`
+	ToCurrency   string    `json:"to" db:"to_currency"`
+	Rate         Rate      `json:"rate" db:"rate"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+func (ExchangeRate) TableName() string {
+	return "exchange_rates"
+}
+
+// Validate checks the currencies and the rate and normalizes the rate.
+func (e *ExchangeRate) Validate() error {
+	for _, c := range []string{e.FromCurrency, e.ToCurrency} {
+		if _, ok := CurrencyExponent(c); !ok {
+			return fmt.Errorf("%w: %q", ErrUnknownCurrency, c)
+		}
+	}
+	if e.FromCurrency == e.ToCurrency {
+		return fmt.Errorf("rate %s/%s converts a currency into itself", e.FromCurrency, e.ToCurrency)
+	}
+	rate, err := ParseRate(string(e.Rate))
+	if err != nil {
+		return fmt.Errorf("rate %s/%s: %w", e.FromCurrency, e.ToCurrency, err)
+	}
+	e.Rate = rate
+	return nil
+}
+
+// ParseExchangeRates decodes and validates a JSON rates table of the form
+// {"rates": [{"from": "USD", "to": "RUB", "rate": "92.5"}]}.
+func ParseExchangeRates(data []byte) ([]ExchangeRate, error) {
+	var table struct {
+		Rates []ExchangeRate `json:"rates"`
+	}
+	if err := json.Unmarshal(data, &table); err != nil {
+		return nil, fmt.Errorf("invalid rates table: %w", err)
+	}
+	for i := range table.Rates {
+		if err := table.Rates[i].Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return table.Rates, nil
+}
+
+// FXQuote fixes the rate of a conversion from FromWalletID to ToWalletID
+// until ExpiresAt. Executing it debits Amount and credits ConvertedAmount;
+// a quote can be executed once.
+type FXQuote struct {
+	ID              uuid.UUID  `json:"id" db:"id"`
+	FromWalletID    uuid.UUID  `json:"from_wallet_id" db:"from_wallet_id"`
+	ToWalletID      uuid.UUID  `json:"to_wallet_id" db:"to_wallet_id"`
+	FromCurrency    string     `json:"from_currency" db:"from_currency"`
+	ToCurrency      string     `json:"to_currency" db:"to_currency"`
+	Amount          int64      `json:"amount" db:"amount"`
+	ConvertedAmount int64      `json:"converted_amount" db:"converted_amount"`
+	Rate            Rate       `json:"rate" db:"rate"`
+	ExpiresAt       time.Time  `json:"expires_at" db:"expires_at"`
+	ExecutedAt      *time.Time `json:"executed_at" db:"executed_at"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+}
+
+func (FXQuote) TableName() string {
+	return "fx_quotes"
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseRate(t *testing.T) {
+	valid := map[string]Rate{
+		"92.5":           "92.5",
+		"092.500":        "92.5",
+		"1":              "1",
+		"1.000":          "1",
+		"0.010846":       "0.010846",
+		"000000000001.5": "1.5",
+	}
+	for in, want := range valid {
+		got, err := ParseRate(in)
+		if err != nil || got != want {
+			t.Errorf("ParseRate(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "0", "0.000", "-1", "1e3", "1/3", ".5", "1.", "1.0000000000001", "1000000000000"} {
+		if _, err := ParseRate(in); err == nil {
+			t.Errorf("ParseRate(%q) must fail", in)
+		}
+	}
+}
+
+func TestRate_Convert(t *testing.T) {
+	cases := []struct {
+		name     string
+		rate     Rate
+		amount   int64
+		from, to string
+		want     int64
+	}{
+		{"same exponent", "92.5", 100, "USD", "RUB", 9250},
+		{"rounds down", "0.010846", 100, "RUB", "USD", 1},
+		{"into fewer digits", "149.123", 1001, "USD", "JPY", 1492},
+		{"into more digits", "0.0067", 1000, "JPY", "USD", 670},
+		{"into three digits", "0.308", 12345, "USD", "KWD", 38022},
+		{"below one unit", "0.0001", 99, "RUB", "USD", 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.rate.Convert(tc.amount, tc.from, tc.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("got %d, want %d", got, tc.want)
+			}
+		})
+	}
+
+	if _, err := Rate("2").Convert(math.MaxInt64, "USD", "EUR"); err == nil {
+		t.Error("overflow must fail")
+	}
+	if _, err := Rate("2").Convert(100, "USD", "XXX"); err == nil {
+		t.Error("unknown currency must fail")
+	}
+}
+
+func TestParseExchangeRates(t *testing.T) {
+	rates, err := ParseExchangeRates([]byte(`{"rates": [{"from": "USD", "to": "RUB", "rate": "92.50"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rates) != 1 || rates[0].Rate != "92.5" {
+		t.Errorf("unexpected rates: %+v", rates)
+	}
+	for _, data := range []string{
+		`{"rates": [{"from": "USD", "to": "USD", "rate": "1"}]}`,
+		`{"rates": [{"from": "USD", "to": "XXX", "rate": "1"}]}`,
+		`{"rates": [{"from": "USD", "to": "RUB", "rate": 92.5}]}`,
+	} {
+		if _, err := ParseExchangeRates([]byte(data)); err == nil {
+			t.Errorf("want error for %s", data)
+		}
+	}
+}
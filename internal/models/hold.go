@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type HoldStatus string
+
+const (
+	HoldStatusActive   HoldStatus = "ACTIVE"
+	HoldStatusCaptured HoldStatus = "CAPTURED"
+	HoldStatusVoided   HoldStatus = "VOIDED"
+	HoldStatusExpired  HoldStatus = "EXPIRED"
+)
+
+// Hold reserves Amount on a wallet without moving it. While the hold is
+// active the amount is counted in Wallet.Held and is not available for
+// withdrawals; a capture debits up to Amount and releases the rest.
+type Hold struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	WalletID       uuid.UUID  `json:"wallet_id" db:"wallet_id"`
+	Amount         int64      `json:"amount" db:"amount"`
+	CapturedAmount int64      `json:"captured_amount" db:"captured_amount"`
+	Status         HoldStatus `json:"status" db:"status"`
+	ExpiresAt      time.Time  `json:"expires_at" db:"expires_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+func (Hold) TableName() string {
+	return "wallet_holds"
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdempotencyKey stores the outcome of a request sent with an Idempotency-Key
+// header. StatusCode is 0 until the response has been recorded.
+type IdempotencyKey struct {
+	Key           string     `json:"key" db:"key" gorm:"primaryKey"`
+	RequestHash   string     `json:"request_hash" db:"request_hash"`
+	TransactionID *uuid.UUID `json:"transaction_id" db:"transaction_id"`
+	StatusCode    int        `json:"status_code" db:"status_code"`
+	ResponseBody  []byte     `json:"response_body" db:"response_body"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+}
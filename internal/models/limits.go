@@ -0,0 +1,46 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Wallet limit rules. The rule name is reported with ErrLimitExceeded.
+const (
+	LimitRuleMaxWithdrawalAmount24h = "max_withdrawal_amount_24h"
+	LimitRuleMaxWithdrawalsPerHour  = "max_withdrawals_per_hour"
+	LimitRuleMaxBalance             = "max_balance"
+)
+
+// LimitExceeded returns ErrLimitExceeded naming the broken rule and its limit.
+func LimitExceeded(rule string, limit int64) error {
+	return fmt.Errorf("%w: %s (limit %d)", ErrLimitExceeded, rule, limit)
+}
+
+// WalletLimits are the per-wallet rules checked before a balance operation is
+// queued. A nil rule is not enforced.
+type WalletLimits struct {
+	WalletID               uuid.UUID `json:"wallet_id" db:"wallet_id"`
+	MaxWithdrawalAmount24h *int64    `json:"max_withdrawal_amount_24h" db:"max_withdrawal_amount_24h"`
+	MaxWithdrawalsPerHour  *int64    `json:"max_withdrawals_per_hour" db:"max_withdrawals_per_hour"`
+	MaxBalance             *int64    `json:"max_balance" db:"max_balance"`
+	UpdatedAt              time.Time `json:"updated_at" db:"updated_at"`
+}
+
+func (WalletLimits) TableName() string {
+	return "wallet_limits"
+}
+
+// Empty reports whether no rule is set.
+func (l WalletLimits) Empty() bool {
+	return l.MaxWithdrawalAmount24h == nil && l.MaxWithdrawalsPerHour == nil && l.MaxBalance == nil
+}
+
+// WithdrawalUsage is what a wallet has withdrawn within the rolling windows
+// of the velocity rules.
+type WithdrawalUsage struct {
+	Amount24h int64 `db:"amount_24h"`
+	Count1h   int64 `db:"count_1h"`
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WalletMetadata describes who a wallet belongs to. It is not used by balance
+// operations.
+type WalletMetadata struct {
+	OwnerID string `json:"owner_id" db:"owner_id"`
+	Name    string `json:"name" db:"name"`
+	Labels  Labels `json:"labels" db:"labels"`
+}
+
+// Labels are free-form wallet tags, stored as a JSON array.
+type Labels []string
+
+func (l Labels) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal([]string(l))
+	return string(data), err
+}
+
+func (l *Labels) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*l = Labels{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, (*[]string)(l))
+	case string:
+		return json.Unmarshal([]byte(v), (*[]string)(l))
+	}
+	return fmt.Errorf("cannot scan %T into Labels", src)
+}
+
+// Wallet list sort keys.
+const (
+	WalletSortCreatedAt = "created_at"
+	WalletSortBalance   = "balance"
+)
+
+// WalletListQuery selects wallets owned by OwnerID (any owner if empty) that
+// carry every label in Labels, ordered by Sort and then by ID, Limit at a
+// time. A nil After starts from the first wallet.
+type WalletListQuery struct {
+	OwnerID string
+	Labels  []string
+	Sort    string
+	Desc    bool
+	Limit   int
+	After   *WalletCursor
+}
+
+// WalletCursor is the position of the last wallet of a page: its sort key
+// and ID.
+type WalletCursor struct {
+	Balance   int64
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// CursorOf returns the position of w in a list sorted by sort.
+func CursorOf(w Wallet, sort string) WalletCursor {
+	if sort == WalletSortBalance {
+		return WalletCursor{Balance: w.Balance, ID: w.ID}
+	}
+	return WalletCursor{CreatedAt: w.CreatedAt, ID: w.ID}
+}
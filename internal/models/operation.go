@@ -0,0 +1,39 @@
+package models
+
+import "github.com/google/uuid"
+
+// Operation is a single client request to change a wallet balance.
+// ID becomes the ID of the ledger row written for it; for a TRANSFER it is the
+// transfer ID shared by both ledger rows and WalletID is the source wallet.
+// An empty Currency skips the check against the wallet currency. Fees are
+// charged on top of Amount in the same transaction.
+type Operation struct {
+	ID             uuid.UUID
+	Type           string
+	WalletID       string
+	ToWalletID     string
+	Amount         int64
+	Currency       string
+	IdempotencyKey string
+	RequestHash    string
+	Fees           []FeeCharge
+}
+
+// OpGroup is a group of DEPOSIT or WITHDRAW ops of one type and currency for
+// one wallet, as the queue aggregates them.
+type OpGroup struct {
+	WalletID string
+	Type     string
+	Currency string
+	Ops      []Operation
+}
+
+// GroupResult is the outcome of one OpGroup applied together with others.
+// Applied reports whether the group took effect; Balance is then the wallet
+// balance after the whole batch, or zero if every op of the group was a
+// replay and the wallet did not change. A group that was not applied left
+// the wallet untouched and is retried on its own, which tells why it failed.
+type GroupResult struct {
+	Applied bool
+	Balance int64
+}
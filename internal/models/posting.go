@@ -0,0 +1,82 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Named system accounts. Money deposited into a wallet comes from
+// SystemAccountExternalCash and money withdrawn goes back to it, so its
+// balance is the negative of what clients hold in the system.
+// SystemAccountAdjustments absorbs corrective entries written by
+// reconciliation. SystemAccountFX takes the source currency of a conversion
+// and pays out the target currency.
+const (
+	SystemAccountExternalCash = "external_cash"
+	SystemAccountFees         = "fees"
+	SystemAccountAdjustments  = "adjustments"
+	SystemAccountFX           = "fx"
+)
+
+// Posting is one side of a double-entry ledger entry. It belongs either to a
+// wallet or to a named system account. Amount is signed: positive increases
+// the account balance, negative decreases it. The postings of one entry
+// (EntryID) sum to zero in every currency.
+type Posting struct {
+	ID            uuid.UUID  `json:"id" db:"id"`
+	EntryID       uuid.UUID  `json:"entry_id" db:"entry_id"`
+	WalletID      *uuid.UUID `json:"wallet_id" db:"wallet_id"`
+	SystemAccount *string    `json:"system_account" db:"system_account"`
+	Currency      string     `json:"currency" db:"currency"`
+	Amount        int64      `json:"amount" db:"amount"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+}
+
+func (Posting) TableName() string {
+	return "ledger_postings"
+}
+
+// SystemAccountBalance is the sum of a system account's postings in one
+// currency.
+type SystemAccountBalance struct {
+	Account  string `json:"account" db:"account"`
+	Currency string `json:"currency" db:"currency"`
+	Balance  int64  `json:"balance" db:"balance"`
+}
+
+// WalletPosting and SystemPosting build a posting of entry for a wallet or a
+// system account.
+func WalletPosting(entryID, walletID uuid.UUID, currency string, amount int64) Posting {
+	return Posting{ID: uuid.New(), EntryID: entryID, WalletID: &walletID, Currency: currency, Amount: amount}
+}
+
+func SystemPosting(entryID uuid.UUID, account, currency string, amount int64) Posting {
+	return Posting{ID: uuid.New(), EntryID: entryID, SystemAccount: &account, Currency: currency, Amount: amount}
+}
+
+// CheckBalanced returns ErrUnbalancedEntry unless the postings of every entry
+// sum to zero in every currency and each posting names exactly one account.
+func CheckBalanced(postings []Posting) error {
+	type key struct {
+		entry    uuid.UUID
+		currency string
+	}
+	sums := make(map[key]int64)
+	for _, p := range postings {
+		if (p.WalletID == nil) == (p.SystemAccount == nil) {
+			return fmt.Errorf("%w: posting %s must name one account", ErrUnbalancedEntry, p.ID)
+		}
+		if p.Amount == 0 || p.Currency == "" {
+			return fmt.Errorf("%w: posting %s has no amount or currency", ErrUnbalancedEntry, p.ID)
+		}
+		sums[key{p.EntryID, p.Currency}] += p.Amount
+	}
+	for k, sum := range sums {
+		if sum != 0 {
+			return fmt.Errorf("%w: entry %s is off by %d %s", ErrUnbalancedEntry, k.entry, sum, k.currency)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCheckBalanced(t *testing.T) {
+	entry, other := uuid.New(), uuid.New()
+	wallet := uuid.New()
+
+	cases := []struct {
+		name     string
+		postings []Posting
+		ok       bool
+	}{
+		{"deposit", []Posting{
+			WalletPosting(entry, wallet, "RUB", 100),
+			SystemPosting(entry, SystemAccountExternalCash, "RUB", -100),
+		}, true},
+		{"two entries", []Posting{
+			WalletPosting(entry, wallet, "RUB", 100),
+			SystemPosting(entry, SystemAccountExternalCash, "RUB", -100),
+			WalletPosting(other, wallet, "RUB", -40),
+			SystemPosting(other, SystemAccountExternalCash, "RUB", 40),
+		}, true},
+		{"one side", []Posting{
+			WalletPosting(entry, wallet, "RUB", 100),
+		}, false},
+		{"balanced only across entries", []Posting{
+			WalletPosting(entry, wallet, "RUB", 100),
+			SystemPosting(other, SystemAccountExternalCash, "RUB", -100),
+		}, false},
+		{"currencies differ", []Posting{
+			WalletPosting(entry, wallet, "RUB", 100),
+			SystemPosting(entry, SystemAccountExternalCash, "USD", -100),
+		}, false},
+		{"no account", []Posting{
+			{ID: uuid.New(), EntryID: entry, Currency: "RUB", Amount: 100},
+			SystemPosting(entry, SystemAccountExternalCash, "RUB", -100),
+		}, false},
+		{"zero amount", []Posting{
+			WalletPosting(entry, wallet, "RUB", 0),
+		}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckBalanced(tc.postings)
+			if tc.ok && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !tc.ok && !errors.Is(err, ErrUnbalancedEntry) {
+				t.Errorf("want ErrUnbalancedEntry, got %v", err)
+			}
+		})
+	}
+}
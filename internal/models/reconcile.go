@@ -0,0 +1,18 @@
+package models
+
+import "github.com/google/uuid"
+
+// BalanceMismatch is a wallet whose stored balance differs from the sum of
+// its ledger postings. Fixed is set once an adjustment entry was written.
+type BalanceMismatch struct {
+	WalletID       uuid.UUID `json:"wallet_id" db:"wallet_id"`
+	Currency       string    `json:"currency" db:"currency"`
+	Balance        int64     `json:"balance" db:"balance"`
+	HistoryBalance int64     `json:"history_balance" db:"history_balance"`
+	Fixed          bool      `json:"fixed" db:"-"`
+}
+
+// Drift is how much the stored balance exceeds the history.
+func (m BalanceMismatch) Drift() int64 {
+	return m.Balance - m.HistoryBalance
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ScheduleStatus string
+
+const (
+	ScheduleStatusActive    ScheduleStatus = "ACTIVE"
+	ScheduleStatusPaused    ScheduleStatus = "PAUSED"
+	ScheduleStatusCancelled ScheduleStatus = "CANCELLED"
+	ScheduleStatusCompleted ScheduleStatus = "COMPLETED"
+	ScheduleStatusFailed    ScheduleStatus = "FAILED"
+)
+
+// CanTransitionTo reports whether a client may move a schedule from s to
+// next. Completed, failed and cancelled schedules are final.
+func (s ScheduleStatus) CanTransitionTo(next ScheduleStatus) bool {
+	switch next {
+	case ScheduleStatusPaused:
+		return s == ScheduleStatusActive
+	case ScheduleStatusActive:
+		return s == ScheduleStatusPaused
+	case ScheduleStatusCancelled:
+		return s == ScheduleStatusActive || s == ScheduleStatusPaused
+	}
+	return false
+}
+
+// Schedule runs a DEPOSIT into WalletID or a TRANSFER from WalletID to
+// ToWalletID at NextRunAt. A schedule without Cron runs once and then becomes
+// COMPLETED or FAILED; a recurring one moves NextRunAt to the next match of
+// Cron after each run, whether the run succeeded or not. LastError is the
+// error of the latest run, empty if it succeeded.
+type Schedule struct {
+	ID              uuid.UUID      `json:"id" db:"id"`
+	WalletID        uuid.UUID      `json:"wallet_id" db:"wallet_id"`
+	ToWalletID      *uuid.UUID     `json:"to_wallet_id" db:"to_wallet_id"`
+	OperationType   string         `json:"operation_type" db:"operation_type"`
+	Amount          int64          `json:"amount" db:"amount"`
+	Currency        string         `json:"currency" db:"currency"`
+	Cron            string         `json:"cron" db:"cron"`
+	Status          ScheduleStatus `json:"status" db:"status"`
+	NextRunAt       *time.Time     `json:"next_run_at" db:"next_run_at"`
+	RunCount        int64          `json:"run_count" db:"run_count"`
+	LastRunAt       *time.Time     `json:"last_run_at" db:"last_run_at"`
+	LastOperationID *uuid.UUID     `json:"last_operation_id" db:"last_operation_id"`
+	LastError       string         `json:"last_error" db:"last_error"`
+	CreatedAt       time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+func (Schedule) TableName() string {
+	return "schedules"
+}
+
+// Operation builds the operation of the next run of the schedule. Its ID and
+// idempotency key depend only on the schedule and the run number, so a run
+// retried after a crash is not applied twice.
+func (s Schedule) Operation() Operation {
+	run := strconv.FormatInt(s.RunCount+1, 10)
+	key := "schedule:" + s.ID.String() + ":" + run
+	op := Operation{
+		ID:             uuid.NewSHA1(s.ID, []byte(run)),
+		Type:           s.OperationType,
+		WalletID:       s.WalletID.String(),
+		Amount:         s.Amount,
+		Currency:       s.Currency,
+		IdempotencyKey: key,
+		RequestHash:    key,
+	}
+	if s.ToWalletID != nil {
+		op.ToWalletID = s.ToWalletID.String()
+	}
+	return op
+}
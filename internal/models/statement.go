@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Statement summarizes a wallet's ledger rows created in [From, To).
+// OpeningBalance is the balance before the first of them and ClosingBalance
+// the balance after the last one.
+type Statement struct {
+	WalletID         uuid.UUID `json:"wallet_id" db:"wallet_id"`
+	Currency         string    `json:"currency" db:"currency"`
+	CurrencyExponent int       `json:"currency_exponent" db:"currency_exponent"`
+	From             time.Time `json:"from" db:"from"`
+	To               time.Time `json:"to" db:"to"`
+	OpeningBalance   int64     `json:"opening_balance" db:"opening_balance"`
+	ClosingBalance   int64     `json:"closing_balance" db:"closing_balance"`
+}
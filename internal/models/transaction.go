@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Transaction is an append-only ledger row, one per client operation on a
+// wallet (a transfer writes one row on each side).
+type Transaction struct {
+	ID           uuid.UUID `json:"id" db:"id"`
+	WalletID     uuid.UUID `json:"wallet_id" db:"wallet_id"`
+	Type         string    `json:"type" db:"type"`
+	Amount       int64     `json:"amount" db:"amount"`
+	BalanceAfter int64     `json:"balance_after" db:"balance_after"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+
+	TransferID           *uuid.UUID `json:"transfer_id" db:"transfer_id"`
+	CounterpartyWalletID *uuid.UUID `json:"counterparty_wallet_id" db:"counterparty_wallet_id"`
+	HoldID               *uuid.UUID `json:"hold_id" db:"hold_id"`
+	// ReversalOf links a REVERSAL_IN/REVERSAL_OUT row to the operation it undoes
+	ReversalOf *uuid.UUID `json:"reversal_of" db:"reversal_of"`
+	// FeeFor links a FEE row to the operation it was charged on
+	FeeFor *uuid.UUID `json:"fee_for" db:"fee_for"`
+	// FXRate is the rate a CONVERSION_IN/CONVERSION_OUT row was converted at
+	FXRate *Rate `json:"fx_rate" db:"fx_rate"`
+}
+
+func (Transaction) TableName() string {
+	return "wallet_transactions"
+}
+
+// SignedAmount is Amount with the sign of its effect on the wallet balance:
+// positive for credits, negative for debits.
+func (t Transaction) SignedAmount() int64 {
+	switch t.Type {
+	case "DEPOSIT", "TRANSFER_IN", "ADJUSTMENT_IN", "REVERSAL_IN", "CONVERSION_IN":
+		return t.Amount
+	}
+	return -t.Amount
+}
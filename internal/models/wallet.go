@@ -7,9 +7,70 @@ import (
 	"github.com/google/uuid"
 )
 
+type WalletStatus string
+
+const (
+	WalletStatusActive WalletStatus = "ACTIVE"
+	WalletStatusFrozen WalletStatus = "FROZEN"
+	WalletStatusClosed WalletStatus = "CLOSED"
+)
+
+// CanTransitionTo reports whether a wallet may move from s to next.
+// A closed wallet is final.
+func (s WalletStatus) CanTransitionTo(next WalletStatus) bool {
+	switch next {
+	case WalletStatusFrozen:
+		return s == WalletStatusActive
+	case WalletStatusActive:
+		return s == WalletStatusFrozen
+	case WalletStatusClosed:
+		return s == WalletStatusActive || s == WalletStatusFrozen
+	}
+	return false
+}
+
+// Err returns the error for balance operations on a wallet in status s,
+// or nil if the wallet accepts them.
+func (s WalletStatus) Err() error {
+	switch s {
+	case WalletStatusFrozen:
+		return ErrWalletFrozen
+	case WalletStatusClosed:
+		return ErrWalletClosed
+	}
+	return nil
+}
+
+// Wallet balances are stored in minor units of Currency; CurrencyExponent is
+// the number of minor-unit digits (2 means the balance is in cents). Held is
+// the sum of active holds. CreditLimit is how far the balance may go below
+// zero; Held never exceeds Balance plus CreditLimit.
 type Wallet struct {
-	ID        uuid.UUID `json:"id" db:"id"`
-	Balance   int64     `json:"balance" db:"balance"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID               uuid.UUID    `json:"id" db:"id"`
+	Balance          int64        `json:"balance" db:"balance"`
+	Held             int64        `json:"held" db:"held"`
+	CreditLimit      int64        `json:"credit_limit" db:"credit_limit"`
+	Status           WalletStatus `json:"status" db:"status"`
+	Currency         string       `json:"currency" db:"currency"`
+	CurrencyExponent int          `json:"currency_exponent" db:"currency_exponent"`
+	CreatedAt        time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time    `json:"updated_at" db:"updated_at"`
+
+	WalletMetadata
+}
+
+// Balance is what a wallet balance query reports. Available is what can
+// still be spent: the balance minus active holds plus the credit limit.
+type Balance struct {
+	Balance          int64  `json:"balance" db:"balance"`
+	Available        int64  `json:"available" db:"available"`
+	CreditLimit      int64  `json:"credit_limit" db:"credit_limit"`
+	Currency         string `json:"currency" db:"currency"`
+	CurrencyExponent int    `json:"currency_exponent" db:"currency_exponent"`
+}
+
+// RemainingCredit is the part of the credit limit not yet used by a negative
+// balance or holds.
+func (b Balance) RemainingCredit() int64 {
+	return min(b.CreditLimit, b.Available)
 }
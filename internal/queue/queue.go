@@ -3,64 +3,367 @@ package queue
 
 import (
 	"context"
+	"errors"
+	"hash/fnv"
+	"sync"
 	"time"
+
+	"test-psql/internal/models"
 )
 
 type opRequest struct {
-	Op       string
-	WalletID string
-	Amount   int64
-	Result   chan error
+	models.Operation
+	// Batch holds the ops of a BATCH request, applied in one transaction
+	Batch  []models.Operation
+	Result chan error
+	// meet, if set, makes the request a marker of a request that changes
+	// wallets of several partitions
+	meet *rendezvous
+}
+
+// rendezvous runs a request that changes wallets of several partitions once
+// every one of them has reached its marker, so the request keeps its place
+// among the ops of each of its wallets.
+type rendezvous struct {
+	req *opRequest
+	// ready is closed once the markers are queued; err is set if the
+	// request could not be queued in all of its partitions
+	ready chan struct{}
+	parts int
+	err   error
+
+	mu       sync.Mutex
+	arrived  int
+	applying bool
+	failed   bool
+	// done is closed once the request is applied or failed
+	done chan struct{}
 }
 
 type walletRepo interface {
-	GetBalance(ctx context.Context, walletID string) (int64, error)
-	Deposit(ctx context.Context, walletID string, amount int64) error
-	Withdraw(ctx context.Context, walletID string, amount int64) error
+	GetBalance(ctx context.Context, walletID string) (models.Balance, error)
+	Deposit(ctx context.Context, walletID string, ops []models.Operation) ([]models.Transaction, error)
+	Withdraw(ctx context.Context, walletID string, ops []models.Operation) ([]models.Transaction, error)
+	WithdrawEach(ctx context.Context, walletID string, ops []models.Operation) ([]error, error)
+	Transfer(ctx context.Context, op models.Operation) ([]models.Transaction, error)
+	ApplyBatch(ctx context.Context, ops []models.Operation) ([]models.Transaction, error)
+	ApplyGroups(ctx context.Context, groups []models.OpGroup) ([]models.GroupResult, error)
 }
 
+const (
+	// DefaultEnqueueTimeout is how long Add waits for room in a full queue
+	// unless SetEnqueueTimeout says otherwise.
+	DefaultEnqueueTimeout = time.Second
+	// DefaultWorkers is the number of workers unless SetWorkers says
+	// otherwise.
+	DefaultWorkers = 10
+	// crossRetry is how often a request that spans partitions checks them
+	// for room while they are full.
+	crossRetry = 5 * time.Millisecond
+)
+
+// Queue is split into partitions chosen by a hash of the wallet ID, one per
+// worker. Each partition has its own buffer, flush timer and a single
+// worker, so ops on different wallets are applied in parallel while ops on
+// one wallet are applied one flush after another in arrival order. A
+// TRANSFER or BATCH whose wallets fall into several partitions is queued
+// into each of them and applied once all of them have reached it.
 type Queue struct {
-	opsChan     chan *opRequest
-	walletRepo  walletRepo
-	buffSize    int
-	flushPeriod time.Duration
+	partitions     []chan *opRequest
+	walletRepo     walletRepo
+	buffSize       int
+	flushPeriod    time.Duration
+	enqueueTimeout time.Duration
+
+	// mu guards closed: enqueue holds it for reading while it sends, so once
+	// Drain has set closed no op can slip into a partition
+	mu     sync.RWMutex
+	closed bool
+	// crossMu makes requests that span partitions queue their markers one
+	// request at a time; it is never held while waiting for room
+	crossMu sync.Mutex
+	// halted is closed once a partition has stopped on the context of
+	// ProcessQueue: markers left in it will never be reached
+	haltOnce sync.Once
+	halted   chan struct{}
+	// drainC is closed by Drain to make ProcessQueue flush and return;
+	// drained is closed when it has returned
+	drainOnce sync.Once
+	drainC    chan struct{}
+	drained   chan struct{}
+	// Workers run under workCtx rather than the context of ProcessQueue, so
+	// stopping the loop does not cancel the writes already under way or
+	// waiting for a worker
+	workers    sync.WaitGroup
+	workCtx    context.Context
+	workCancel context.CancelFunc
 }
 
 func NewQueue(walletRepo walletRepo, buffSize int, flushPeriod time.Duration) *Queue {
-	return &Queue{
-		opsChan:     make(chan *opRequest, buffSize),
-		walletRepo:  walletRepo,
-		buffSize:    buffSize,
-		flushPeriod: flushPeriod,
+	workCtx, workCancel := context.WithCancel(context.Background())
+	q := &Queue{
+		walletRepo:     walletRepo,
+		buffSize:       buffSize,
+		flushPeriod:    flushPeriod,
+		enqueueTimeout: DefaultEnqueueTimeout,
+		drainC:         make(chan struct{}),
+		drained:        make(chan struct{}),
+		halted:         make(chan struct{}),
+		workCtx:        workCtx,
+		workCancel:     workCancel,
 	}
+	q.SetWorkers(DefaultWorkers)
+	return q
+}
+
+// SetEnqueueTimeout sets how long Add and AddBatch wait for room in a full
+// partition before giving up with a *models.QueueFullError; zero or less
+// fails at once.
+func (q *Queue) SetEnqueueTimeout(timeout time.Duration) {
+	q.enqueueTimeout = timeout
+}
+
+// SetWorkers splits the queue into n partitions with a worker each, so at
+// most n batches are applied at once. Every partition buffers buffSize ops.
+// It must be called before the queue is used.
+func (q *Queue) SetWorkers(n int) {
+	q.partitions = make([]chan *opRequest, n)
+	for i := range q.partitions {
+		q.partitions[i] = make(chan *opRequest, q.buffSize)
+	}
+}
+
+// Add queues op; its result is sent to result once the op is applied. It
+// returns ctx.Err() if ctx ends first, a *models.QueueFullError if the
+// partition has no room within the enqueue timeout and models.ErrQueueClosed
+// once the queue is draining.
+func (q *Queue) Add(ctx context.Context, op models.Operation, result chan error) error {
+	return q.submit(ctx, &opRequest{Operation: op, Result: result})
 }
 
-func (q *Queue) Add(ctx context.Context, op, walletID string, amount int64, result chan error) {
-	q.opsChan <- &opRequest{Op: op, WalletID: walletID, Amount: amount, Result: result}
+// AddBatch queues ops that must take effect together or not at all. It fails
+// the same way as Add.
+func (q *Queue) AddBatch(ctx context.Context, ops []models.Operation, result chan error) error {
+	return q.submit(ctx, &opRequest{Operation: models.Operation{Type: "BATCH"}, Batch: ops, Result: result})
 }
 
+// submit queues req into the partition of its wallets or, if they fall into
+// several, a marker into each of them.
+func (q *Queue) submit(ctx context.Context, req *opRequest) error {
+	var parts []chan *opRequest
+	seen := make(map[chan *opRequest]bool)
+	for _, id := range req.wallets() {
+		if ops := q.partition(id); !seen[ops] {
+			seen[ops] = true
+			parts = append(parts, ops)
+		}
+	}
+	switch len(parts) {
+	case 0:
+		return q.enqueue(ctx, q.partition(req.WalletID), req)
+	case 1:
+		return q.enqueue(ctx, parts[0], req)
+	}
+
+	if q.enqueueTimeout <= 0 {
+		return q.enqueueMarkers(parts, req)
+	}
+	timer := time.NewTimer(q.enqueueTimeout)
+	defer timer.Stop()
+	retry := time.NewTicker(crossRetry)
+	defer retry.Stop()
+	for {
+		err := q.enqueueMarkers(parts, req)
+		if !errors.Is(err, models.ErrQueueFull) {
+			return err
+		}
+		select {
+		case <-retry.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			return err
+		}
+	}
+}
+
+// enqueueMarkers queues a marker of req into each of parts if all of them
+// have room, and fails with *models.QueueFullError at once otherwise.
+func (q *Queue) enqueueMarkers(parts []chan *opRequest, req *opRequest) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return models.ErrQueueClosed
+	}
+	// Маркеры ставятся по одному запросу за раз, поэтому маркеры двух
+	// запросов стоят в общих партициях в одном порядке и не ждут друг
+	// друга по кругу
+	q.crossMu.Lock()
+	defer q.crossMu.Unlock()
+	for _, ops := range parts {
+		if len(ops) == cap(ops) {
+			return q.fullErr(ops)
+		}
+	}
+	meet := &rendezvous{req: req, ready: make(chan struct{}), parts: len(parts), done: make(chan struct{})}
+	defer close(meet.ready)
+	for _, ops := range parts {
+		select {
+		case ops <- &opRequest{meet: meet}:
+		default:
+			// Место успели занять обычные операции: уже поставленные
+			// маркеры пропускаются
+			meet.err = q.fullErr(ops)
+			return meet.err
+		}
+	}
+	return nil
+}
+
+// Depth returns the number of operations waiting to be picked up in all
+// partitions.
+func (q *Queue) Depth() int {
+	depth := 0
+	for _, ops := range q.partitions {
+		depth += len(ops)
+	}
+	return depth
+}
+
+// partition returns the partition of walletID.
+func (q *Queue) partition(walletID string) chan *opRequest {
+	h := fnv.New32a()
+	h.Write([]byte(walletID))
+	return q.partitions[h.Sum32()%uint32(len(q.partitions))]
+}
+
+func (q *Queue) enqueue(ctx context.Context, ops chan *opRequest, req *opRequest) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return models.ErrQueueClosed
+	}
+	select {
+	case ops <- req:
+		return nil
+	default:
+	}
+	if q.enqueueTimeout <= 0 {
+		return q.fullErr(ops)
+	}
+	timer := time.NewTimer(q.enqueueTimeout)
+	defer timer.Stop()
+	select {
+	case ops <- req:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return q.fullErr(ops)
+	}
+}
+
+// fullErr estimates the wait from the depth of the full partition: every
+// flush takes at most buffSize operations and happens at least once per
+// flush period.
+func (q *Queue) fullErr(ops chan *opRequest) error {
+	depth := len(ops)
+	flushes := depth/q.buffSize + 1
+	return &models.QueueFullError{Depth: depth, RetryAfter: time.Duration(flushes) * q.flushPeriod}
+}
+
+// Drain stops the queue for good: new ops are rejected with
+// models.ErrQueueClosed, the ops already accepted are flushed and Drain waits
+// for the workers to apply them. If ctx ends first, the workers' database
+// calls are cancelled, so every waiting caller still gets a reply, and Drain
+// returns ctx.Err() once they have returned. ProcessQueue must be running.
+func (q *Queue) Drain(ctx context.Context) error {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	q.drainOnce.Do(func() { close(q.drainC) })
+
+	done := make(chan struct{})
+	go func() {
+		<-q.drained
+		q.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		q.workCancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// ProcessQueue runs every partition until ctx ends or, after flushing every
+// accepted op, until Drain is called; the workers finish the batches already
+// handed to them either way.
 func (q *Queue) ProcessQueue(ctx context.Context) {
+	var loops sync.WaitGroup
+	for _, ops := range q.partitions {
+		loops.Add(1)
+		go func() {
+			defer loops.Done()
+			q.processPartition(ctx, ops)
+		}()
+	}
+	loops.Wait()
+	close(q.drained)
+}
+
+// processPartition collects the ops of one partition into batches every
+// flush period or once buffSize ops are waiting and hands them to the
+// partition's worker. One batch may wait for the worker; beyond that flushing
+// blocks, ops pile up in the partition and Add starts to fail with
+// *models.QueueFullError.
+func (q *Queue) processPartition(ctx context.Context, ops chan *opRequest) {
 	ticker := time.NewTicker(q.flushPeriod)
 	defer ticker.Stop()
 	buff := make([]*opRequest, 0, q.buffSize)
 
+	batches := make(chan []*opRequest, 1)
+	defer close(batches)
+	q.workers.Add(1)
+	go func() {
+		defer q.workers.Done()
+		for batch := range batches {
+			q.worker(q.workCtx, batch)
+		}
+	}()
+
 	flush := func() {
 		if len(buff) == 0 {
 			return
 		}
 		batch := make([]*opRequest, len(buff))
 		copy(batch, buff)
-		go q.worker(ctx, batch)
+		batches <- batch
 		buff = buff[:0]
 	}
 
 	for {
 		select {
 		case <-ctx.Done():
+			q.haltOnce.Do(func() { close(q.halted) })
+			return
+		case <-q.drainC:
+			// Новые операции уже не принимаются, поэтому достаточно
+			// выбрать из канала то, что в нём осталось
+			for len(ops) > 0 {
+				buff = append(buff, <-ops)
+				if len(buff) >= q.buffSize {
+					flush()
+				}
+			}
+			flush()
 			return
 		case <-ticker.C:
 			flush()
-		case req := <-q.opsChan:
+		case req := <-ops:
 			buff = append(buff, req)
 			if len(buff) >= q.buffSize {
 				flush()
@@ -69,36 +372,240 @@ func (q *Queue) ProcessQueue(ctx context.Context) {
 	}
 }
 
+// phase is one step of a flush: its groups are applied together, then its
+// barriers one by one in arrival order.
+type phase struct {
+	groups        []models.OpGroup
+	groupRequests [][]*opRequest
+	// barriers are TRANSFER and BATCH requests, which touch several wallets
+	// and run each in its own transaction
+	barriers []*opRequest
+}
+
+// wallets returns the wallets a request changes.
+func (r *opRequest) wallets() []string {
+	switch r.Type {
+	case "TRANSFER":
+		return []string{r.WalletID, r.ToWalletID}
+	case "BATCH":
+		wallets := make([]string, 0, len(r.Batch))
+		for _, op := range r.Batch {
+			wallets = append(wallets, op.WalletID)
+		}
+		return wallets
+	}
+	return []string{r.WalletID}
+}
+
+// worker applies a batch of one partition. A marker splits it: the ops
+// before it are applied first, then the worker waits at the marker, and the
+// ops after it are applied once its request is.
 func (q *Queue) worker(ctx context.Context, batch []*opRequest) {
+	start := 0
+	for i, req := range batch {
+		if req.meet == nil {
+			continue
+		}
+		q.apply(ctx, batch[start:i])
+		q.join(ctx, req.meet)
+		start = i + 1
+	}
+	q.apply(ctx, batch[start:])
+}
+
+// join waits at a marker of m until every partition of its request has
+// reached one. The last partition to arrive applies the request while the
+// others wait. If ctx ends or another partition stops before reaching its
+// marker, the request fails instead.
+func (q *Queue) join(ctx context.Context, m *rendezvous) {
+	<-m.ready
+	if m.err != nil {
+		return
+	}
+	m.mu.Lock()
+	m.arrived++
+	m.applying = m.arrived == m.parts && !m.failed
+	apply := m.applying
+	m.mu.Unlock()
+	if apply {
+		reply(m.req, q.applyRequest(ctx, m.req))
+		close(m.done)
+		return
+	}
+	select {
+	case <-m.done:
+	case <-ctx.Done():
+		m.fail(ctx.Err())
+	case <-q.halted:
+		m.fail(models.ErrQueueClosed)
+	}
+}
+
+// fail replies err to the request of m unless it is being applied already,
+// in which case it waits for that to finish.
+func (m *rendezvous) fail(err error) {
+	m.mu.Lock()
+	switch {
+	case m.applying:
+		m.mu.Unlock()
+		<-m.done
+		return
+	case m.failed:
+		m.mu.Unlock()
+		return
+	}
+	m.failed = true
+	m.mu.Unlock()
+	reply(m.req, err)
+	close(m.done)
+}
+
+// apply applies ops of one partition with no markers among them.
+func (q *Queue) apply(ctx context.Context, batch []*opRequest) {
 	if len(batch) == 0 {
 		return
 	}
-	type key struct {
-		walletID string
-		op       string
+	// Операции кошелька собираются в группы в порядке поступления: подряд
+	// идущие операции одного типа и валюты попадают в одну группу, а группы
+	// кошелька применяются по порядку, поэтому пополнение успевает до
+	// следующего за ним списания. Валюта входит в ключ: операции в разных
+	// валютах никогда не агрегируются в одно обновление.
+	// Перевод или пакет разделяет операции каждого своего кошелька: он
+	// выполняется после групп, пришедших до него, а группы, пришедшие
+	// после, попадают в следующую фазу. phaseOf — фаза, в которую попадёт
+	// следующая группа кошелька
+	var phases []*phase
+	at := func(i int) *phase {
+		for len(phases) <= i {
+			phases = append(phases, &phase{})
+		}
+		return phases[i]
 	}
-	byKey := make(map[key][]*opRequest)
+	phaseOf := make(map[string]int)
+	lastGroup := make(map[string]int)
+	// Повторы с тем же Idempotency-Key внутри батча не применяются,
+	// а получают результат первого запроса
+	leaders := make(map[string]*opRequest)
+	duplicates := make(map[*opRequest][]*opRequest)
 	for _, req := range batch {
-		k := key{walletID: req.WalletID, op: req.Op}
-		byKey[k] = append(byKey[k], req)
+		if req.IdempotencyKey != "" {
+			if leader, ok := leaders[req.IdempotencyKey]; ok {
+				duplicates[leader] = append(duplicates[leader], req)
+				continue
+			}
+			leaders[req.IdempotencyKey] = req
+		}
+		if req.Type == "TRANSFER" || req.Type == "BATCH" {
+			wallets := req.wallets()
+			i := 0
+			for _, w := range wallets {
+				i = max(i, phaseOf[w])
+			}
+			at(i).barriers = append(at(i).barriers, req)
+			for _, w := range wallets {
+				phaseOf[w] = i + 1
+				delete(lastGroup, w)
+			}
+			continue
+		}
+		// Операции передаются по отдельности, чтобы в журнал попала
+		// каждая исходная операция, а не агрегат батча
+		ph := at(phaseOf[req.WalletID])
+		if i, ok := lastGroup[req.WalletID]; ok && ph.groups[i].Type == req.Type && ph.groups[i].Currency == req.Currency {
+			ph.groups[i].Ops = append(ph.groups[i].Ops, req.Operation)
+			ph.groupRequests[i] = append(ph.groupRequests[i], req)
+			continue
+		}
+		lastGroup[req.WalletID] = len(ph.groups)
+		ph.groups = append(ph.groups, models.OpGroup{WalletID: req.WalletID, Type: req.Type, Currency: req.Currency, Ops: []models.Operation{req.Operation}})
+		ph.groupRequests = append(ph.groupRequests, []*opRequest{req})
 	}
-	for k, requests := range byKey {
-		var totalAmount int64
-		for _, req := range requests {
-			totalAmount += req.Amount
+	replyAll := func(req *opRequest, err error) {
+		reply(req, err)
+		for _, dup := range duplicates[req] {
+			if dup.RequestHash != req.RequestHash {
+				reply(dup, models.ErrIdempotencyKeyReused)
+				continue
+			}
+			reply(dup, err)
 		}
+	}
+	for _, ph := range phases {
+		q.applyPhase(ctx, ph, replyAll)
+	}
+}
+
+// applyPhase applies the groups of ph and then its barriers.
+func (q *Queue) applyPhase(ctx context.Context, ph *phase, replyAll func(*opRequest, error)) {
+	// Все группы фазы применяются одним запросом к базе. Группы, которые
+	// он не применил, а при его ошибке все группы выполняются по
+	// отдельности в том же порядке: так каждая получает свою ошибку
+	var results []models.GroupResult
+	if len(ph.groups) > 0 {
 		var err error
-		switch k.op {
-		case "DEPOSIT":
-			err = q.walletRepo.Deposit(ctx, k.walletID, totalAmount)
-		case "WITHDRAW":
-			err = q.walletRepo.Withdraw(ctx, k.walletID, totalAmount)
-		}
-		for _, req := range requests {
-			select {
-			case req.Result <- err:
-			default:
+		if results, err = q.walletRepo.ApplyGroups(ctx, ph.groups); err != nil {
+			results = nil
+		}
+	}
+	for i, g := range ph.groups {
+		if results != nil && results[i].Applied {
+			for _, req := range ph.groupRequests[i] {
+				replyAll(req, nil)
 			}
+			continue
 		}
+		q.applyGroup(ctx, g, ph.groupRequests[i], replyAll)
+	}
+	// Переводы затрагивают два кошелька, поэтому не агрегируются и
+	// выполняются каждый в своей транзакции, как и атомарные батчи клиента
+	for _, req := range ph.barriers {
+		replyAll(req, q.applyRequest(ctx, req))
+	}
+}
+
+// applyRequest applies a TRANSFER or BATCH request in its own transaction.
+func (q *Queue) applyRequest(ctx context.Context, req *opRequest) error {
+	var err error
+	if req.Type == "TRANSFER" {
+		_, err = q.walletRepo.Transfer(ctx, req.Operation)
+	} else {
+		_, err = q.walletRepo.ApplyBatch(ctx, req.Batch)
+	}
+	return err
+}
+
+// applyGroup applies one group of DEPOSIT or WITHDRAW ops in its own
+// transaction and replies to its requests.
+func (q *Queue) applyGroup(ctx context.Context, g models.OpGroup, requests []*opRequest, replyAll func(*opRequest, error)) {
+	var err error
+	switch g.Type {
+	case "DEPOSIT":
+		_, err = q.walletRepo.Deposit(ctx, g.WalletID, g.Ops)
+	case "WITHDRAW":
+		_, err = q.walletRepo.Withdraw(ctx, g.WalletID, g.Ops)
+		// Если сумма батча не проходит по балансу, запросы применяются
+		// по одному в порядке поступления, и отклоняются только те,
+		// которые действительно уводят баланс в минус
+		if errors.Is(err, models.ErrInsufficientBalance) && len(g.Ops) > 1 {
+			errs, err := q.walletRepo.WithdrawEach(ctx, g.WalletID, g.Ops)
+			for i, req := range requests {
+				if err != nil {
+					replyAll(req, err)
+					continue
+				}
+				replyAll(req, errs[i])
+			}
+			return
+		}
+	}
+	for _, req := range requests {
+		replyAll(req, err)
+	}
+}
+
+func reply(req *opRequest, err error) {
+	select {
+	case req.Result <- err:
+	default:
 	}
 }
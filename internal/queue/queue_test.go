@@ -0,0 +1,695 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"test-psql/internal/models"
+)
+
+// stubWalletRepo pays withdrawals out of available and rejects a call
+// whose total does not fit, like the real repo.
+type stubWalletRepo struct {
+	mu            sync.Mutex
+	available     int64
+	eachErr       error
+	withdrawCalls [][]models.Operation
+	eachCalls     [][]models.Operation
+	deposits      int
+	// blockDeposit makes Deposit wait until its context ends
+	blockDeposit bool
+	// release, if set, holds Deposit until it is closed
+	release   chan struct{}
+	active    int
+	maxActive int
+	// groups makes ApplyGroups apply what fits into available; otherwise
+	// it applies nothing
+	groups      bool
+	groupsErr   error
+	groupsCalls int
+	gotGroups   []models.OpGroup
+}
+
+func (s *stubWalletRepo) GetBalance(ctx context.Context, walletID string) (models.Balance, error) {
+	return models.Balance{}, nil
+}
+
+func (s *stubWalletRepo) Deposit(ctx context.Context, walletID string, ops []models.Operation) ([]models.Transaction, error) {
+	if s.blockDeposit {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if s.release != nil {
+		s.mu.Lock()
+		s.active++
+		s.maxActive = max(s.maxActive, s.active)
+		s.mu.Unlock()
+		<-s.release
+		s.mu.Lock()
+		s.active--
+		s.mu.Unlock()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deposits += len(ops)
+	for _, op := range ops {
+		s.available += op.Amount
+	}
+	return nil, nil
+}
+
+func (s *stubWalletRepo) Withdraw(ctx context.Context, walletID string, ops []models.Operation) ([]models.Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.withdrawCalls = append(s.withdrawCalls, ops)
+	var total int64
+	for _, op := range ops {
+		total += op.Amount
+	}
+	if total > s.available {
+		return nil, models.ErrInsufficientBalance
+	}
+	s.available -= total
+	return nil, nil
+}
+
+func (s *stubWalletRepo) WithdrawEach(ctx context.Context, walletID string, ops []models.Operation) ([]error, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.eachCalls = append(s.eachCalls, ops)
+	if s.eachErr != nil {
+		return nil, s.eachErr
+	}
+	errs := make([]error, len(ops))
+	for i, op := range ops {
+		if op.Amount > s.available {
+			errs[i] = models.ErrInsufficientBalance
+			continue
+		}
+		s.available -= op.Amount
+	}
+	return errs, nil
+}
+
+func (s *stubWalletRepo) ApplyGroups(ctx context.Context, groups []models.OpGroup) ([]models.GroupResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.groupsCalls++
+	s.gotGroups = groups
+	if s.groupsErr != nil {
+		return nil, s.groupsErr
+	}
+	results := make([]models.GroupResult, len(groups))
+	if !s.groups {
+		return results, nil
+	}
+	for i, g := range groups {
+		var total int64
+		for _, op := range g.Ops {
+			total += op.Amount
+		}
+		switch {
+		case g.Type == "DEPOSIT":
+			s.deposits += len(g.Ops)
+			s.available += total
+		case total > s.available:
+			continue
+		default:
+			s.available -= total
+		}
+		results[i] = models.GroupResult{Applied: true}
+	}
+	return results, nil
+}
+
+// Transfer pays transfers out of id1 from available.
+func (s *stubWalletRepo) Transfer(ctx context.Context, op models.Operation) ([]models.Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if op.WalletID != "id1" {
+		return nil, nil
+	}
+	if op.Amount > s.available {
+		return nil, models.ErrInsufficientBalance
+	}
+	s.available -= op.Amount
+	return nil, nil
+}
+
+func (s *stubWalletRepo) ApplyBatch(ctx context.Context, ops []models.Operation) ([]models.Transaction, error) {
+	return nil, nil
+}
+
+// applyAll queues ops into a single partition before the queue starts, so
+// all of them land in one flush in this order, and returns their results.
+func applyAll(repo *stubWalletRepo, ops []models.Operation) []error {
+	q := NewQueue(repo, 50, 20*time.Millisecond)
+	q.SetWorkers(1)
+	results := make([]chan error, len(ops))
+	for i, op := range ops {
+		results[i] = make(chan error, 1)
+		_ = q.Add(context.Background(), op, results[i])
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.ProcessQueue(ctx)
+
+	errs := make([]error, len(ops))
+	for i, result := range results {
+		errs[i] = <-result
+	}
+	return errs
+}
+
+// withdrawAll applies one WITHDRAW from id1 per amount with applyAll.
+func withdrawAll(repo *stubWalletRepo, amounts ...int64) []error {
+	ops := make([]models.Operation, len(amounts))
+	for i, amount := range amounts {
+		ops[i] = models.Operation{WalletID: "id1", Type: "WITHDRAW", Amount: amount}
+	}
+	return applyAll(repo, ops)
+}
+
+func TestQueue_WithdrawFallback(t *testing.T) {
+	t.Run("aggregate fits", func(t *testing.T) {
+		repo := &stubWalletRepo{available: 100}
+		errs := withdrawAll(repo, 30, 70)
+		if errs[0] != nil || errs[1] != nil {
+			t.Fatalf("unexpected results: %v", errs)
+		}
+		if len(repo.withdrawCalls) != 1 || len(repo.eachCalls) != 0 {
+			t.Errorf("want one aggregated call, got %d aggregated and %d per-request", len(repo.withdrawCalls), len(repo.eachCalls))
+		}
+	})
+
+	t.Run("only overdrawing requests fail", func(t *testing.T) {
+		repo := &stubWalletRepo{available: 100}
+		errs := withdrawAll(repo, 60, 50, 40)
+		if errs[0] != nil || !errors.Is(errs[1], models.ErrInsufficientBalance) || errs[2] != nil {
+			t.Fatalf("unexpected results: %v", errs)
+		}
+		if len(repo.eachCalls) != 1 || len(repo.eachCalls[0]) != 3 {
+			t.Fatalf("want one per-request call with 3 ops, got %+v", repo.eachCalls)
+		}
+		for i, amount := range []int64{60, 50, 40} {
+			if repo.eachCalls[0][i].Amount != amount {
+				t.Errorf("op %d: want amount %d in arrival order, got %d", i, amount, repo.eachCalls[0][i].Amount)
+			}
+		}
+		if repo.available != 0 {
+			t.Errorf("want available 0, got %d", repo.available)
+		}
+	})
+
+	t.Run("single request has no fallback", func(t *testing.T) {
+		repo := &stubWalletRepo{available: 10}
+		errs := withdrawAll(repo, 50)
+		if !errors.Is(errs[0], models.ErrInsufficientBalance) {
+			t.Errorf("want ErrInsufficientBalance, got %v", errs[0])
+		}
+		if len(repo.eachCalls) != 0 {
+			t.Error("single request must not be retried")
+		}
+	})
+
+	t.Run("fallback error fails every request", func(t *testing.T) {
+		dbErr := errors.New("db down")
+		repo := &stubWalletRepo{available: 10, eachErr: dbErr}
+		errs := withdrawAll(repo, 20, 5)
+		for i, err := range errs {
+			if !errors.Is(err, dbErr) {
+				t.Errorf("request %d: want db error, got %v", i, err)
+			}
+		}
+	})
+}
+
+func TestQueue_Add(t *testing.T) {
+	op := models.Operation{WalletID: "id1", Type: "DEPOSIT", Amount: 10}
+
+	t.Run("full queue times out", func(t *testing.T) {
+		q := NewQueue(&stubWalletRepo{}, 2, 500*time.Millisecond)
+		q.SetEnqueueTimeout(20 * time.Millisecond)
+		for i := 0; i < 2; i++ {
+			if err := q.Add(context.Background(), op, make(chan error, 1)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		err := q.Add(context.Background(), op, make(chan error, 1))
+		var full *models.QueueFullError
+		if !errors.As(err, &full) || !errors.Is(err, models.ErrQueueFull) {
+			t.Fatalf("want QueueFullError, got %v", err)
+		}
+		if full.Depth != 2 || full.RetryAfter != time.Second {
+			t.Errorf("want depth 2 and retry after 1s, got %+v", full)
+		}
+	})
+
+	t.Run("zero timeout fails at once", func(t *testing.T) {
+		q := NewQueue(&stubWalletRepo{}, 1, time.Second)
+		q.SetEnqueueTimeout(0)
+		_ = q.Add(context.Background(), op, make(chan error, 1))
+
+		start := time.Now()
+		err := q.Add(context.Background(), op, make(chan error, 1))
+		if !errors.Is(err, models.ErrQueueFull) {
+			t.Fatalf("want ErrQueueFull, got %v", err)
+		}
+		if time.Since(start) > 100*time.Millisecond {
+			t.Error("Add must not wait")
+		}
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		q := NewQueue(&stubWalletRepo{}, 1, time.Second)
+		q.SetEnqueueTimeout(time.Minute)
+		_ = q.Add(context.Background(), op, make(chan error, 1))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		err := q.AddBatch(ctx, []models.Operation{op}, make(chan error, 1))
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("want DeadlineExceeded, got %v", err)
+		}
+	})
+
+	t.Run("waits for room", func(t *testing.T) {
+		q := NewQueue(&stubWalletRepo{}, 1, 10*time.Millisecond)
+		_ = q.Add(context.Background(), op, make(chan error, 1))
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go q.ProcessQueue(ctx)
+
+		result := make(chan error, 1)
+		if err := q.Add(context.Background(), op, result); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := <-result; err != nil {
+			t.Errorf("unexpected result: %v", err)
+		}
+	})
+}
+
+func TestQueue_Drain(t *testing.T) {
+	op := models.Operation{WalletID: "id1", Type: "DEPOSIT", Amount: 10}
+
+	t.Run("flushes accepted ops", func(t *testing.T) {
+		repo := &stubWalletRepo{}
+		q := NewQueue(repo, 4, time.Hour)
+		results := make([]chan error, 3)
+		for i := range results {
+			results[i] = make(chan error, 1)
+			if err := q.Add(context.Background(), op, results[i]); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		go q.ProcessQueue(context.Background())
+
+		if err := q.Drain(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for i, result := range results {
+			select {
+			case err := <-result:
+				if err != nil {
+					t.Errorf("op %d: unexpected result: %v", i, err)
+				}
+			default:
+				t.Errorf("op %d: no reply after drain", i)
+			}
+		}
+		if repo.deposits != 3 {
+			t.Errorf("want 3 deposits, got %d", repo.deposits)
+		}
+	})
+
+	t.Run("rejects new ops", func(t *testing.T) {
+		q := NewQueue(&stubWalletRepo{}, 2, time.Hour)
+		go q.ProcessQueue(context.Background())
+		if err := q.Drain(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		err := q.Add(context.Background(), op, make(chan error, 1))
+		if !errors.Is(err, models.ErrQueueClosed) {
+			t.Errorf("want ErrQueueClosed, got %v", err)
+		}
+		if err := q.Drain(context.Background()); err != nil {
+			t.Errorf("second drain: unexpected error: %v", err)
+		}
+	})
+
+	t.Run("deadline cancels workers", func(t *testing.T) {
+		q := NewQueue(&stubWalletRepo{blockDeposit: true}, 2, time.Hour)
+		result := make(chan error, 1)
+		_ = q.Add(context.Background(), op, result)
+		go q.ProcessQueue(context.Background())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if err := q.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("want DeadlineExceeded, got %v", err)
+		}
+		select {
+		case err := <-result:
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("want Canceled, got %v", err)
+			}
+		default:
+			t.Error("caller got no reply")
+		}
+	})
+}
+
+func TestQueue_Partitions(t *testing.T) {
+	t.Run("one wallet is applied one batch at a time", func(t *testing.T) {
+		repo := &stubWalletRepo{release: make(chan struct{})}
+		q := NewQueue(repo, 1, 5*time.Millisecond)
+		q.SetEnqueueTimeout(200 * time.Millisecond)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go q.ProcessQueue(ctx)
+
+		// Одна операция у worker, одна в очереди батчей, одна ждёт отправки
+		// в processPartition и одна в канале партиции: пятой места уже нет
+		results := make([]chan error, 4)
+		for i := range results {
+			results[i] = make(chan error, 1)
+			op := models.Operation{WalletID: "id1", Type: "DEPOSIT", Amount: int64(i + 1)}
+			if err := q.Add(context.Background(), op, results[i]); err != nil {
+				t.Fatalf("op %d: unexpected error: %v", i, err)
+			}
+		}
+		err := q.Add(context.Background(), models.Operation{WalletID: "id1", Type: "DEPOSIT", Amount: 5}, make(chan error, 1))
+		if !errors.Is(err, models.ErrQueueFull) {
+			t.Fatalf("want ErrQueueFull while the worker is busy, got %v", err)
+		}
+
+		close(repo.release)
+		for i, result := range results {
+			if err := <-result; err != nil {
+				t.Errorf("op %d: unexpected result: %v", i, err)
+			}
+		}
+		if repo.maxActive != 1 {
+			t.Errorf("want at most 1 batch at once, got %d", repo.maxActive)
+		}
+	})
+
+	t.Run("wallets in different partitions run in parallel", func(t *testing.T) {
+		repo := &stubWalletRepo{release: make(chan struct{})}
+		q := NewQueue(repo, 50, 5*time.Millisecond)
+		q.SetWorkers(2)
+		wallets := []string{"id1"}
+		for i := 2; len(wallets) < 2; i++ {
+			if id := fmt.Sprintf("id%d", i); q.partition(id) != q.partition("id1") {
+				wallets = append(wallets, id)
+			}
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go q.ProcessQueue(ctx)
+
+		results := make([]chan error, len(wallets))
+		for i, id := range wallets {
+			results[i] = make(chan error, 1)
+			_ = q.Add(context.Background(), models.Operation{WalletID: id, Type: "DEPOSIT", Amount: 10}, results[i])
+		}
+		deadline := time.Now().Add(time.Second)
+		for {
+			repo.mu.Lock()
+			active := repo.active
+			repo.mu.Unlock()
+			if active == 2 {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("want both wallets applied at once, got %d", active)
+			}
+			time.Sleep(time.Millisecond)
+		}
+
+		close(repo.release)
+		for i, result := range results {
+			if err := <-result; err != nil {
+				t.Errorf("op %d: unexpected result: %v", i, err)
+			}
+		}
+	})
+
+	t.Run("transfer waits for both partitions", func(t *testing.T) {
+		repo := &stubWalletRepo{available: 100, release: make(chan struct{})}
+		q := NewQueue(repo, 50, 5*time.Millisecond)
+		q.SetWorkers(2)
+		to := ""
+		for i := 2; to == ""; i++ {
+			if id := fmt.Sprintf("id%d", i); q.partition(id) != q.partition("id1") {
+				to = id
+			}
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go q.ProcessQueue(ctx)
+
+		// Зачисление на кошелёк получателя висит, пока не закрыт release:
+		// перевод, поставленный после него, должен его дождаться
+		deposit := make(chan error, 1)
+		_ = q.Add(context.Background(), models.Operation{WalletID: to, Type: "DEPOSIT", Amount: 10}, deposit)
+		transfer := make(chan error, 1)
+		if err := q.Add(context.Background(), models.Operation{WalletID: "id1", ToWalletID: to, Type: "TRANSFER", Amount: 30}, transfer); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		select {
+		case err := <-transfer:
+			t.Fatalf("transfer applied before the earlier deposit to its destination: %v", err)
+		case <-time.After(50 * time.Millisecond):
+		}
+		close(repo.release)
+		if err := <-deposit; err != nil {
+			t.Errorf("deposit: unexpected result: %v", err)
+		}
+		if err := <-transfer; err != nil {
+			t.Errorf("transfer: unexpected result: %v", err)
+		}
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+		if repo.available != 80 {
+			t.Errorf("want available 80, got %d", repo.available)
+		}
+	})
+
+	t.Run("transfer fails when a partition stops before its marker", func(t *testing.T) {
+		repo := &stubWalletRepo{available: 100, release: make(chan struct{})}
+		q := NewQueue(repo, 1, 5*time.Millisecond)
+		q.SetWorkers(2)
+		q.SetEnqueueTimeout(time.Second)
+		to := ""
+		for i := 2; to == ""; i++ {
+			if id := fmt.Sprintf("id%d", i); q.partition(id) != q.partition("id1") {
+				to = id
+			}
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go q.ProcessQueue(ctx)
+
+		// Партиция получателя занята тремя зачислениями, поэтому маркер
+		// перевода остаётся в её канале и не будет прочитан
+		for range 3 {
+			_ = q.Add(context.Background(), models.Operation{WalletID: to, Type: "DEPOSIT", Amount: 1}, make(chan error, 1))
+		}
+		transfer := make(chan error, 1)
+		if err := q.Add(context.Background(), models.Operation{WalletID: "id1", ToWalletID: to, Type: "TRANSFER", Amount: 30}, transfer); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+
+		select {
+		case err := <-transfer:
+			if !errors.Is(err, models.ErrQueueClosed) {
+				t.Errorf("want ErrQueueClosed, got %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("transfer is still waiting for the stopped partition")
+		}
+		close(repo.release)
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+		if repo.available != 100 {
+			t.Errorf("failed transfer changed available to %d", repo.available)
+		}
+	})
+
+	t.Run("full partition fails a transfer without blocking others", func(t *testing.T) {
+		q := NewQueue(&stubWalletRepo{}, 1, time.Second)
+		q.SetWorkers(4)
+		q.SetEnqueueTimeout(200 * time.Millisecond)
+		full := q.partition("id1")
+		var to, free []string
+		for i := 2; len(to) == 0 || len(free) < 2; i++ {
+			id := fmt.Sprintf("id%d", i)
+			switch ops := q.partition(id); {
+			case ops == full:
+			case len(to) == 0:
+				to = append(to, id)
+			case len(free) == 0 && ops != q.partition(to[0]):
+				free = append(free, id)
+			case len(free) == 1 && ops != q.partition(to[0]) && ops != q.partition(free[0]):
+				free = append(free, id)
+			}
+		}
+		_ = q.Add(context.Background(), models.Operation{WalletID: "id1", Type: "DEPOSIT", Amount: 1}, make(chan error, 1))
+
+		blocked := make(chan error, 1)
+		go func() {
+			blocked <- q.Add(context.Background(), models.Operation{WalletID: "id1", ToWalletID: to[0], Type: "TRANSFER", Amount: 1}, make(chan error, 1))
+		}()
+		// Пока первый перевод ждёт места, перевод между свободными
+		// партициями ставится сразу
+		time.Sleep(20 * time.Millisecond)
+		start := time.Now()
+		if err := q.Add(context.Background(), models.Operation{WalletID: free[0], ToWalletID: free[1], Type: "TRANSFER", Amount: 1}, make(chan error, 1)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if waited := time.Since(start); waited > 100*time.Millisecond {
+			t.Errorf("transfer between free partitions waited %s", waited)
+		}
+		if err := <-blocked; !errors.Is(err, models.ErrQueueFull) {
+			t.Errorf("want ErrQueueFull, got %v", err)
+		}
+		if depth := len(q.partition(to[0])); depth != 0 {
+			t.Errorf("failed transfer left %d markers", depth)
+		}
+	})
+
+	t.Run("depth counts every partition", func(t *testing.T) {
+		q := NewQueue(&stubWalletRepo{}, 50, time.Second)
+		q.SetWorkers(4)
+		for i := range 8 {
+			_ = q.Add(context.Background(), models.Operation{WalletID: fmt.Sprintf("id%d", i), Type: "DEPOSIT", Amount: 1}, make(chan error, 1))
+		}
+		if q.Depth() != 8 {
+			t.Errorf("want depth 8, got %d", q.Depth())
+		}
+	})
+}
+
+func TestQueue_ApplyGroups(t *testing.T) {
+	ops := []models.Operation{
+		{WalletID: "id1", Type: "DEPOSIT", Amount: 10},
+		{WalletID: "id2", Type: "WITHDRAW", Amount: 60},
+		{WalletID: "id3", Type: "WITHDRAW", Amount: 70},
+	}
+	t.Run("groups not applied are retried one by one", func(t *testing.T) {
+		repo := &stubWalletRepo{available: 100, groups: true}
+		errs := applyAll(repo, ops)
+		if repo.groupsCalls != 1 {
+			t.Fatalf("want one ApplyGroups call, got %d", repo.groupsCalls)
+		}
+		// Из двух списаний помещается только одно, второе повторяется
+		// через Withdraw и получает свою ошибку
+		failed := 0
+		for i, err := range errs {
+			switch {
+			case err == nil:
+			case errors.Is(err, models.ErrInsufficientBalance) && ops[i].Type == "WITHDRAW":
+				failed++
+			default:
+				t.Errorf("op %d: unexpected result: %v", i, err)
+			}
+		}
+		if failed != 1 || len(repo.withdrawCalls) != 1 || repo.deposits != 1 {
+			t.Errorf("want one retried withdrawal, got %d failed, %d retried, %d deposits", failed, len(repo.withdrawCalls), repo.deposits)
+		}
+	})
+
+	t.Run("error falls back for every group", func(t *testing.T) {
+		repo := &stubWalletRepo{available: 200, groupsErr: errors.New("db down")}
+		errs := applyAll(repo, ops)
+		for i, err := range errs {
+			if err != nil {
+				t.Errorf("op %d: unexpected result: %v", i, err)
+			}
+		}
+		if len(repo.withdrawCalls) != 2 || repo.deposits != 1 {
+			t.Errorf("want every group retried, got %d withdrawals and %d deposits", len(repo.withdrawCalls), repo.deposits)
+		}
+	})
+}
+
+func TestQueue_WalletOrder(t *testing.T) {
+	t.Run("deposit then withdraw always succeeds", func(t *testing.T) {
+		ops := []models.Operation{
+			{WalletID: "id1", Type: "DEPOSIT", Amount: 100},
+			{WalletID: "id1", Type: "WITHDRAW", Amount: 100},
+		}
+		// Порядок не должен зависеть от обхода map, поэтому проверка
+		// повторяется, и в одном запросе, и по группам
+		for range 20 {
+			for _, groups := range []bool{false, true} {
+				errs := applyAll(&stubWalletRepo{groups: groups}, ops)
+				if errs[0] != nil || errs[1] != nil {
+					t.Fatalf("groups=%v: unexpected results: %v", groups, errs)
+				}
+			}
+		}
+	})
+
+	t.Run("consecutive ops of one type share a group", func(t *testing.T) {
+		repo := &stubWalletRepo{}
+		applyAll(repo, []models.Operation{
+			{WalletID: "id1", Type: "DEPOSIT", Amount: 50},
+			{WalletID: "id2", Type: "DEPOSIT", Amount: 5},
+			{WalletID: "id1", Type: "DEPOSIT", Amount: 50},
+			{WalletID: "id1", Type: "WITHDRAW", Amount: 100},
+			{WalletID: "id1", Type: "DEPOSIT", Amount: 10},
+		})
+		var got []string
+		for _, g := range repo.gotGroups {
+			got = append(got, fmt.Sprintf("%s %s x%d", g.WalletID, g.Type, len(g.Ops)))
+		}
+		want := []string{"id1 DEPOSIT x2", "id2 DEPOSIT x1", "id1 WITHDRAW x1", "id1 DEPOSIT x1"}
+		if strings.Join(got, ", ") != strings.Join(want, ", ") {
+			t.Errorf("got groups %v, want %v", got, want)
+		}
+	})
+
+	t.Run("transfer splits the wallet's ops", func(t *testing.T) {
+		// В порядке поступления перевод проходит, а второе списание нет;
+		// если бы списания объединились до перевода, было бы наоборот
+		for _, groups := range []bool{false, true} {
+			repo := &stubWalletRepo{available: 100, groups: groups}
+			errs := applyAll(repo, []models.Operation{
+				{WalletID: "id1", Type: "WITHDRAW", Amount: 60},
+				{WalletID: "id1", ToWalletID: "id2", Type: "TRANSFER", Amount: 30},
+				{WalletID: "id1", Type: "WITHDRAW", Amount: 20},
+			})
+			if errs[0] != nil || errs[1] != nil || !errors.Is(errs[2], models.ErrInsufficientBalance) {
+				t.Fatalf("groups=%v: got %v, want nil, nil, insufficient balance", groups, errs)
+			}
+			if repo.available != 10 {
+				t.Errorf("groups=%v: want available 10, got %d", groups, repo.available)
+			}
+		}
+	})
+
+	t.Run("other wallets are not split by a transfer", func(t *testing.T) {
+		repo := &stubWalletRepo{}
+		applyAll(repo, []models.Operation{
+			{WalletID: "id3", Type: "DEPOSIT", Amount: 1},
+			{WalletID: "id1", ToWalletID: "id2", Type: "TRANSFER", Amount: 1},
+			{WalletID: "id3", Type: "DEPOSIT", Amount: 1},
+		})
+		if repo.groupsCalls != 1 || len(repo.gotGroups) != 1 || len(repo.gotGroups[0].Ops) != 2 {
+			t.Errorf("want one group of 2 deposits, got %d calls, last %+v", repo.groupsCalls, repo.gotGroups)
+		}
+	})
+}
@@ -0,0 +1,199 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"test-psql/internal/models"
+	"test-psql/pkg/logger"
+)
+
+// GetExchangeRates returns the whole rates table ordered by currency pair.
+func (r *WalletRepo) GetExchangeRates(ctx context.Context) ([]models.ExchangeRate, error) {
+	logger.Info("repo GetExchangeRates")
+	rates := make([]models.ExchangeRate, 0)
+	err := r.db.WithContext(ctx).Order("from_currency, to_currency").Find(&rates).Error
+	if err != nil {
+		logger.Error(fmt.Sprintf("repo GetExchangeRates db error: %v", err))
+		return nil, err
+	}
+	return rates, nil
+}
+
+// GetExchangeRate returns the rate converting from into to, or
+// ErrRateNotFound.
+func (r *WalletRepo) GetExchangeRate(ctx context.Context, from, to string) (models.ExchangeRate, error) {
+	logger.Info(fmt.Sprintf("repo GetExchangeRate from=%s to=%s", from, to))
+	var rate models.ExchangeRate
+	err := r.db.WithContext(ctx).
+		Where("from_currency = ? AND to_currency = ?", from, to).
+		First(&rate).Error
+	if err == gorm.ErrRecordNotFound {
+		return models.ExchangeRate{}, fmt.Errorf("%w: %s/%s", models.ErrRateNotFound, from, to)
+	}
+	if err != nil {
+		logger.Error(fmt.Sprintf("repo GetExchangeRate db error: %v", err))
+		return models.ExchangeRate{}, err
+	}
+	return rate, nil
+}
+
+// SetExchangeRates inserts or replaces the given rates in one transaction;
+// pairs not listed keep their rates. Quotes already issued keep the rate they
+// were issued at.
+func (r *WalletRepo) SetExchangeRates(ctx context.Context, rates []models.ExchangeRate) ([]models.ExchangeRate, error) {
+	logger.Info(fmt.Sprintf("repo SetExchangeRates count=%d", len(rates)))
+	if len(rates) == 0 {
+		return []models.ExchangeRate{}, nil
+	}
+	now := time.Now().UTC()
+	for i := range rates {
+		rates[i].UpdatedAt = now
+	}
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "from_currency"}, {Name: "to_currency"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "updated_at"}),
+	}).Create(&rates).Error
+	if err != nil {
+		logger.Error(fmt.Sprintf("repo SetExchangeRates db error: %v", err))
+		return nil, err
+	}
+	return rates, nil
+}
+
+// CreateQuote stores a quote issued by the service.
+func (r *WalletRepo) CreateQuote(ctx context.Context, quote models.FXQuote) (models.FXQuote, error) {
+	logger.Info(fmt.Sprintf("repo CreateQuote id=%s from=%s to=%s amount=%d", quote.ID, quote.FromWalletID, quote.ToWalletID, quote.Amount))
+	if quote.ID == uuid.Nil {
+		quote.ID = uuid.New()
+	}
+	if err := r.db.WithContext(ctx).Create(&quote).Error; err != nil {
+		logger.Error(fmt.Sprintf("repo CreateQuote db error: %v", err))
+		return models.FXQuote{}, err
+	}
+	return quote, nil
+}
+
+// ExecuteQuote debits the quoted amount from the source wallet and credits
+// the converted amount to the target wallet in one transaction. It writes a
+// CONVERSION_OUT and a CONVERSION_IN ledger row carrying the quote rate and an
+// entry that passes both currencies through SystemAccountFX. The quote ID
+// doubles as the transfer ID of both rows.
+func (r *WalletRepo) ExecuteQuote(ctx context.Context, quoteID string) (models.FXQuote, error) {
+	logger.Info(fmt.Sprintf("repo ExecuteQuote id=%s", quoteID))
+	id, err := uuid.Parse(quoteID)
+	if err != nil {
+		return models.FXQuote{}, models.ErrQuoteNotFound
+	}
+
+	var quote models.FXQuote
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&quote).Error
+		if err == gorm.ErrRecordNotFound {
+			return models.ErrQuoteNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if quote.ExecutedAt != nil {
+			return models.ErrQuoteExecuted
+		}
+		now := time.Now().UTC()
+		if !quote.ExpiresAt.After(now) {
+			return models.ErrQuoteExpired
+		}
+
+		// Порядок блокировок тот же, что в Transfer
+		var wallets []models.Wallet
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "balance", "held", "credit_limit", "status", "currency").
+			Where("id IN ?", []uuid.UUID{quote.FromWalletID, quote.ToWalletID}).
+			Order("id").
+			Find(&wallets).Error
+		if err != nil {
+			return err
+		}
+		if len(wallets) != 2 {
+			return models.ErrWalletNotFound
+		}
+		balances := make(map[uuid.UUID]int64, len(wallets))
+		for _, w := range wallets {
+			if err := w.Status.Err(); err != nil {
+				return err
+			}
+			currency := quote.ToCurrency
+			if w.ID == quote.FromWalletID {
+				currency = quote.FromCurrency
+				if w.Balance-w.Held+w.CreditLimit < quote.Amount {
+					return models.ErrInsufficientBalance
+				}
+			}
+			if w.Currency != currency {
+				return models.ErrCurrencyMismatch
+			}
+			balances[w.ID] = w.Balance
+		}
+		// Лимиты проверяются под блокировкой кошельков, поэтому встречные
+		// конвертации и переводы видят друг друга
+		if err := checkWithdrawalLimits(tx, quote.FromWalletID, 1, quote.Amount); err != nil {
+			return err
+		}
+		if err := checkMaxBalance(tx, quote.ToWalletID, balances[quote.ToWalletID]+quote.ConvertedAmount); err != nil {
+			return err
+		}
+
+		err = tx.Model(&models.Wallet{}).Where("id = ?", quote.FromWalletID).
+			Update("balance", gorm.Expr("balance - ?", quote.Amount)).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&models.Wallet{}).Where("id = ?", quote.ToWalletID).
+			Update("balance", gorm.Expr("balance + ?", quote.ConvertedAmount)).Error
+		if err != nil {
+			return err
+		}
+
+		rate := quote.Rate
+		txs := []models.Transaction{
+			{
+				ID:                   uuid.New(),
+				WalletID:             quote.FromWalletID,
+				Type:                 "CONVERSION_OUT",
+				Amount:               quote.Amount,
+				BalanceAfter:         balances[quote.FromWalletID] - quote.Amount,
+				TransferID:           &quote.ID,
+				CounterpartyWalletID: &quote.ToWalletID,
+				FXRate:               &rate,
+			},
+			{
+				ID:                   uuid.New(),
+				WalletID:             quote.ToWalletID,
+				Type:                 "CONVERSION_IN",
+				Amount:               quote.ConvertedAmount,
+				BalanceAfter:         balances[quote.ToWalletID] + quote.ConvertedAmount,
+				TransferID:           &quote.ID,
+				CounterpartyWalletID: &quote.FromWalletID,
+				FXRate:               &rate,
+			},
+		}
+		if err := tx.Create(&txs).Error; err != nil {
+			return err
+		}
+		if err := writePostings(tx, conversionPostings(quote)); err != nil {
+			return err
+		}
+
+		quote.ExecutedAt = &now
+		return tx.Model(&quote).Update("executed_at", now).Error
+	})
+	if err != nil {
+		logger.Error(fmt.Sprintf("repo ExecuteQuote id=%s error: %v", quoteID, err))
+		return models.FXQuote{}, err
+	}
+	return quote, nil
+}
@@ -0,0 +1,82 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"test-psql/internal/models"
+)
+
+func TestWalletRepo_ExecuteQuote_Limits(t *testing.T) {
+	r := testRepo(t)
+	ctx := context.Background()
+
+	// newQuote создаёт кошельки RUB и USD с балансом 10000 у отправителя и
+	// котировку на 5000 RUB -> 50 USD
+	newQuote := func(t *testing.T) models.FXQuote {
+		t.Helper()
+		from, err := r.CreateWallet(ctx, uuid.New(), "RUB", models.WalletMetadata{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		to, err := r.CreateWallet(ctx, uuid.New(), "USD", models.WalletMetadata{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := r.db.Exec("UPDATE wallets SET balance = 10000 WHERE id = ?", from.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		quote, err := r.CreateQuote(ctx, models.FXQuote{
+			FromWalletID:    from.ID,
+			ToWalletID:      to.ID,
+			FromCurrency:    "RUB",
+			ToCurrency:      "USD",
+			Amount:          5000,
+			ConvertedAmount: 50,
+			Rate:            models.Rate("0.01"),
+			ExpiresAt:       time.Now().UTC().Add(time.Minute),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return quote
+	}
+	limit := func(v int64) *int64 { return &v }
+
+	t.Run("withdrawal limit of the source", func(t *testing.T) {
+		quote := newQuote(t)
+		_, err := r.SetWalletLimits(ctx, models.WalletLimits{WalletID: quote.FromWalletID, MaxWithdrawalAmount24h: limit(4000)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.ExecuteQuote(ctx, quote.ID.String()); !errors.Is(err, models.ErrLimitExceeded) {
+			t.Fatalf("want ErrLimitExceeded, got %v", err)
+		}
+	})
+
+	t.Run("max balance of the recipient", func(t *testing.T) {
+		quote := newQuote(t)
+		_, err := r.SetWalletLimits(ctx, models.WalletLimits{WalletID: quote.ToWalletID, MaxBalance: limit(40)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.ExecuteQuote(ctx, quote.ID.String()); !errors.Is(err, models.ErrLimitExceeded) {
+			t.Fatalf("want ErrLimitExceeded, got %v", err)
+		}
+	})
+
+	t.Run("within limits", func(t *testing.T) {
+		quote := newQuote(t)
+		_, err := r.SetWalletLimits(ctx, models.WalletLimits{WalletID: quote.FromWalletID, MaxWithdrawalAmount24h: limit(5000)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.ExecuteQuote(ctx, quote.ID.String()); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
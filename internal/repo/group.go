@@ -0,0 +1,177 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"test-psql/internal/models"
+	"test-psql/pkg/logger"
+)
+
+// groupRow is one wallet updated by ApplyGroups: its balance after the batch,
+// the change the batch made and the indexes of the groups applied to it.
+type groupRow struct {
+	ID       uuid.UUID
+	Balance  int64
+	Currency string
+	Delta    int64
+	Groups   string
+}
+
+// ApplyGroups applies DEPOSIT and WITHDRAW groups in one transaction with a
+// single UPDATE of all their wallets, where Deposit and Withdraw take several
+// round trips per group. The groups of one wallet are applied in their order
+// in groups and all together or not at all: only if the wallet is active, in
+// the currency of every group and its available balance stays covered after
+// each of them, so a deposit funds the withdrawals after it. The groups of
+// other wallets are left untouched and reported as not applied, and so are
+// invalid ones. Results follow the order of groups. Any error fails the whole
+// call and applies nothing.
+func (r *WalletRepo) ApplyGroups(ctx context.Context, groups []models.OpGroup) ([]models.GroupResult, error) {
+	logger.Info(fmt.Sprintf("repo ApplyGroups groups=%d", len(groups)))
+	results := make([]models.GroupResult, len(groups))
+	ids := make([]uuid.UUID, len(groups))
+	var ops []models.Operation
+	for i, g := range groups {
+		id, _, err := prepareOps(g.WalletID, g.Ops)
+		if err != nil || (g.Type != "DEPOSIT" && g.Type != "WITHDRAW") {
+			continue
+		}
+		ids[i] = id
+		ops = append(ops, g.Ops...)
+	}
+	if len(ops) == 0 {
+		return results, nil
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		claimed, err := claimIdempotencyKeys(tx, ops)
+		if err != nil {
+			return err
+		}
+		isPending := make(map[string]bool, len(claimed))
+		for _, op := range claimed {
+			isPending[op.IdempotencyKey] = true
+		}
+
+		pending := make([][]models.Operation, len(groups))
+		var values []string
+		var args []any
+		for i, g := range groups {
+			if ids[i] == uuid.Nil {
+				continue
+			}
+			for _, op := range g.Ops {
+				if op.IdempotencyKey == "" || isPending[op.IdempotencyKey] {
+					pending[i] = append(pending[i], op)
+				}
+			}
+			if len(pending[i]) == 0 {
+				results[i].Applied = true
+				continue
+			}
+			amount := sumOps(pending[i])
+			if g.Type == "WITHDRAW" {
+				for _, op := range pending[i] {
+					amount += models.TotalFees(op.Fees)
+				}
+				amount = -amount
+			}
+			values = append(values, "(?::int, ?::uuid, ?::text, ?::bigint)")
+			args = append(args, i, ids[i], g.Currency, amount)
+		}
+		if len(values) == 0 {
+			return nil
+		}
+
+		// Кошельки блокируются в порядке id, как в ApplyBatch. low — самая
+		// низкая точка баланса кошелька при применении его групп по порядку:
+		// если в ней не хватает средств, кошелёк не меняется совсем
+		var rows []groupRow
+		args = append(args, models.WalletStatusActive)
+		err = tx.Raw(`
+			WITH v (gid, id, currency, amount) AS (
+				VALUES `+strings.Join(values, ", ")+`
+			), locked AS (
+				SELECT id, currency FROM wallets
+				WHERE id IN (SELECT id FROM v)
+				ORDER BY id
+				FOR UPDATE
+			), s AS (
+				SELECT v.*, SUM(v.amount) OVER (PARTITION BY v.id ORDER BY v.gid) AS running FROM v
+			), d AS (
+				SELECT s.id, SUM(s.amount)::bigint AS delta, LEAST(MIN(s.running), 0)::bigint AS low,
+					string_agg(s.gid::text, ',' ORDER BY s.gid) AS groups
+				FROM s JOIN locked w ON w.id = s.id
+				GROUP BY s.id
+				HAVING bool_and(s.currency = '' OR s.currency = w.currency)
+			)
+			UPDATE wallets w SET balance = w.balance + d.delta, updated_at = (NOW() AT TIME ZONE 'UTC')
+			FROM d
+			WHERE w.id = d.id AND w.status = ? AND w.balance - w.held + w.credit_limit + d.low >= 0
+			RETURNING w.id, w.balance, w.currency, d.delta, d.groups`, args...).
+			Scan(&rows).Error
+		if err != nil {
+			return err
+		}
+
+		var txs []models.Transaction
+		var postings []models.Posting
+		for _, row := range rows {
+			balance := row.Balance - row.Delta
+			for _, s := range strings.Split(row.Groups, ",") {
+				i, err := strconv.Atoi(s)
+				if err != nil {
+					return err
+				}
+				if groups[i].Type == "DEPOSIT" {
+					groupTxs := newTransactions(row.ID, "DEPOSIT", pending[i], balance, 1)
+					postings = append(postings, externalPostings(groupTxs, row.Currency, 1)...)
+					txs = append(txs, groupTxs...)
+				} else {
+					groupTxs := newTransactions(row.ID, "WITHDRAW", pending[i], balance, -1)
+					postings = append(postings, withdrawPostings(groupTxs, row.Currency)...)
+					txs = append(txs, groupTxs...)
+				}
+				balance = txs[len(txs)-1].BalanceAfter
+				results[i] = models.GroupResult{Applied: true, Balance: row.Balance}
+			}
+		}
+		if len(txs) > 0 {
+			if err := tx.Create(&txs).Error; err != nil {
+				return err
+			}
+			if err := writePostings(tx, postings); err != nil {
+				return err
+			}
+		}
+
+		// Ключи отклонённых групп освобождаются, чтобы повтор группы
+		// по отдельности не принял её операции за уже выполненные
+		var released []string
+		for i := range groups {
+			if results[i].Applied {
+				continue
+			}
+			for _, op := range pending[i] {
+				if op.IdempotencyKey != "" {
+					released = append(released, op.IdempotencyKey)
+				}
+			}
+		}
+		if len(released) > 0 {
+			return tx.Exec("DELETE FROM idempotency_keys WHERE key IN ?", released).Error
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error(fmt.Sprintf("repo ApplyGroups db error: %v", err))
+		return nil, err
+	}
+	return results, nil
+}
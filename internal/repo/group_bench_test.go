@@ -0,0 +1,87 @@
+package repo
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"test-psql/internal/migrations"
+	"test-psql/internal/models"
+)
+
+// benchWallets is the number of wallets touched by one queue flush.
+const benchWallets = 50
+
+// benchRepo connects to the database in BENCH_DATABASE_DSN, migrates it and
+// creates benchWallets wallets. Benchmarks are skipped without it.
+func benchRepo(b *testing.B) (*WalletRepo, []string) {
+	dsn := os.Getenv("BENCH_DATABASE_DSN")
+	if dsn == "" {
+		b.Skip("BENCH_DATABASE_DSN is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err := migrations.Run(db, "../../migrations"); err != nil {
+		b.Fatal(err)
+	}
+	r := NewWalletRepo(db)
+	ids := make([]string, benchWallets)
+	for i := range ids {
+		w, err := r.CreateWallet(context.Background(), uuid.New(), "RUB", models.WalletMetadata{})
+		if err != nil {
+			b.Fatal(err)
+		}
+		ids[i] = w.ID.String()
+	}
+	return r, ids
+}
+
+// benchGroups builds one flush: a group of two deposits for every wallet.
+func benchGroups(ids []string) []models.OpGroup {
+	groups := make([]models.OpGroup, len(ids))
+	for i, id := range ids {
+		groups[i] = models.OpGroup{WalletID: id, Type: "DEPOSIT", Currency: "RUB", Ops: []models.Operation{
+			{ID: uuid.New(), Type: "DEPOSIT", WalletID: id, Amount: 100, Currency: "RUB"},
+			{ID: uuid.New(), Type: "DEPOSIT", WalletID: id, Amount: 200, Currency: "RUB"},
+		}}
+	}
+	return groups
+}
+
+// BenchmarkApplyGroups compares one flush applied group by group, as the
+// queue did before ApplyGroups, with the same flush in a single statement.
+func BenchmarkApplyGroups(b *testing.B) {
+	r, ids := benchRepo(b)
+	ctx := context.Background()
+
+	b.Run("per-group", func(b *testing.B) {
+		for b.Loop() {
+			for _, g := range benchGroups(ids) {
+				if _, err := r.Deposit(ctx, g.WalletID, g.Ops); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+
+	b.Run("single-statement", func(b *testing.B) {
+		for b.Loop() {
+			results, err := r.ApplyGroups(ctx, benchGroups(ids))
+			if err != nil {
+				b.Fatal(err)
+			}
+			for _, res := range results {
+				if !res.Applied {
+					b.Fatal("group not applied")
+				}
+			}
+		}
+	})
+}
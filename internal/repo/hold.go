@@ -0,0 +1,206 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"test-psql/internal/models"
+	"test-psql/pkg/logger"
+)
+
+// CreateHold reserves amount on an active wallet if its available balance
+// covers it.
+func (r *WalletRepo) CreateHold(ctx context.Context, walletID string, amount int64, currency string, expiresAt time.Time) (models.Hold, error) {
+	logger.Info(fmt.Sprintf("repo CreateHold walletId=%s amount=%d", walletID, amount))
+	id, err := uuid.Parse(walletID)
+	if err != nil {
+		return models.Hold{}, models.ErrWalletNotFound
+	}
+	if amount <= 0 {
+		return models.Hold{}, fmt.Errorf("amount must be positive")
+	}
+	hold := models.Hold{
+		ID:        uuid.New(),
+		WalletID:  id,
+		Amount:    amount,
+		Status:    models.HoldStatusActive,
+		ExpiresAt: expiresAt,
+	}
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := activeWallet(tx.Model(&models.Wallet{}), id, currency).
+			Where("balance - held + credit_limit >= ?", amount).
+			Update("held", gorm.Expr("held + ?", amount))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			if err := walletOpErr(tx, id, currency); err != nil {
+				return err
+			}
+			return models.ErrInsufficientBalance
+		}
+		return tx.Create(&hold).Error
+	})
+	if err != nil {
+		return models.Hold{}, err
+	}
+	return hold, nil
+}
+
+// CaptureHold debits amount (the whole hold if amount is 0) from the wallet,
+// releases the rest of the hold and writes a HOLD_CAPTURE ledger row with a
+// balanced entry paying the amount out to external cash.
+func (r *WalletRepo) CaptureHold(ctx context.Context, walletID, holdID string, amount int64) (models.Hold, error) {
+	logger.Info(fmt.Sprintf("repo CaptureHold walletId=%s holdId=%s amount=%d", walletID, holdID, amount))
+	var hold models.Hold
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockActiveHold(tx, walletID, holdID, &hold); err != nil {
+			return err
+		}
+		if amount == 0 {
+			amount = hold.Amount
+		}
+		if amount < 0 {
+			return fmt.Errorf("amount must be positive")
+		}
+		if amount > hold.Amount {
+			return models.ErrCaptureExceedsHold
+		}
+
+		var w models.Wallet
+		result := activeWallet(tx.Model(&w), hold.WalletID, "").
+			Clauses(clause.Returning{Columns: []clause.Column{{Name: "balance"}, {Name: "currency"}}}).
+			Updates(map[string]any{
+				"balance": gorm.Expr("balance - ?", amount),
+				"held":    gorm.Expr("held - ?", hold.Amount),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			if err := walletOpErr(tx, hold.WalletID, ""); err != nil {
+				return err
+			}
+			return fmt.Errorf("capture matched no rows")
+		}
+
+		err := tx.Model(&hold).Updates(map[string]any{
+			"status":          models.HoldStatusCaptured,
+			"captured_amount": amount,
+		}).Error
+		if err != nil {
+			return err
+		}
+		hold.Status, hold.CapturedAmount = models.HoldStatusCaptured, amount
+
+		capture := models.Transaction{
+			ID:           uuid.New(),
+			WalletID:     hold.WalletID,
+			Type:         "HOLD_CAPTURE",
+			Amount:       amount,
+			BalanceAfter: w.Balance,
+			HoldID:       &hold.ID,
+		}
+		if err := tx.Create(&capture).Error; err != nil {
+			return err
+		}
+		return writePostings(tx, externalPostings([]models.Transaction{capture}, w.Currency, -1))
+	})
+	if err != nil {
+		return models.Hold{}, err
+	}
+	return hold, nil
+}
+
+// VoidHold releases an active hold without moving money.
+func (r *WalletRepo) VoidHold(ctx context.Context, walletID, holdID string) (models.Hold, error) {
+	logger.Info(fmt.Sprintf("repo VoidHold walletId=%s holdId=%s", walletID, holdID))
+	var hold models.Hold
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockActiveHold(tx, walletID, holdID, &hold); err != nil {
+			return err
+		}
+		err := tx.Model(&models.Wallet{}).Where("id = ?", hold.WalletID).
+			Update("held", gorm.Expr("held - ?", hold.Amount)).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&hold).Update("status", models.HoldStatusVoided).Error; err != nil {
+			return err
+		}
+		hold.Status = models.HoldStatusVoided
+		return nil
+	})
+	if err != nil {
+		return models.Hold{}, err
+	}
+	return hold, nil
+}
+
+// ExpireHolds marks up to limit active holds past their expiry as EXPIRED and
+// releases their amounts in one statement. It returns how many holds expired.
+func (r *WalletRepo) ExpireHolds(ctx context.Context, limit int) (int, error) {
+	var expired int
+	err := r.db.WithContext(ctx).Raw(`
+		WITH expired AS (
+			UPDATE wallet_holds SET status = ?, updated_at = (NOW() AT TIME ZONE 'UTC')
+			WHERE id IN (
+				SELECT id FROM wallet_holds
+				WHERE status = ? AND expires_at <= (NOW() AT TIME ZONE 'UTC')
+				ORDER BY expires_at
+				LIMIT ?
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING wallet_id, amount
+		), released AS (
+			UPDATE wallets w SET held = w.held - e.amount, updated_at = (NOW() AT TIME ZONE 'UTC')
+			FROM (SELECT wallet_id, SUM(amount) AS amount FROM expired GROUP BY wallet_id) e
+			WHERE w.id = e.wallet_id
+			RETURNING w.id
+		)
+		SELECT COUNT(*) FROM expired`,
+		models.HoldStatusExpired, models.HoldStatusActive, limit).Scan(&expired).Error
+	if err != nil {
+		logger.Error(fmt.Sprintf("repo ExpireHolds db error: %v", err))
+		return 0, err
+	}
+	return expired, nil
+}
+
+func findHold(tx *gorm.DB, walletID, holdID string, hold *models.Hold) error {
+	wid, err := uuid.Parse(walletID)
+	if err != nil {
+		return models.ErrHoldNotFound
+	}
+	hid, err := uuid.Parse(holdID)
+	if err != nil {
+		return models.ErrHoldNotFound
+	}
+	err = tx.Where("id = ? AND wallet_id = ?", hid, wid).First(hold).Error
+	if err == gorm.ErrRecordNotFound {
+		return models.ErrHoldNotFound
+	}
+	return err
+}
+
+// lockActiveHold loads the hold FOR UPDATE and checks that it can still be
+// captured or voided. Expired holds not yet swept by ExpireHolds are rejected
+// too; the sweeper releases them.
+func lockActiveHold(tx *gorm.DB, walletID, holdID string, hold *models.Hold) error {
+	if err := findHold(tx.Clauses(clause.Locking{Strength: "UPDATE"}), walletID, holdID, hold); err != nil {
+		return err
+	}
+	if hold.Status == models.HoldStatusExpired ||
+		(hold.Status == models.HoldStatusActive && !hold.ExpiresAt.After(time.Now())) {
+		return models.ErrHoldExpired
+	}
+	if hold.Status != models.HoldStatusActive {
+		return models.ErrHoldNotActive
+	}
+	return nil
+}
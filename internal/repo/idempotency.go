@@ -0,0 +1,84 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"test-psql/internal/models"
+	"test-psql/pkg/logger"
+)
+
+func (r *WalletRepo) GetIdempotencyKey(ctx context.Context, key string) (*models.IdempotencyKey, error) {
+	logger.Info(fmt.Sprintf("repo GetIdempotencyKey key=%s", key))
+	var k models.IdempotencyKey
+	err := r.db.WithContext(ctx).Where("key = ?", key).First(&k).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		logger.Error(fmt.Sprintf("repo GetIdempotencyKey db error: %v", err))
+		return nil, err
+	}
+	return &k, nil
+}
+
+// SaveIdempotentResponse records the response for key unless one is already
+// stored, and returns whatever is stored afterwards: the first response wins.
+func (r *WalletRepo) SaveIdempotentResponse(ctx context.Context, key, requestHash string, statusCode int, body []byte) (*models.IdempotencyKey, error) {
+	logger.Info(fmt.Sprintf("repo SaveIdempotentResponse key=%s status=%d", key, statusCode))
+	err := r.db.WithContext(ctx).Exec(`
+		INSERT INTO idempotency_keys (key, request_hash, status_code, response_body)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (key) DO UPDATE
+		SET status_code = EXCLUDED.status_code, response_body = EXCLUDED.response_body, updated_at = (NOW() AT TIME ZONE 'UTC')
+		WHERE idempotency_keys.status_code = 0 AND idempotency_keys.request_hash = EXCLUDED.request_hash`,
+		key, requestHash, statusCode, body).Error
+	if err != nil {
+		logger.Error(fmt.Sprintf("repo SaveIdempotentResponse db error: %v", err))
+		return nil, err
+	}
+	return r.GetIdempotencyKey(ctx, key)
+}
+
+// claimIdempotencyKeys stores the keys of ops inside tx and returns the ops
+// that still have to be applied. An op whose key is already stored is a retry
+// of a request committed earlier and is dropped.
+func claimIdempotencyKeys(tx *gorm.DB, ops []models.Operation) ([]models.Operation, error) {
+	var values []string
+	var args []any
+	for _, op := range ops {
+		if op.IdempotencyKey == "" {
+			continue
+		}
+		values = append(values, "(?, ?, ?)")
+		args = append(args, op.IdempotencyKey, op.RequestHash, op.ID)
+	}
+	if len(values) == 0 {
+		return ops, nil
+	}
+
+	var claimed []string
+	err := tx.Raw("INSERT INTO idempotency_keys (key, request_hash, transaction_id) VALUES "+
+		strings.Join(values, ", ")+" ON CONFLICT (key) DO NOTHING RETURNING key", args...).
+		Scan(&claimed).Error
+	if err != nil {
+		return nil, err
+	}
+	isClaimed := make(map[string]bool, len(claimed))
+	for _, key := range claimed {
+		isClaimed[key] = true
+	}
+
+	pending := make([]models.Operation, 0, len(ops))
+	for _, op := range ops {
+		if op.IdempotencyKey == "" || isClaimed[op.IdempotencyKey] {
+			pending = append(pending, op)
+			continue
+		}
+		logger.Info(fmt.Sprintf("repo skip replayed op key=%s", op.IdempotencyKey))
+	}
+	return pending, nil
+}
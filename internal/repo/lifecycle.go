@@ -0,0 +1,152 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"test-psql/internal/models"
+	"test-psql/pkg/logger"
+)
+
+// CreateWallet inserts an empty active wallet with the given ID, currency and
+// metadata.
+func (r *WalletRepo) CreateWallet(ctx context.Context, id uuid.UUID, currency string, meta models.WalletMetadata) (models.Wallet, error) {
+	logger.Info(fmt.Sprintf("repo CreateWallet walletId=%s currency=%s", id, currency))
+	exponent, ok := models.CurrencyExponent(currency)
+	if !ok {
+		return models.Wallet{}, models.ErrUnknownCurrency
+	}
+	w := models.Wallet{ID: id, Status: models.WalletStatusActive, Currency: currency, CurrencyExponent: exponent, WalletMetadata: meta}
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&w)
+	if result.Error != nil {
+		logger.Error(fmt.Sprintf("repo CreateWallet db error: %v", result.Error))
+		return models.Wallet{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return models.Wallet{}, models.ErrWalletExists
+	}
+	return w, nil
+}
+
+func (r *WalletRepo) GetWallet(ctx context.Context, walletID string) (models.Wallet, error) {
+	logger.Info(fmt.Sprintf("repo GetWallet walletId=%s", walletID))
+	id, err := uuid.Parse(walletID)
+	if err != nil {
+		return models.Wallet{}, models.ErrWalletNotFound
+	}
+	var w models.Wallet
+	err = r.db.WithContext(ctx).Where("id = ?", id).First(&w).Error
+	if err == gorm.ErrRecordNotFound {
+		return models.Wallet{}, models.ErrWalletNotFound
+	}
+	if err != nil {
+		logger.Error(fmt.Sprintf("repo GetWallet db error: %v", err))
+		return models.Wallet{}, err
+	}
+	return w, nil
+}
+
+// SetStatus moves the wallet to status if the transition is allowed.
+// A wallet can only be closed with a zero balance, no held funds and no
+// active schedule paying from or into it.
+func (r *WalletRepo) SetStatus(ctx context.Context, walletID string, status models.WalletStatus) (models.Wallet, error) {
+	logger.Info(fmt.Sprintf("repo SetStatus walletId=%s status=%s", walletID, status))
+	id, err := uuid.Parse(walletID)
+	if err != nil {
+		return models.Wallet{}, models.ErrWalletNotFound
+	}
+	var w models.Wallet
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&w).Error
+		if err == gorm.ErrRecordNotFound {
+			return models.ErrWalletNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if !w.Status.CanTransitionTo(status) {
+			return fmt.Errorf("%w: %s -> %s", models.ErrInvalidTransition, w.Status, status)
+		}
+		if status == models.WalletStatusClosed {
+			if err := checkClosable(tx, w); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&w).Update("status", status).Error; err != nil {
+			return err
+		}
+		w.Status = status
+		return nil
+	})
+	if err != nil {
+		return models.Wallet{}, err
+	}
+	return w, nil
+}
+
+// checkClosable reports why the locked wallet w cannot be closed, if it
+// cannot.
+func checkClosable(tx *gorm.DB, w models.Wallet) error {
+	if w.Balance != 0 {
+		return models.ErrWalletNotEmpty
+	}
+	if w.Held > 0 {
+		return models.ErrWalletHasHolds
+	}
+	var schedules int64
+	err := tx.Model(&models.Schedule{}).
+		Where("status = ? AND (wallet_id = ? OR to_wallet_id = ?)", models.ScheduleStatusActive, w.ID, w.ID).
+		Count(&schedules).Error
+	if err != nil {
+		return err
+	}
+	if schedules > 0 {
+		return models.ErrWalletHasSchedules
+	}
+	return nil
+}
+
+// SetCreditLimit changes how far the wallet balance may go below zero. The
+// new limit must still cover the credit in use (a negative balance plus
+// holds), and a closed wallet keeps its limit.
+func (r *WalletRepo) SetCreditLimit(ctx context.Context, walletID string, limit int64) (models.Wallet, error) {
+	logger.Info(fmt.Sprintf("repo SetCreditLimit walletId=%s limit=%d", walletID, limit))
+	id, err := uuid.Parse(walletID)
+	if err != nil {
+		return models.Wallet{}, models.ErrWalletNotFound
+	}
+	if limit < 0 {
+		return models.Wallet{}, fmt.Errorf("credit limit must not be negative")
+	}
+	var w models.Wallet
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&w).Error
+		if err == gorm.ErrRecordNotFound {
+			return models.ErrWalletNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if w.Status == models.WalletStatusClosed {
+			return models.ErrWalletClosed
+		}
+		if w.Balance-w.Held+limit < 0 {
+			return models.ErrCreditLimitInUse
+		}
+		if err := tx.Model(&w).Update("credit_limit", limit).Error; err != nil {
+			return err
+		}
+		w.CreditLimit = limit
+		return nil
+	})
+	if err != nil {
+		return models.Wallet{}, err
+	}
+	return w, nil
+}
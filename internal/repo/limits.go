@@ -0,0 +1,130 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"test-psql/internal/models"
+	"test-psql/pkg/logger"
+)
+
+// GetWalletLimits returns the wallet's limit rules. A wallet without a
+// wallet_limits row has no rules.
+func (r *WalletRepo) GetWalletLimits(ctx context.Context, walletID string) (models.WalletLimits, error) {
+	logger.Info(fmt.Sprintf("repo GetWalletLimits walletId=%s", walletID))
+	id, err := uuid.Parse(walletID)
+	if err != nil {
+		return models.WalletLimits{}, models.ErrWalletNotFound
+	}
+	limits := models.WalletLimits{WalletID: id}
+	err = r.db.WithContext(ctx).Where("wallet_id = ?", id).Limit(1).Find(&limits).Error
+	if err != nil {
+		logger.Error(fmt.Sprintf("repo GetWalletLimits db error: %v", err))
+		return models.WalletLimits{}, err
+	}
+	return limits, nil
+}
+
+// SetWalletLimits replaces all limit rules of an existing wallet.
+func (r *WalletRepo) SetWalletLimits(ctx context.Context, limits models.WalletLimits) (models.WalletLimits, error) {
+	logger.Info(fmt.Sprintf("repo SetWalletLimits walletId=%s", limits.WalletID))
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Лимиты можно менять и у замороженного кошелька
+		err := walletOpErr(tx, limits.WalletID, "")
+		if err != nil && !errors.Is(err, models.ErrWalletFrozen) {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "wallet_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"max_withdrawal_amount_24h", "max_withdrawals_per_hour", "max_balance", "updated_at"}),
+		}).Create(&limits).Error
+	})
+	if err != nil {
+		logger.Error(fmt.Sprintf("repo SetWalletLimits walletId=%s error: %v", limits.WalletID, err))
+		return models.WalletLimits{}, err
+	}
+	return limits, nil
+}
+
+// GetWithdrawalUsage sums the money that left the wallet over the last 24
+// hours and counts those debits over the last hour. Withdrawals, outgoing
+// transfers, conversions and hold captures all count; fees are charged on
+// one of them, and reversals and adjustments are corrections, so these do
+// not. Open holds count as debits made when they were created, so a hold
+// and its capture count once.
+func (r *WalletRepo) GetWithdrawalUsage(ctx context.Context, walletID string) (models.WithdrawalUsage, error) {
+	logger.Info(fmt.Sprintf("repo GetWithdrawalUsage walletId=%s", walletID))
+	id, err := uuid.Parse(walletID)
+	if err != nil {
+		return models.WithdrawalUsage{}, models.ErrWalletNotFound
+	}
+	usage, err := withdrawalUsage(r.db.WithContext(ctx), id)
+	if err != nil {
+		logger.Error(fmt.Sprintf("repo GetWithdrawalUsage db error: %v", err))
+		return models.WithdrawalUsage{}, err
+	}
+	return usage, nil
+}
+
+// withdrawalUsage is the query of GetWithdrawalUsage run on db.
+func withdrawalUsage(db *gorm.DB, id uuid.UUID) (models.WithdrawalUsage, error) {
+	// created_at хранится в UTC, поэтому окна считаются от текущего
+	// момента в UTC
+	var usage models.WithdrawalUsage
+	err := db.Raw(`
+		SELECT COALESCE(SUM(amount), 0) AS amount_24h,
+			COUNT(*) FILTER (WHERE created_at >= (NOW() AT TIME ZONE 'UTC') - INTERVAL '1 hour') AS count_1h
+		FROM (
+			SELECT amount, created_at FROM wallet_transactions
+			WHERE wallet_id = ? AND type IN ('WITHDRAW', 'TRANSFER_OUT', 'CONVERSION_OUT', 'HOLD_CAPTURE')
+				AND created_at >= (NOW() AT TIME ZONE 'UTC') - INTERVAL '24 hours'
+			UNION ALL
+			SELECT amount, created_at FROM wallet_holds
+			WHERE wallet_id = ? AND status = ? AND expires_at > (NOW() AT TIME ZONE 'UTC')
+		) debits`, id, id, models.HoldStatusActive).Scan(&usage).Error
+	return usage, err
+}
+
+// checkWithdrawalLimits rejects count debits of amount in total from the
+// wallet inside tx if, together with its recent debits, they break a
+// velocity rule. The wallet row must be locked by tx, so debits made at the
+// same time are counted once they commit.
+func checkWithdrawalLimits(tx *gorm.DB, walletID uuid.UUID, count, amount int64) error {
+	var limits models.WalletLimits
+	err := tx.Where("wallet_id = ?", walletID).Limit(1).Find(&limits).Error
+	if err != nil || (limits.MaxWithdrawalAmount24h == nil && limits.MaxWithdrawalsPerHour == nil) {
+		return err
+	}
+	usage, err := withdrawalUsage(tx, walletID)
+	if err != nil {
+		return err
+	}
+	if limit := limits.MaxWithdrawalAmount24h; limit != nil && usage.Amount24h+amount > *limit {
+		return models.LimitExceeded(models.LimitRuleMaxWithdrawalAmount24h, *limit)
+	}
+	if limit := limits.MaxWithdrawalsPerHour; limit != nil && usage.Count1h+count > *limit {
+		return models.LimitExceeded(models.LimitRuleMaxWithdrawalsPerHour, *limit)
+	}
+	return nil
+}
+
+// checkMaxBalance rejects a credit that leaves the wallet with balance above
+// its max_balance rule. It runs in the crediting transaction with the wallet
+// row locked, so the balance stays as it is until the credit commits or
+// rolls back.
+func checkMaxBalance(tx *gorm.DB, walletID uuid.UUID, balance int64) error {
+	var limits models.WalletLimits
+	err := tx.Where("wallet_id = ?", walletID).Limit(1).Find(&limits).Error
+	if err != nil || limits.MaxBalance == nil {
+		return err
+	}
+	if balance > *limits.MaxBalance {
+		return models.LimitExceeded(models.LimitRuleMaxBalance, *limits.MaxBalance)
+	}
+	return nil
+}
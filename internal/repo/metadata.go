@@ -0,0 +1,92 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+
+	"test-psql/internal/models"
+	"test-psql/pkg/logger"
+)
+
+// cursorTimeLayout renders created_at as Postgres stores it: without a time
+// zone and with microsecond precision.
+const cursorTimeLayout = "2006-01-02 15:04:05.999999"
+
+// SetWalletMetadata replaces the owner, name and labels of a wallet in any
+// status.
+func (r *WalletRepo) SetWalletMetadata(ctx context.Context, walletID string, meta models.WalletMetadata) (models.Wallet, error) {
+	logger.Info(fmt.Sprintf("repo SetWalletMetadata walletId=%s ownerId=%s", walletID, meta.OwnerID))
+	id, err := uuid.Parse(walletID)
+	if err != nil {
+		return models.Wallet{}, models.ErrWalletNotFound
+	}
+	if meta.Labels == nil {
+		meta.Labels = models.Labels{}
+	}
+	var w models.Wallet
+	result := r.db.WithContext(ctx).Model(&w).
+		Clauses(clause.Returning{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"owner_id": meta.OwnerID,
+			"name":     meta.Name,
+			"labels":   meta.Labels,
+		})
+	if result.Error != nil {
+		logger.Error(fmt.Sprintf("repo SetWalletMetadata db error: %v", result.Error))
+		return models.Wallet{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return models.Wallet{}, models.ErrWalletNotFound
+	}
+	return w, nil
+}
+
+// ListWallets returns up to q.Limit wallets matching q, starting after
+// q.After. Pages are cut by the sort key and the ID (keyset pagination), so
+// a page costs the same however deep it is and wallets created between
+// requests neither repeat nor shift the following pages.
+func (r *WalletRepo) ListWallets(ctx context.Context, q models.WalletListQuery) ([]models.Wallet, error) {
+	logger.Info(fmt.Sprintf("repo ListWallets ownerId=%s labels=%v sort=%s desc=%t", q.OwnerID, q.Labels, q.Sort, q.Desc))
+	column := "created_at"
+	if q.Sort == models.WalletSortBalance {
+		column = "balance"
+	}
+	cmp, dir := ">", "ASC"
+	if q.Desc {
+		cmp, dir = "<", "DESC"
+	}
+
+	tx := r.db.WithContext(ctx).Model(&models.Wallet{})
+	if q.OwnerID != "" {
+		tx = tx.Where("owner_id = ?", q.OwnerID)
+	}
+	if len(q.Labels) > 0 {
+		labels, err := json.Marshal(q.Labels)
+		if err != nil {
+			return nil, err
+		}
+		tx = tx.Where("labels @> CAST(? AS jsonb)", string(labels))
+	}
+	if q.After != nil {
+		if q.Sort == models.WalletSortBalance {
+			tx = tx.Where(fmt.Sprintf("(balance, id) %s (?, ?)", cmp), q.After.Balance, q.After.ID)
+		} else {
+			tx = tx.Where(fmt.Sprintf("(created_at, id) %s (CAST(? AS timestamp), ?)", cmp),
+				q.After.CreatedAt.Format(cursorTimeLayout), q.After.ID)
+		}
+	}
+	wallets := make([]models.Wallet, 0, q.Limit)
+	err := tx.Order(fmt.Sprintf("%s %s, id %s", column, dir, dir)).
+		Limit(q.Limit).
+		Find(&wallets).Error
+	if err != nil {
+		logger.Error(fmt.Sprintf("repo ListWallets db error: %v", err))
+		return nil, err
+	}
+	return wallets, nil
+}
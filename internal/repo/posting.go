@@ -0,0 +1,90 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"test-psql/internal/models"
+	"test-psql/pkg/logger"
+)
+
+// GetSystemAccountBalances sums the postings of every system account per
+// currency. Accounts without postings are not listed.
+func (r *WalletRepo) GetSystemAccountBalances(ctx context.Context) ([]models.SystemAccountBalance, error) {
+	logger.Info("repo GetSystemAccountBalances")
+	balances := make([]models.SystemAccountBalance, 0)
+	err := r.db.WithContext(ctx).Model(&models.Posting{}).
+		Select("system_account AS account", "currency", "SUM(amount) AS balance").
+		Where("system_account IS NOT NULL").
+		Group("system_account, currency").
+		Order("system_account, currency").
+		Scan(&balances).Error
+	if err != nil {
+		logger.Error(fmt.Sprintf("repo GetSystemAccountBalances db error: %v", err))
+		return nil, err
+	}
+	return balances, nil
+}
+
+// writePostings inserts postings after checking that every entry balances;
+// it is the only way postings reach the ledger.
+func writePostings(tx *gorm.DB, postings []models.Posting) error {
+	if err := models.CheckBalanced(postings); err != nil {
+		logger.Error(fmt.Sprintf("repo writePostings refused: %v", err))
+		return err
+	}
+	return tx.Create(&postings).Error
+}
+
+// externalPostings builds one entry per wallet transaction moving its amount
+// between the wallet and SystemAccountExternalCash; sign is +1 for money
+// coming in and -1 for money going out.
+func externalPostings(txs []models.Transaction, currency string, sign int64) []models.Posting {
+	postings := make([]models.Posting, 0, 2*len(txs))
+	for _, t := range txs {
+		postings = append(postings,
+			models.WalletPosting(t.ID, t.WalletID, currency, sign*t.Amount),
+			models.SystemPosting(t.ID, models.SystemAccountExternalCash, currency, -sign*t.Amount),
+		)
+	}
+	return postings
+}
+
+// withdrawPostings is externalPostings for withdrawals, except that FEE rows
+// pay their amount to SystemAccountFees.
+func withdrawPostings(txs []models.Transaction, currency string) []models.Posting {
+	postings := make([]models.Posting, 0, 2*len(txs))
+	for _, t := range txs {
+		if t.Type != "FEE" {
+			postings = append(postings, externalPostings([]models.Transaction{t}, currency, -1)...)
+			continue
+		}
+		postings = append(postings,
+			models.WalletPosting(t.ID, t.WalletID, currency, -t.Amount),
+			models.SystemPosting(t.ID, models.SystemAccountFees, currency, t.Amount),
+		)
+	}
+	return postings
+}
+
+// transferPostings builds the entry of a wallet-to-wallet transfer.
+func transferPostings(transferID, fromID, toID uuid.UUID, currency string, amount int64) []models.Posting {
+	return []models.Posting{
+		models.WalletPosting(transferID, fromID, currency, -amount),
+		models.WalletPosting(transferID, toID, currency, amount),
+	}
+}
+
+// conversionPostings builds the entry of an executed quote. Each currency
+// balances on its own through SystemAccountFX.
+func conversionPostings(quote models.FXQuote) []models.Posting {
+	return []models.Posting{
+		models.WalletPosting(quote.ID, quote.FromWalletID, quote.FromCurrency, -quote.Amount),
+		models.SystemPosting(quote.ID, models.SystemAccountFX, quote.FromCurrency, quote.Amount),
+		models.WalletPosting(quote.ID, quote.ToWalletID, quote.ToCurrency, quote.ConvertedAmount),
+		models.SystemPosting(quote.ID, models.SystemAccountFX, quote.ToCurrency, -quote.ConvertedAmount),
+	}
+}
@@ -0,0 +1,98 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"test-psql/internal/models"
+	"test-psql/pkg/logger"
+)
+
+// FindBalanceMismatches compares every wallet balance with the sum of its
+// postings. It is a single statement, so it sees one consistent snapshot
+// even while operations keep running.
+func (r *WalletRepo) FindBalanceMismatches(ctx context.Context) ([]models.BalanceMismatch, error) {
+	logger.Info("repo FindBalanceMismatches")
+	mismatches := make([]models.BalanceMismatch, 0)
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT w.id AS wallet_id, w.currency, w.balance,
+			COALESCE(SUM(p.amount), 0) AS history_balance
+		FROM wallets w
+		LEFT JOIN ledger_postings p ON p.wallet_id = w.id
+		GROUP BY w.id
+		HAVING w.balance <> COALESCE(SUM(p.amount), 0)
+		ORDER BY w.id`).Scan(&mismatches).Error
+	if err != nil {
+		logger.Error(fmt.Sprintf("repo FindBalanceMismatches db error: %v", err))
+		return nil, err
+	}
+	return mismatches, nil
+}
+
+// FixBalanceMismatch recomputes the wallet's history under a row lock and,
+// if it still disagrees with the balance, writes an adjustment entry against
+// SystemAccountAdjustments that brings the history in line. The balance
+// itself is left as is. The returned mismatch has a zero drift if there was
+// nothing left to fix.
+func (r *WalletRepo) FixBalanceMismatch(ctx context.Context, walletID uuid.UUID) (models.BalanceMismatch, error) {
+	logger.Info(fmt.Sprintf("repo FixBalanceMismatch walletId=%s", walletID))
+	m := models.BalanceMismatch{WalletID: walletID}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var w models.Wallet
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "balance", "currency").
+			Where("id = ?", walletID).
+			First(&w).Error
+		if err == gorm.ErrRecordNotFound {
+			return models.ErrWalletNotFound
+		}
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&models.Posting{}).
+			Select("COALESCE(SUM(amount), 0)").
+			Where("wallet_id = ?", walletID).
+			Scan(&m.HistoryBalance).Error
+		if err != nil {
+			return err
+		}
+		m.Balance, m.Currency = w.Balance, w.Currency
+		drift := m.Drift()
+		if drift == 0 {
+			return nil
+		}
+
+		txType, amount := "ADJUSTMENT_IN", drift
+		if drift < 0 {
+			txType, amount = "ADJUSTMENT_OUT", -drift
+		}
+		adjustment := models.Transaction{
+			ID:           uuid.New(),
+			WalletID:     walletID,
+			Type:         txType,
+			Amount:       amount,
+			BalanceAfter: w.Balance,
+		}
+		if err := tx.Create(&adjustment).Error; err != nil {
+			return err
+		}
+		err = writePostings(tx, []models.Posting{
+			models.WalletPosting(adjustment.ID, walletID, w.Currency, drift),
+			models.SystemPosting(adjustment.ID, models.SystemAccountAdjustments, w.Currency, -drift),
+		})
+		if err != nil {
+			return err
+		}
+		m.Fixed = true
+		return nil
+	})
+	if err != nil {
+		logger.Error(fmt.Sprintf("repo FixBalanceMismatch walletId=%s error: %v", walletID, err))
+		return models.BalanceMismatch{}, err
+	}
+	return m, nil
+}
@@ -0,0 +1,112 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"test-psql/internal/models"
+	"test-psql/pkg/logger"
+)
+
+// ReverseOperation undoes amount (everything not yet reversed if amount is 0)
+// of a DEPOSIT or WITHDRAW. It writes a REVERSAL_OUT or REVERSAL_IN ledger
+// row linked to the original and a balanced entry against external cash that
+// mirrors the original one. Reversing a deposit needs the same available
+// balance as a withdrawal of that amount.
+func (r *WalletRepo) ReverseOperation(ctx context.Context, operationID string, amount int64) (models.Transaction, error) {
+	logger.Info(fmt.Sprintf("repo ReverseOperation operationId=%s amount=%d", operationID, amount))
+	id, err := uuid.Parse(operationID)
+	if err != nil {
+		return models.Transaction{}, models.ErrOperationNotFound
+	}
+	if amount < 0 {
+		return models.Transaction{}, fmt.Errorf("amount must not be negative")
+	}
+	var reversal models.Transaction
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Блокировка исходной строки сериализует встречные отмены
+		// одной операции, поэтому сумма отмен не превысит исходную
+		var orig models.Transaction
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&orig).Error
+		if err == gorm.ErrRecordNotFound {
+			return models.ErrOperationNotFound
+		}
+		if err != nil {
+			return err
+		}
+		txType, sign := "", int64(0)
+		switch orig.Type {
+		case "DEPOSIT":
+			txType, sign = "REVERSAL_OUT", -1
+		case "WITHDRAW":
+			txType, sign = "REVERSAL_IN", 1
+		default:
+			return models.ErrNotReversible
+		}
+
+		var reversed int64
+		err = tx.Model(&models.Transaction{}).
+			Select("COALESCE(SUM(amount), 0)").
+			Where("reversal_of = ?", id).
+			Scan(&reversed).Error
+		if err != nil {
+			return err
+		}
+		left := orig.Amount - reversed
+		if left == 0 {
+			return models.ErrAlreadyReversed
+		}
+		if amount == 0 {
+			amount = left
+		}
+		if amount > left {
+			return models.ErrReversalExceedsOp
+		}
+
+		var w models.Wallet
+		update := activeWallet(tx.Model(&w), orig.WalletID, "")
+		if sign < 0 {
+			update = update.Where("balance - held + credit_limit >= ?", amount)
+		}
+		result := update.
+			Clauses(clause.Returning{Columns: []clause.Column{{Name: "balance"}, {Name: "currency"}}}).
+			Update("balance", gorm.Expr("balance + ?", sign*amount))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			if err := walletOpErr(tx, orig.WalletID, ""); err != nil {
+				return err
+			}
+			return models.ErrInsufficientBalance
+		}
+		// Отмена списания зачисляет деньги и подчиняется max_balance
+		if sign > 0 {
+			if err := checkMaxBalance(tx, orig.WalletID, w.Balance); err != nil {
+				return err
+			}
+		}
+
+		reversal = models.Transaction{
+			ID:           uuid.New(),
+			WalletID:     orig.WalletID,
+			Type:         txType,
+			Amount:       amount,
+			BalanceAfter: w.Balance,
+			ReversalOf:   &orig.ID,
+		}
+		if err := tx.Create(&reversal).Error; err != nil {
+			return err
+		}
+		return writePostings(tx, externalPostings([]models.Transaction{reversal}, w.Currency, sign))
+	})
+	if err != nil {
+		logger.Error(fmt.Sprintf("repo ReverseOperation operationId=%s error: %v", operationID, err))
+		return models.Transaction{}, err
+	}
+	return reversal, nil
+}
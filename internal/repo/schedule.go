@@ -0,0 +1,193 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"test-psql/internal/models"
+	"test-psql/pkg/logger"
+)
+
+// CreateSchedule stores a schedule prepared by the service.
+func (r *WalletRepo) CreateSchedule(ctx context.Context, s models.Schedule) (models.Schedule, error) {
+	logger.Info(fmt.Sprintf("repo CreateSchedule walletId=%s type=%s amount=%d cron=%q", s.WalletID, s.OperationType, s.Amount, s.Cron))
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	if s.Status == "" {
+		s.Status = models.ScheduleStatusActive
+	}
+	if err := r.db.WithContext(ctx).Create(&s).Error; err != nil {
+		logger.Error(fmt.Sprintf("repo CreateSchedule db error: %v", err))
+		return models.Schedule{}, err
+	}
+	return s, nil
+}
+
+func (r *WalletRepo) GetSchedule(ctx context.Context, scheduleID string) (models.Schedule, error) {
+	logger.Info(fmt.Sprintf("repo GetSchedule id=%s", scheduleID))
+	id, err := uuid.Parse(scheduleID)
+	if err != nil {
+		return models.Schedule{}, models.ErrScheduleNotFound
+	}
+	var s models.Schedule
+	err = r.db.WithContext(ctx).Where("id = ?", id).First(&s).Error
+	if err == gorm.ErrRecordNotFound {
+		return models.Schedule{}, models.ErrScheduleNotFound
+	}
+	if err != nil {
+		logger.Error(fmt.Sprintf("repo GetSchedule db error: %v", err))
+		return models.Schedule{}, err
+	}
+	return s, nil
+}
+
+// ListSchedules returns the schedules that debit or credit the wallet, newest
+// first.
+func (r *WalletRepo) ListSchedules(ctx context.Context, walletID string, limit, offset int) ([]models.Schedule, error) {
+	logger.Info(fmt.Sprintf("repo ListSchedules walletId=%s limit=%d offset=%d", walletID, limit, offset))
+	id, err := uuid.Parse(walletID)
+	if err != nil {
+		return []models.Schedule{}, nil
+	}
+	schedules := make([]models.Schedule, 0, limit)
+	err = r.db.WithContext(ctx).
+		Where("wallet_id = ? OR to_wallet_id = ?", id, id).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&schedules).Error
+	if err != nil {
+		logger.Error(fmt.Sprintf("repo ListSchedules db error: %v", err))
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// SetScheduleStatus moves the schedule to status if the transition is
+// allowed. A resumed recurring schedule skips the runs it missed while
+// paused and continues from the next match of its cron spec.
+func (r *WalletRepo) SetScheduleStatus(ctx context.Context, scheduleID string, status models.ScheduleStatus) (models.Schedule, error) {
+	logger.Info(fmt.Sprintf("repo SetScheduleStatus id=%s status=%s", scheduleID, status))
+	id, err := uuid.Parse(scheduleID)
+	if err != nil {
+		return models.Schedule{}, models.ErrScheduleNotFound
+	}
+	var s models.Schedule
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&s).Error
+		if err == gorm.ErrRecordNotFound {
+			return models.ErrScheduleNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if !s.Status.CanTransitionTo(status) {
+			return fmt.Errorf("%w: %s -> %s", models.ErrScheduleTransition, s.Status, status)
+		}
+
+		now := time.Now().UTC()
+		updates := map[string]any{"status": status, "updated_at": now}
+		if status == models.ScheduleStatusActive && s.Cron != "" && s.NextRunAt != nil && s.NextRunAt.Before(now) {
+			next, err := nextRun(s.Cron, now)
+			if err != nil {
+				return err
+			}
+			updates["next_run_at"] = next
+		}
+		return tx.Model(&s).Clauses(clause.Returning{}).Updates(updates).Error
+	})
+	if err != nil {
+		return models.Schedule{}, err
+	}
+	return s, nil
+}
+
+// RunDueSchedule runs the earliest active schedule due at now, if any, and
+// reports whether there was one. The schedule stays locked while run applies
+// its operation, and moves to its next run only after that, so a run is never
+// lost across restarts; the operation carries an idempotency key of the run,
+// so a run whose schedule update did not commit is not applied twice. SKIP
+// LOCKED lets several instances share the work. A failed operation is
+// recorded in LastError and the schedule moves on all the same; a transient
+// failure (see models.IsTransient) is returned and leaves the schedule due.
+func (r *WalletRepo) RunDueSchedule(ctx context.Context, now time.Time, run func(context.Context, models.Operation) error) (models.Schedule, bool, error) {
+	var s models.Schedule
+	var found bool
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var due []models.Schedule
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_run_at <= ?", models.ScheduleStatusActive, now).
+			Order("next_run_at").
+			Limit(1).
+			Find(&due).Error
+		if err != nil || len(due) == 0 {
+			return err
+		}
+		s, found = due[0], true
+
+		op := s.Operation()
+		runErr := run(ctx, op)
+		// Прерванный запуск не записывается: расписание останется к выполнению
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// Очередь не приняла операцию: запуск повторится на следующем тике
+		if models.IsTransient(runErr) {
+			return runErr
+		}
+
+		updates := map[string]any{
+			"run_count":   gorm.Expr("run_count + 1"),
+			"last_run_at": now,
+			"last_error":  "",
+			"updated_at":  now,
+		}
+		if runErr != nil {
+			logger.Error(fmt.Sprintf("repo RunDueSchedule id=%s run failed: %v", s.ID, runErr))
+			updates["last_error"] = runErr.Error()
+		} else {
+			updates["last_operation_id"] = op.ID
+		}
+
+		// Пропущенные за время простоя запуски не догоняются:
+		// следующий запуск считается от текущего момента
+		var next *time.Time
+		if s.Cron != "" {
+			t, err := nextRun(s.Cron, now)
+			if err == nil {
+				next = &t
+			}
+		}
+		updates["next_run_at"] = next
+		if next == nil {
+			updates["status"] = models.ScheduleStatusCompleted
+			if runErr != nil && s.Cron == "" {
+				updates["status"] = models.ScheduleStatusFailed
+			}
+		}
+		return tx.Model(&s).Clauses(clause.Returning{}).Updates(updates).Error
+	})
+	if err != nil {
+		logger.Error(fmt.Sprintf("repo RunDueSchedule db error: %v", err))
+		return models.Schedule{}, false, err
+	}
+	return s, found, nil
+}
+
+func nextRun(spec string, after time.Time) (time.Time, error) {
+	c, err := models.ParseCron(spec)
+	if err != nil {
+		return time.Time{}, err
+	}
+	next, ok := c.Next(after)
+	if !ok {
+		return time.Time{}, fmt.Errorf("cron spec %q never matches", spec)
+	}
+	return next, nil
+}
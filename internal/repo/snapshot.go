@@ -0,0 +1,47 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"test-psql/pkg/logger"
+)
+
+// TakeBalanceSnapshots records, at upTo, the balance of every wallet with
+// postings since the last snapshot run: its previous snapshot plus those
+// postings. Runs must move forward in time, and upTo must lag behind now by
+// more than any transaction lasts, so that no posting stamped before upTo is
+// still uncommitted. It returns the number of snapshots taken.
+func (r *WalletRepo) TakeBalanceSnapshots(ctx context.Context, upTo time.Time) (int64, error) {
+	upTo = upTo.UTC()
+	logger.Info(fmt.Sprintf("repo TakeBalanceSnapshots upTo=%s", upTo.Format(time.RFC3339)))
+
+	// Каждый запуск снимает все изменившиеся кошельки, поэтому проводки
+	// до последнего запуска уже учтены в последних снимках кошельков
+	result := r.db.WithContext(ctx).Exec(`
+		WITH since AS (
+			SELECT COALESCE(MAX(taken_at), '-infinity'::timestamp) AS taken_at
+			FROM wallet_balance_snapshots
+		), changed AS (
+			SELECT p.wallet_id, SUM(p.amount) AS amount
+			FROM ledger_postings p, since
+			WHERE p.wallet_id IS NOT NULL AND p.created_at > since.taken_at AND p.created_at <= ?
+			GROUP BY p.wallet_id
+		)
+		INSERT INTO wallet_balance_snapshots (wallet_id, taken_at, balance)
+		SELECT c.wallet_id, ?, COALESCE(s.balance, 0) + c.amount
+		FROM changed c
+		LEFT JOIN LATERAL (
+			SELECT balance FROM wallet_balance_snapshots
+			WHERE wallet_id = c.wallet_id
+			ORDER BY taken_at DESC
+			LIMIT 1
+		) s ON true
+		ON CONFLICT DO NOTHING`, upTo, upTo)
+	if result.Error != nil {
+		logger.Error(fmt.Sprintf("repo TakeBalanceSnapshots db error: %v", result.Error))
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
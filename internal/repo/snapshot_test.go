@@ -0,0 +1,86 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"test-psql/internal/migrations"
+	"test-psql/internal/models"
+)
+
+// testRepo connects to the database in TEST_DATABASE_DSN and migrates it.
+// Tests that need a database are skipped without it.
+func testRepo(t *testing.T) *WalletRepo {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger:  gormlogger.Discard,
+		NowFunc: func() time.Time { return time.Now().UTC() },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := migrations.Run(db, "../../migrations"); err != nil {
+		t.Fatal(err)
+	}
+	return NewWalletRepo(db)
+}
+
+func TestWalletRepo_GetBalanceAt_OpeningPostings(t *testing.T) {
+	r := testRepo(t)
+	ctx := context.Background()
+	w, err := r.CreateWallet(ctx, uuid.New(), "RUB", models.WalletMetadata{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Кошелёк с балансом до журнала: строк операций нет, есть только
+	// начальная проводка, как после миграции 000008
+	opened := time.Now().UTC().Add(-time.Hour)
+	entry := uuid.New()
+	postings := []models.Posting{
+		models.WalletPosting(entry, w.ID, "RUB", 500),
+		models.SystemPosting(entry, models.SystemAccountExternalCash, "RUB", -500),
+	}
+	for i := range postings {
+		postings[i].CreatedAt = opened
+	}
+	if err := r.db.Exec("UPDATE wallets SET balance = 500 WHERE id = ?", w.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := writePostings(r.db, postings); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(at time.Time, want int64) {
+		t.Helper()
+		b, err := r.GetBalanceAt(ctx, w.ID.String(), at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.Balance != want {
+			t.Errorf("balance at %s: got %d, want %d", at.Format(time.RFC3339), b.Balance, want)
+		}
+	}
+	check(time.Now(), 500)
+	if _, err := r.GetBalanceAt(ctx, w.ID.String(), opened.Add(-time.Minute)); !errors.Is(err, models.ErrBalanceUnknown) {
+		t.Errorf("balance before the opening posting: want ErrBalanceUnknown, got %v", err)
+	}
+
+	if _, err := r.TakeBalanceSnapshots(ctx, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	check(time.Now(), 500)
+	// Время запроса в другом поясе — тот же момент
+	check(time.Now().In(time.FixedZone("UTC+3", 3*60*60)), 500)
+}
@@ -0,0 +1,86 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"test-psql/internal/models"
+	"test-psql/pkg/logger"
+)
+
+// StreamStatement reads the wallet's ledger rows created in [from, to),
+// oldest first. The opening balance is the sum of the wallet's postings
+// before from, opening postings included, so it adds up with the rows to the
+// wallet balance. open is called with the statement header before the first
+// row and row for every row; the rows come from a cursor, so memory does not
+// grow with the wallet's history. Everything is read in one REPEATABLE READ
+// snapshot, so the returned closing balance matches the rows passed to row.
+func (r *WalletRepo) StreamStatement(ctx context.Context, walletID string, from, to time.Time,
+	open func(models.Statement) error, row func(models.Transaction) error) (models.Statement, error) {
+	logger.Info(fmt.Sprintf("repo StreamStatement walletId=%s from=%s to=%s", walletID, from.Format(time.RFC3339), to.Format(time.RFC3339)))
+	id, err := uuid.Parse(walletID)
+	if err != nil {
+		return models.Statement{}, models.ErrWalletNotFound
+	}
+	st := models.Statement{WalletID: id, From: from, To: to}
+	// Время журнала хранится в UTC, поэтому границы сравниваются тоже в UTC
+	fromUTC, toUTC := from.UTC(), to.UTC()
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Wallet{}).
+			Select("currency", "currency_exponent").
+			Where("id = ?", id).
+			Limit(1).
+			Scan(&st)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return models.ErrWalletNotFound
+		}
+
+		// Входящий остаток — сумма проводок строго до from: в отличие от
+		// строк операций они включают начальную проводку кошельков,
+		// заведённых до журнала
+		err := tx.Model(&models.Posting{}).
+			Select("COALESCE(SUM(amount), 0)").
+			Where("wallet_id = ? AND created_at < ?", id, fromUTC).
+			Scan(&st.OpeningBalance).Error
+		if err != nil {
+			return err
+		}
+		st.ClosingBalance = st.OpeningBalance
+		if err := open(st); err != nil {
+			return err
+		}
+
+		rows, err := tx.Model(&models.Transaction{}).
+			Where("wallet_id = ? AND created_at >= ? AND created_at < ?", id, fromUTC, toUTC).
+			Order("created_at, seq").
+			Rows()
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var t models.Transaction
+			if err := tx.ScanRows(rows, &t); err != nil {
+				return err
+			}
+			if err := row(t); err != nil {
+				return err
+			}
+			st.ClosingBalance = t.BalanceAfter
+		}
+		return rows.Err()
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		logger.Error(fmt.Sprintf("repo StreamStatement walletId=%s error: %v", walletID, err))
+		return models.Statement{}, err
+	}
+	return st, nil
+}
@@ -0,0 +1,56 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"test-psql/internal/models"
+)
+
+func TestWalletRepo_StreamStatement_OpeningPosting(t *testing.T) {
+	r := testRepo(t)
+	ctx := context.Background()
+	w, err := r.CreateWallet(ctx, uuid.New(), "RUB", models.WalletMetadata{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Кошелёк с балансом до журнала: начальная проводка без строки операции
+	opened := time.Now().UTC().Add(-time.Hour)
+	entry := uuid.New()
+	postings := []models.Posting{
+		models.WalletPosting(entry, w.ID, "RUB", 500),
+		models.SystemPosting(entry, models.SystemAccountExternalCash, "RUB", -500),
+	}
+	for i := range postings {
+		postings[i].CreatedAt = opened
+	}
+	if err := r.db.Exec("UPDATE wallets SET balance = 500 WHERE id = ?", w.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := writePostings(r.db, postings); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Deposit(ctx, w.ID.String(), []models.Operation{
+		{ID: uuid.New(), Type: "DEPOSIT", WalletID: w.ID.String(), Amount: 100, Currency: "RUB"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var rows []models.Transaction
+	st, err := r.StreamStatement(ctx, w.ID.String(), opened.Add(time.Minute), time.Now().Add(time.Minute),
+		func(models.Statement) error { return nil },
+		func(tx models.Transaction) error { rows = append(rows, tx); return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.OpeningBalance != 500 {
+		t.Errorf("got opening balance %d, want 500", st.OpeningBalance)
+	}
+	if len(rows) != 1 || st.ClosingBalance != 600 {
+		t.Errorf("got %d rows and closing balance %d, want 1 and 600", len(rows), st.ClosingBalance)
+	}
+}
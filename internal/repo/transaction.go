@@ -0,0 +1,82 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"test-psql/internal/models"
+	"test-psql/pkg/logger"
+)
+
+// GetTransactions returns the wallet's ledger, newest first.
+func (r *WalletRepo) GetTransactions(ctx context.Context, walletID string, limit, offset int) ([]models.Transaction, error) {
+	logger.Info(fmt.Sprintf("repo GetTransactions walletId=%s limit=%d offset=%d", walletID, limit, offset))
+	id, err := uuid.Parse(walletID)
+	if err != nil {
+		return []models.Transaction{}, nil
+	}
+	txs := make([]models.Transaction, 0, limit)
+	err = r.db.WithContext(ctx).
+		Where("wallet_id = ?", id).
+		Order("seq DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&txs).Error
+	if err != nil {
+		logger.Error(fmt.Sprintf("repo GetTransactions db error: %v", err))
+		return nil, err
+	}
+	return txs, nil
+}
+
+// GetOperationFee returns the sum of the FEE rows charged on the operation.
+func (r *WalletRepo) GetOperationFee(ctx context.Context, operationID uuid.UUID) (int64, error) {
+	logger.Info(fmt.Sprintf("repo GetOperationFee operationId=%s", operationID))
+	var fee int64
+	err := r.db.WithContext(ctx).Model(&models.Transaction{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("fee_for = ? AND type = ?", operationID, "FEE").
+		Scan(&fee).Error
+	if err != nil {
+		logger.Error(fmt.Sprintf("repo GetOperationFee db error: %v", err))
+		return 0, err
+	}
+	return fee, nil
+}
+
+// newTransactions builds ledger rows for ops applied in order on top of
+// startBalance; sign is +1 for credits and -1 for debits. An op with fees is
+// followed by a FEE row debiting their total.
+func newTransactions(walletID uuid.UUID, opType string, ops []models.Operation, startBalance int64, sign int64) []models.Transaction {
+	txs := make([]models.Transaction, 0, len(ops))
+	balance := startBalance
+	for _, op := range ops {
+		balance += sign * op.Amount
+		id := op.ID
+		if id == uuid.Nil {
+			id = uuid.New()
+		}
+		txs = append(txs, models.Transaction{
+			ID:           id,
+			WalletID:     walletID,
+			Type:         opType,
+			Amount:       op.Amount,
+			BalanceAfter: balance,
+		})
+		if fee := models.TotalFees(op.Fees); fee > 0 {
+			opID := id
+			balance -= fee
+			txs = append(txs, models.Transaction{
+				ID:           uuid.New(),
+				WalletID:     walletID,
+				Type:         "FEE",
+				Amount:       fee,
+				BalanceAfter: balance,
+				FeeFor:       &opID,
+			})
+		}
+	}
+	return txs
+}
@@ -0,0 +1,124 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"test-psql/internal/models"
+	"test-psql/pkg/logger"
+)
+
+// Transfer moves op.Amount from op.WalletID to op.ToWalletID in one
+// transaction and writes a TRANSFER_OUT and a TRANSFER_IN ledger row and the
+// matching balanced entry. A transfer replayed under a known idempotency key
+// is skipped.
+func (r *WalletRepo) Transfer(ctx context.Context, op models.Operation) ([]models.Transaction, error) {
+	logger.Info(fmt.Sprintf("repo Transfer from=%s to=%s amount=%d", op.WalletID, op.ToWalletID, op.Amount))
+	fromID, err := uuid.Parse(op.WalletID)
+	if err != nil {
+		return nil, models.ErrWalletNotFound
+	}
+	toID, err := uuid.Parse(op.ToWalletID)
+	if err != nil {
+		return nil, models.ErrWalletNotFound
+	}
+	if fromID == toID {
+		return nil, models.ErrSameWalletTransfer
+	}
+	if op.Amount <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+	transferID := op.ID
+	if transferID == uuid.Nil {
+		transferID = uuid.New()
+	}
+
+	var txs []models.Transaction
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		pending, err := claimIdempotencyKeys(tx, []models.Operation{op})
+		if err != nil || len(pending) == 0 {
+			return err
+		}
+		txs, err = transfer(tx, fromID, toID, transferID, op)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return txs, nil
+}
+
+// transfer is the body of Transfer inside an open transaction.
+func transfer(tx *gorm.DB, fromID, toID, transferID uuid.UUID, op models.Operation) ([]models.Transaction, error) {
+	// Строки блокируются в порядке id, поэтому встречные переводы
+	// A->B и B->A не могут взаимно заблокировать друг друга
+	var wallets []models.Wallet
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "balance", "held", "credit_limit", "status", "currency").
+		Where("id IN ?", []uuid.UUID{fromID, toID}).
+		Order("id").
+		Find(&wallets).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(wallets) != 2 {
+		return nil, models.ErrWalletNotFound
+	}
+	balances := make(map[uuid.UUID]int64, len(wallets))
+	var fromAvailable int64
+	for _, w := range wallets {
+		if err := w.Status.Err(); err != nil {
+			return nil, err
+		}
+		if w.Currency != wallets[0].Currency || (op.Currency != "" && w.Currency != op.Currency) {
+			return nil, models.ErrCurrencyMismatch
+		}
+		balances[w.ID] = w.Balance
+		if w.ID == fromID {
+			fromAvailable = w.Balance - w.Held + w.CreditLimit
+		}
+	}
+	if fromAvailable < op.Amount {
+		return nil, models.ErrInsufficientBalance
+	}
+
+	err = tx.Model(&models.Wallet{}).Where("id = ?", fromID).
+		Update("balance", gorm.Expr("balance - ?", op.Amount)).Error
+	if err != nil {
+		return nil, err
+	}
+	err = tx.Model(&models.Wallet{}).Where("id = ?", toID).
+		Update("balance", gorm.Expr("balance + ?", op.Amount)).Error
+	if err != nil {
+		return nil, err
+	}
+
+	txs := []models.Transaction{
+		{
+			ID:                   uuid.New(),
+			WalletID:             fromID,
+			Type:                 "TRANSFER_OUT",
+			Amount:               op.Amount,
+			BalanceAfter:         balances[fromID] - op.Amount,
+			TransferID:           &transferID,
+			CounterpartyWalletID: &toID,
+		},
+		{
+			ID:                   uuid.New(),
+			WalletID:             toID,
+			Type:                 "TRANSFER_IN",
+			Amount:               op.Amount,
+			BalanceAfter:         balances[toID] + op.Amount,
+			TransferID:           &transferID,
+			CounterpartyWalletID: &fromID,
+		},
+	}
+	if err := tx.Create(&txs).Error; err != nil {
+		return nil, err
+	}
+	return txs, writePostings(tx, transferPostings(transferID, fromID, toID, wallets[0].Currency, op.Amount))
+}
@@ -3,9 +3,13 @@ package repo
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"test-psql/internal/models"
 	"test-psql/pkg/logger"
@@ -19,55 +23,325 @@ func NewWalletRepo(db *gorm.DB) *WalletRepo {
 	return &WalletRepo{db: db}
 }
 
-func (r *WalletRepo) GetBalance(ctx context.Context, walletID string) (int64, error) {
+func (r *WalletRepo) GetBalance(ctx context.Context, walletID string) (models.Balance, error) {
 	logger.Info(fmt.Sprintf("repo GetBalance walletId=%s", walletID))
-	var w models.Wallet
-	err := r.db.WithContext(ctx).Select("balance").Where("id = ?", walletID).First(&w).Error
+	id, err := uuid.Parse(walletID)
+	if err != nil {
+		return models.Balance{}, models.ErrWalletNotFound
+	}
+	var b models.Balance
+	result := r.db.WithContext(ctx).Model(&models.Wallet{}).
+		Select("balance", "balance - held + credit_limit AS available", "credit_limit", "currency", "currency_exponent").
+		Where("id = ?", id).
+		Limit(1).
+		Scan(&b)
+	if result.Error != nil {
+		logger.Error(fmt.Sprintf("repo GetBalance db error: %v", result.Error))
+		return models.Balance{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return models.Balance{}, models.ErrWalletNotFound
+	}
+	return b, nil
+}
+
+// balanceAtRow is the balance read by GetBalanceAt together with the time of
+// the wallet's first posting, or nil if it has none.
+type balanceAtRow struct {
+	models.Balance
+	FirstPostingAt *time.Time
+}
+
+// GetBalanceAt returns the wallet balance as of at: the last balance snapshot
+// taken at or before at plus the wallet's postings after it, so the query
+// reads at most one snapshot period of history however long the wallet's
+// history is. Postings include the opening balances of wallets created
+// before the ledger, so such a wallet reports its balance even without
+// ledger rows. The opening posting carries the migration time, not the time
+// the balance appeared, so for a moment before the wallet's first posting
+// the balance is unknown and ErrBalanceUnknown is returned.
+func (r *WalletRepo) GetBalanceAt(ctx context.Context, walletID string, at time.Time) (models.Balance, error) {
+	logger.Info(fmt.Sprintf("repo GetBalanceAt walletId=%s at=%s", walletID, at.Format(time.RFC3339)))
+	id, err := uuid.Parse(walletID)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return 0, nil
+		return models.Balance{}, models.ErrWalletNotFound
+	}
+
+	// Время журнала хранится в UTC, поэтому at сравнивается тоже в UTC
+	at = at.UTC()
+	var row balanceAtRow
+	result := r.db.WithContext(ctx).Raw(`
+		SELECT w.currency, w.currency_exponent,
+			COALESCE(s.balance, 0) + COALESCE((
+				SELECT SUM(p.amount) FROM ledger_postings p
+				WHERE p.wallet_id = w.id AND p.created_at <= ?
+					AND (s.taken_at IS NULL OR p.created_at > s.taken_at)
+			), 0) AS balance,
+			(SELECT MIN(p.created_at) FROM ledger_postings p WHERE p.wallet_id = w.id) AS first_posting_at
+		FROM wallets w
+		LEFT JOIN LATERAL (
+			SELECT balance, taken_at FROM wallet_balance_snapshots
+			WHERE wallet_id = w.id AND taken_at <= ?
+			ORDER BY taken_at DESC
+			LIMIT 1
+		) s ON true
+		WHERE w.id = ?`, at, at, id).
+		Scan(&row)
+	if result.Error != nil {
+		logger.Error(fmt.Sprintf("repo GetBalanceAt db error: %v", result.Error))
+		return models.Balance{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return models.Balance{}, models.ErrWalletNotFound
+	}
+	// Без проводок баланс кошелька всегда был нулевым
+	if row.FirstPostingAt != nil && at.Before(*row.FirstPostingAt) {
+		return models.Balance{}, models.ErrBalanceUnknown
+	}
+	b := row.Balance
+	b.Available = b.Balance
+	return b, nil
+}
+
+// Deposit credits the ops to the wallet and writes one ledger row and one
+// balanced entry against external cash per op in the same transaction. Ops
+// replayed under a known idempotency key are skipped.
+func (r *WalletRepo) Deposit(ctx context.Context, walletID string, ops []models.Operation) ([]models.Transaction, error) {
+	logger.Info(fmt.Sprintf("repo Deposit walletId=%s ops=%d", walletID, len(ops)))
+	id, currency, err := prepareOps(walletID, ops)
+	if err != nil {
+		return nil, err
+	}
+	var txs []models.Transaction
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txs, err = deposit(tx, id, currency, ops)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return txs, nil
+}
+
+// Withdraw debits the ops and their fees from the wallet if the available
+// balance (balance minus active holds plus the credit limit) covers all of
+// them and writes one ledger row and one balanced entry against external cash
+// per op, plus a FEE row paid to the fees account per op with fees, in the
+// same transaction. Ops replayed under a known idempotency key are skipped.
+func (r *WalletRepo) Withdraw(ctx context.Context, walletID string, ops []models.Operation) ([]models.Transaction, error) {
+	logger.Info(fmt.Sprintf("repo Withdraw walletId=%s ops=%d", walletID, len(ops)))
+	id, currency, err := prepareOps(walletID, ops)
+	if err != nil {
+		return nil, err
+	}
+	var txs []models.Transaction
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txs, err = withdraw(tx, id, currency, ops)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return txs, nil
+}
+
+// WithdrawEach applies the ops one by one in order in one transaction, each
+// behind its own savepoint, so that an op the wallet cannot cover is rejected
+// with ErrInsufficientBalance while the others are still paid. It returns one
+// result per op; any other error fails the whole call and applies nothing.
+func (r *WalletRepo) WithdrawEach(ctx context.Context, walletID string, ops []models.Operation) ([]error, error) {
+	logger.Info(fmt.Sprintf("repo WithdrawEach walletId=%s ops=%d", walletID, len(ops)))
+	id, currency, err := prepareOps(walletID, ops)
+	if err != nil {
+		return nil, err
+	}
+	errs := make([]error, len(ops))
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range ops {
+			err := tx.Transaction(func(sp *gorm.DB) error {
+				_, err := withdraw(sp, id, currency, ops[i:i+1])
+				return err
+			})
+			if errors.Is(err, models.ErrInsufficientBalance) {
+				errs[i] = err
+				continue
+			}
+			if err != nil {
+				return err
+			}
 		}
-		logger.Error(fmt.Sprintf("repo GetBalance db error: %v", err))
-		return 0, err
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return w.Balance, nil
+	return errs, nil
 }
 
-func (r *WalletRepo) Deposit(ctx context.Context, walletID string, amount int64) error {
-	logger.Info(fmt.Sprintf("repo Deposit walletId=%s amount=%d", walletID, amount))
-	if amount <= 0 {
-		return fmt.Errorf("amount must be positive")
+// ApplyBatch applies DEPOSIT and WITHDRAW ops in order in one transaction:
+// either all of them take effect or none does. The error of a failed op names
+// its index in ops.
+func (r *WalletRepo) ApplyBatch(ctx context.Context, ops []models.Operation) ([]models.Transaction, error) {
+	logger.Info(fmt.Sprintf("repo ApplyBatch ops=%d", len(ops)))
+	ids := make([]uuid.UUID, len(ops))
+	for i, op := range ops {
+		id, _, err := prepareOps(op.WalletID, ops[i:i+1])
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+		if op.Type != "DEPOSIT" && op.Type != "WITHDRAW" {
+			return nil, fmt.Errorf("operation %d: unknown operation type: %s", i, op.Type)
+		}
+		ids[i] = id
 	}
-	result := r.db.WithContext(ctx).Model(&models.Wallet{}).
-		Where("id = ?", walletID).
-		Update("balance", gorm.Expr("balance + ?", amount))
+
+	var txs []models.Transaction
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Кошельки блокируются заранее в порядке id, как в Transfer,
+		// чтобы встречные батчи не блокировали друг друга
+		var locked []models.Wallet
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").
+			Where("id IN ?", ids).
+			Order("id").
+			Find(&locked).Error
+		if err != nil {
+			return err
+		}
+		for i, op := range ops {
+			apply := deposit
+			if op.Type == "WITHDRAW" {
+				apply = withdraw
+			}
+			opTxs, err := apply(tx, ids[i], op.Currency, ops[i:i+1])
+			if err != nil {
+				return fmt.Errorf("operation %d: %w", i, err)
+			}
+			txs = append(txs, opTxs...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return txs, nil
+}
+
+// deposit is the body of Deposit inside an open transaction.
+func deposit(tx *gorm.DB, id uuid.UUID, currency string, ops []models.Operation) ([]models.Transaction, error) {
+	pending, err := claimIdempotencyKeys(tx, ops)
+	if err != nil || len(pending) == 0 {
+		return nil, err
+	}
+	total := sumOps(pending)
+	var w models.Wallet
+	result := activeWallet(tx.Model(&w), id, currency).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "balance"}, {Name: "currency"}}}).
+		Update("balance", gorm.Expr("balance + ?", total))
 	if result.Error != nil {
-		return result.Error
+		return nil, result.Error
 	}
 	if result.RowsAffected == 0 {
-		return fmt.Errorf("wallet not found")
+		if err := walletOpErr(tx, id, currency); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("deposit matched no rows")
 	}
-	return nil
+	txs := newTransactions(id, "DEPOSIT", pending, w.Balance-total, 1)
+	if err := tx.Create(&txs).Error; err != nil {
+		return nil, err
+	}
+	return txs, writePostings(tx, externalPostings(txs, w.Currency, 1))
 }
 
-func (r *WalletRepo) Withdraw(ctx context.Context, walletID string, amount int64) error {
-	logger.Info(fmt.Sprintf("repo Withdraw walletId=%s amount=%d", walletID, amount))
-	if amount <= 0 {
-		return fmt.Errorf("amount must be positive")
+// withdraw is the body of Withdraw inside an open transaction.
+func withdraw(tx *gorm.DB, id uuid.UUID, currency string, ops []models.Operation) ([]models.Transaction, error) {
+	pending, err := claimIdempotencyKeys(tx, ops)
+	if err != nil || len(pending) == 0 {
+		return nil, err
 	}
-	result := r.db.WithContext(ctx).Model(&models.Wallet{}).
-		Where("id = ? AND balance >= ?", walletID, amount).
-		Update("balance", gorm.Expr("balance - ?", amount))
+	total := sumOps(pending)
+	for _, op := range pending {
+		total += models.TotalFees(op.Fees)
+	}
+	var w models.Wallet
+	result := activeWallet(tx.Model(&w), id, currency).
+		Where("balance - held + credit_limit >= ?", total).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "balance"}, {Name: "currency"}}}).
+		Update("balance", gorm.Expr("balance - ?", total))
 	if result.Error != nil {
-		return result.Error
+		return nil, result.Error
 	}
 	if result.RowsAffected == 0 {
-		var w models.Wallet
-		err := r.db.WithContext(ctx).Where("id = ?", walletID).First(&w).Error
-		if err == gorm.ErrRecordNotFound {
-			return fmt.Errorf("wallet not found")
+		if err := walletOpErr(tx, id, currency); err != nil {
+			return nil, err
 		}
-		return fmt.Errorf("insufficient balance")
+		return nil, models.ErrInsufficientBalance
+	}
+	txs := newTransactions(id, "WITHDRAW", pending, w.Balance+total, -1)
+	if err := tx.Create(&txs).Error; err != nil {
+		return nil, err
+	}
+	return txs, writePostings(tx, withdrawPostings(txs, w.Currency))
+}
+
+// activeWallet narrows a wallet update to an active wallet in currency;
+// an empty currency matches any.
+func activeWallet(tx *gorm.DB, id uuid.UUID, currency string) *gorm.DB {
+	tx = tx.Where("id = ? AND status = ?", id, models.WalletStatusActive)
+	if currency != "" {
+		tx = tx.Where("currency = ?", currency)
+	}
+	return tx
+}
+
+// walletOpErr explains why an update guarded by activeWallet matched no rows:
+// the wallet is missing, frozen, closed or in another currency. It returns nil
+// if none of these apply.
+func walletOpErr(tx *gorm.DB, id uuid.UUID, currency string) error {
+	var w models.Wallet
+	err := tx.Select("status", "currency").Where("id = ?", id).First(&w).Error
+	if err == gorm.ErrRecordNotFound {
+		return models.ErrWalletNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if err := w.Status.Err(); err != nil {
+		return err
+	}
+	if currency != "" && w.Currency != currency {
+		return models.ErrCurrencyMismatch
 	}
 	return nil
 }
+
+// prepareOps validates a group of ops for one wallet and returns the parsed
+// wallet ID and the currency shared by all ops.
+func prepareOps(walletID string, ops []models.Operation) (uuid.UUID, string, error) {
+	id, err := uuid.Parse(walletID)
+	if err != nil {
+		return uuid.Nil, "", models.ErrWalletNotFound
+	}
+	if len(ops) == 0 {
+		return uuid.Nil, "", fmt.Errorf("no operations")
+	}
+	currency := ops[0].Currency
+	for _, op := range ops {
+		if op.Amount <= 0 {
+			return uuid.Nil, "", fmt.Errorf("amount must be positive")
+		}
+		if op.Currency != currency {
+			return uuid.Nil, "", fmt.Errorf("operations in one group must share a currency")
+		}
+	}
+	return id, currency, nil
+}
+
+func sumOps(ops []models.Operation) int64 {
+	var total int64
+	for _, op := range ops {
+		total += op.Amount
+	}
+	return total
+}
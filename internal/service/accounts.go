@@ -0,0 +1,14 @@
+package service
+
+import (
+	"context"
+
+	"test-psql/internal/models"
+	"test-psql/pkg/logger"
+)
+
+// GetSystemAccounts returns the balance of every system account per currency.
+func (s *WalletService) GetSystemAccounts(ctx context.Context) ([]models.SystemAccountBalance, error) {
+	logger.Info("service GetSystemAccounts")
+	return s.repo.GetSystemAccountBalances(ctx)
+}
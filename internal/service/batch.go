@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"test-psql/internal/models"
+	"test-psql/pkg/logger"
+)
+
+// batchWorkers caps how many wallets of a best_effort batch are applied at
+// once.
+const batchWorkers = 8
+
+// UpdateBalances applies ops independently and returns one result per op.
+// The ops of one wallet are submitted one after another in request order, so
+// each sees the ones before it; up to batchWorkers wallets are applied at
+// once and share queue flushes with each other and with regular requests.
+func (s *WalletService) UpdateBalances(ctx context.Context, ops []models.Operation) []error {
+	logger.Info(fmt.Sprintf("service UpdateBalances ops=%d", len(ops)))
+	errs := make([]error, len(ops))
+	var wallets [][]int
+	walletIndex := make(map[string]int)
+	for i, op := range ops {
+		w, ok := walletIndex[op.WalletID]
+		if !ok {
+			w = len(wallets)
+			walletIndex[op.WalletID] = w
+			wallets = append(wallets, nil)
+		}
+		wallets[w] = append(wallets[w], i)
+	}
+
+	next := make(chan []int)
+	var wg sync.WaitGroup
+	for range min(batchWorkers, len(wallets)) {
+		wg.Go(func() {
+			for items := range next {
+				for _, i := range items {
+					_, errs[i] = s.UpdateBalance(ctx, ops[i])
+				}
+			}
+		})
+	}
+	for _, items := range wallets {
+		next <- items
+	}
+	close(next)
+	wg.Wait()
+	return errs
+}
+
+// batchWallet is what the ops of a batch before the current one do to a
+// wallet: the number and sum of its withdrawals and the change of its
+// balance.
+type batchWallet struct {
+	withdrawals int64
+	withdrawn   int64
+	delta       int64
+}
+
+// ApplyBatch applies DEPOSIT and WITHDRAW ops in order in one transaction
+// through the queue: either all of them take effect or none does. Limits are
+// checked for each op on top of the earlier ops of the batch and fees computed
+// for each op on its own before the batch is queued.
+func (s *WalletService) ApplyBatch(ctx context.Context, ops []models.Operation) error {
+	logger.Info(fmt.Sprintf("service ApplyBatch ops=%d", len(ops)))
+	if len(ops) == 0 {
+		return fmt.Errorf("no operations")
+	}
+	wallets := make(map[string]*batchWallet)
+	for i := range ops {
+		op := &ops[i]
+		if op.Type != "DEPOSIT" && op.Type != "WITHDRAW" {
+			return fmt.Errorf("operation %d: unknown operation type: %s", i, op.Type)
+		}
+		if err := validateCurrency(op.Currency); err != nil {
+			return fmt.Errorf("operation %d: %w", i, err)
+		}
+		if op.ID == uuid.Nil {
+			op.ID = uuid.New()
+		}
+		w := wallets[op.WalletID]
+		if w == nil {
+			w = &batchWallet{}
+			wallets[op.WalletID] = w
+		}
+
+		// Операции пакета ещё не в журнале, поэтому лимиты проверяются
+		// с учётом предыдущих операций пакета на том же кошельке
+		var err error
+		if op.Type == "WITHDRAW" {
+			err = s.checkWithdrawalLimits(ctx, op.WalletID, w.withdrawals+1, w.withdrawn+op.Amount)
+		} else {
+			err = s.checkMaxBalance(ctx, op.WalletID, w.delta+op.Amount)
+		}
+		if err != nil {
+			return fmt.Errorf("operation %d: %w", i, err)
+		}
+		fees, err := s.chargeFees(ctx, *op)
+		if err != nil {
+			return fmt.Errorf("operation %d: %w", i, err)
+		}
+		op.Fees = fees
+
+		if op.Type == "WITHDRAW" {
+			w.withdrawals++
+			w.withdrawn += op.Amount
+			w.delta -= op.Amount + models.TotalFees(fees)
+		} else {
+			w.delta += op.Amount
+		}
+	}
+	resultChan := make(chan error, 1)
+	if err := s.queue.AddBatch(ctx, ops, resultChan); err != nil {
+		return err
+	}
+	return <-resultChan
+}
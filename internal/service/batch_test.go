@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"test-psql/internal/models"
+	"test-psql/internal/queue"
+)
+
+func TestWalletService_ApplyBatch(t *testing.T) {
+	ops := []models.Operation{
+		{WalletID: "id1", Type: "DEPOSIT", Amount: 100},
+		{WalletID: "id2", Type: "WITHDRAW", Amount: 50},
+	}
+
+	t.Run("queued as one batch", func(t *testing.T) {
+		repo := &stubWalletRepo{}
+		q := queue.NewQueue(repo, 50, 10*time.Millisecond)
+		go q.ProcessQueue(context.Background())
+		svc := NewWalletService(q, repo)
+
+		if err := svc.ApplyBatch(context.Background(), append([]models.Operation(nil), ops...)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(repo.batchOps) != 1 || len(repo.batchOps[0]) != 2 || len(repo.depositOps) != 0 {
+			t.Fatalf("want a single batch of 2 ops, got %+v", repo.batchOps)
+		}
+		for _, op := range repo.batchOps[0] {
+			if op.ID == uuid.Nil {
+				t.Error("operation ID must be assigned")
+			}
+		}
+	})
+
+	t.Run("repo error", func(t *testing.T) {
+		repo := &stubWalletRepo{batchErr: models.ErrInsufficientBalance}
+		q := queue.NewQueue(repo, 50, 10*time.Millisecond)
+		go q.ProcessQueue(context.Background())
+		svc := NewWalletService(q, repo)
+
+		err := svc.ApplyBatch(context.Background(), append([]models.Operation(nil), ops...))
+		if !errors.Is(err, models.ErrInsufficientBalance) {
+			t.Errorf("want ErrInsufficientBalance, got %v", err)
+		}
+	})
+
+	t.Run("limit exceeded names the operation", func(t *testing.T) {
+		repo := &stubWalletRepo{limits: models.WalletLimits{MaxWithdrawalsPerHour: ptr(1)}, usage: models.WithdrawalUsage{Count1h: 1}}
+		svc := NewWalletService(queue.NewQueue(repo, 50, 10*time.Millisecond), repo)
+
+		err := svc.ApplyBatch(context.Background(), append([]models.Operation(nil), ops...))
+		if !errors.Is(err, models.ErrLimitExceeded) || !strings.HasPrefix(err.Error(), "operation 1:") {
+			t.Errorf("want limit error for operation 1, got %v", err)
+		}
+		if len(repo.batchOps) != 0 {
+			t.Error("batch must not be queued")
+		}
+	})
+
+	t.Run("withdrawals of the batch count towards the limit", func(t *testing.T) {
+		repo := &stubWalletRepo{limits: models.WalletLimits{MaxWithdrawalsPerHour: ptr(2)}}
+		svc := NewWalletService(queue.NewQueue(repo, 50, 10*time.Millisecond), repo)
+
+		err := svc.ApplyBatch(context.Background(), []models.Operation{
+			{WalletID: "id1", Type: "WITHDRAW", Amount: 10},
+			{WalletID: "id1", Type: "WITHDRAW", Amount: 10},
+			{WalletID: "id1", Type: "WITHDRAW", Amount: 10},
+		})
+		if !errors.Is(err, models.ErrLimitExceeded) || !strings.HasPrefix(err.Error(), "operation 2:") {
+			t.Errorf("want limit error for operation 2, got %v", err)
+		}
+		if len(repo.batchOps) != 0 {
+			t.Error("batch must not be queued")
+		}
+	})
+
+	t.Run("deposits checked against the projected balance", func(t *testing.T) {
+		repo := &stubWalletRepo{limits: models.WalletLimits{MaxBalance: ptr(1000)}, getBalanceVal: 500}
+		svc := NewWalletService(queue.NewQueue(repo, 50, 10*time.Millisecond), repo)
+
+		err := svc.ApplyBatch(context.Background(), []models.Operation{
+			{WalletID: "id1", Type: "DEPOSIT", Amount: 300},
+			{WalletID: "id1", Type: "DEPOSIT", Amount: 300},
+		})
+		if !errors.Is(err, models.ErrLimitExceeded) || !strings.HasPrefix(err.Error(), "operation 1:") {
+			t.Errorf("want limit error for operation 1, got %v", err)
+		}
+	})
+}
+
+func TestWalletService_UpdateBalances(t *testing.T) {
+	repo := &stubWalletRepo{withdrawErr: models.ErrInsufficientBalance}
+	q := queue.NewQueue(repo, 50, 10*time.Millisecond)
+	go q.ProcessQueue(context.Background())
+	svc := NewWalletService(q, repo)
+
+	errs := svc.UpdateBalances(context.Background(), []models.Operation{
+		{WalletID: "id1", Type: "DEPOSIT", Amount: 100},
+		{WalletID: "id2", Type: "WITHDRAW", Amount: 50},
+	})
+
+	if len(errs) != 2 || errs[0] != nil || !errors.Is(errs[1], models.ErrInsufficientBalance) {
+		t.Errorf("unexpected results: %v", errs)
+	}
+}
+
+func TestWalletService_UpdateBalances_WalletOrder(t *testing.T) {
+	var funds int64
+	repo := &stubWalletRepo{funds: &funds}
+	q := queue.NewQueue(repo, 50, 10*time.Millisecond)
+	go q.ProcessQueue(context.Background())
+	svc := NewWalletService(q, repo)
+
+	// Списание идёт после зачисления того же кошелька и видит его
+	errs := svc.UpdateBalances(context.Background(), []models.Operation{
+		{WalletID: "id1", Type: "DEPOSIT", Amount: 100},
+		{WalletID: "id1", Type: "WITHDRAW", Amount: 60},
+	})
+
+	if errs[0] != nil || errs[1] != nil {
+		t.Fatalf("unexpected results: %v", errs)
+	}
+	if funds != 40 {
+		t.Errorf("got balance %d, want 40", funds)
+	}
+}
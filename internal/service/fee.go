@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"test-psql/internal/models"
+)
+
+// LoadFeeSchedule reads a JSON fee schedule from path.
+func LoadFeeSchedule(path string) (models.FeeSchedule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return models.FeeSchedule{}, fmt.Errorf("read fee schedule: %w", err)
+	}
+	return models.ParseFeeSchedule(data)
+}
+
+// SetFeeSchedule replaces the fee rules. It is meant to be called once at
+// startup, before the service handles requests.
+func (s *WalletService) SetFeeSchedule(fees models.FeeSchedule) {
+	s.fees = fees
+}
+
+// chargeFees computes the fees the schedule charges on op. Rules may be bound
+// to a currency, so an op without one is priced in the wallet currency.
+func (s *WalletService) chargeFees(ctx context.Context, op models.Operation) ([]models.FeeCharge, error) {
+	if !s.fees.Applies(op.Type) {
+		return nil, nil
+	}
+	currency := op.Currency
+	if currency == "" {
+		balance, err := s.repo.GetBalance(ctx, op.WalletID)
+		if err != nil {
+			return nil, err
+		}
+		currency = balance.Currency
+	}
+	return s.fees.Charges(op.Type, currency, op.Amount), nil
+}
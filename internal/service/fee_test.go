@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"test-psql/internal/models"
+	"test-psql/internal/queue"
+)
+
+func TestWalletService_UpdateBalance_Fees(t *testing.T) {
+	schedule, err := models.ParseFeeSchedule([]byte(`{"rules": [
+		{"name": "flat", "operation": "WITHDRAW", "currency": "RUB", "type": "flat", "amount": 30},
+		{"name": "usd_flat", "operation": "WITHDRAW", "currency": "USD", "type": "flat", "amount": 99},
+		{"name": "percent", "operation": "WITHDRAW", "type": "percent", "bps": 100, "min": 5}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newService := func(repo *stubWalletRepo) *WalletService {
+		q := queue.NewQueue(repo, 50, 10*time.Millisecond)
+		go q.ProcessQueue(context.Background())
+		svc := NewWalletService(q, repo)
+		svc.SetFeeSchedule(schedule)
+		return svc
+	}
+
+	t.Run("withdraw priced in wallet currency", func(t *testing.T) {
+		repo := &stubWalletRepo{}
+		fees, err := newService(repo).UpdateBalance(context.Background(), models.Operation{WalletID: "id1", Type: "WITHDRAW", Amount: 1000})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := []models.FeeCharge{{Rule: "flat", Amount: 30}, {Rule: "percent", Amount: 10}}
+		if len(fees) != 2 || fees[0] != want[0] || fees[1] != want[1] {
+			t.Errorf("got fees %v, want %v", fees, want)
+		}
+		if len(repo.withdrawOps) != 1 || models.TotalFees(repo.withdrawOps[0].Fees) != 40 {
+			t.Errorf("fees must be applied with the withdraw: %+v", repo.withdrawOps)
+		}
+	})
+
+	t.Run("deposit is free", func(t *testing.T) {
+		repo := &stubWalletRepo{}
+		fees, err := newService(repo).UpdateBalance(context.Background(), models.Operation{WalletID: "id1", Type: "DEPOSIT", Amount: 1000})
+		if err != nil || fees != nil {
+			t.Errorf("got fees %v, err %v", fees, err)
+		}
+	})
+
+	t.Run("failed withdraw reports no fees", func(t *testing.T) {
+		repo := &stubWalletRepo{withdrawErr: models.ErrInsufficientBalance}
+		fees, err := newService(repo).UpdateBalance(context.Background(), models.Operation{WalletID: "id1", Type: "WITHDRAW", Amount: 1000})
+		if !errors.Is(err, models.ErrInsufficientBalance) || fees != nil {
+			t.Errorf("got fees %v, err %v", fees, err)
+		}
+	})
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"test-psql/internal/models"
+	"test-psql/pkg/logger"
+)
+
+// DefaultQuoteTTL is how long a quote holds its rate unless SetQuoteTTL says
+// otherwise.
+const DefaultQuoteTTL = 30 * time.Second
+
+// LoadExchangeRates reads a JSON rates table from path.
+func LoadExchangeRates(path string) ([]models.ExchangeRate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read rates table: %w", err)
+	}
+	return models.ParseExchangeRates(data)
+}
+
+// SetQuoteTTL sets the lifetime of new quotes. It is meant to be called once
+// at startup, before the service handles requests.
+func (s *WalletService) SetQuoteTTL(ttl time.Duration) {
+	s.quoteTTL = ttl
+}
+
+func (s *WalletService) GetExchangeRates(ctx context.Context) ([]models.ExchangeRate, error) {
+	logger.Info("service GetExchangeRates")
+	return s.repo.GetExchangeRates(ctx)
+}
+
+// SetExchangeRates inserts or replaces the given rates; other pairs are kept.
+func (s *WalletService) SetExchangeRates(ctx context.Context, rates []models.ExchangeRate) ([]models.ExchangeRate, error) {
+	logger.Info(fmt.Sprintf("service SetExchangeRates count=%d", len(rates)))
+	seen := make(map[[2]string]bool, len(rates))
+	for i := range rates {
+		if err := rates[i].Validate(); err != nil {
+			return nil, err
+		}
+		pair := [2]string{rates[i].FromCurrency, rates[i].ToCurrency}
+		if seen[pair] {
+			return nil, fmt.Errorf("rate %s/%s is listed twice", pair[0], pair[1])
+		}
+		seen[pair] = true
+	}
+	return s.repo.SetExchangeRates(ctx, rates)
+}
+
+// CreateQuote prices the conversion of amount from one wallet's currency into
+// another's at the current rate and fixes that price for the quote TTL.
+func (s *WalletService) CreateQuote(ctx context.Context, fromWalletID, toWalletID string, amount int64) (models.FXQuote, error) {
+	logger.Info(fmt.Sprintf("service CreateQuote from=%s to=%s amount=%d", fromWalletID, toWalletID, amount))
+	if amount <= 0 {
+		return models.FXQuote{}, fmt.Errorf("amount must be positive")
+	}
+	if fromWalletID == toWalletID {
+		return models.FXQuote{}, models.ErrSameWalletTransfer
+	}
+	from, err := s.repo.GetWallet(ctx, fromWalletID)
+	if err != nil {
+		return models.FXQuote{}, err
+	}
+	to, err := s.repo.GetWallet(ctx, toWalletID)
+	if err != nil {
+		return models.FXQuote{}, err
+	}
+	for _, w := range []models.Wallet{from, to} {
+		if err := w.Status.Err(); err != nil {
+			return models.FXQuote{}, err
+		}
+	}
+	if from.Currency == to.Currency {
+		return models.FXQuote{}, models.ErrSameCurrency
+	}
+
+	rate, err := s.repo.GetExchangeRate(ctx, from.Currency, to.Currency)
+	if err != nil {
+		return models.FXQuote{}, err
+	}
+	converted, err := rate.Rate.Convert(amount, from.Currency, to.Currency)
+	if err != nil {
+		return models.FXQuote{}, err
+	}
+	if converted == 0 {
+		return models.FXQuote{}, models.ErrConversionTooSmall
+	}
+
+	ttl := s.quoteTTL
+	if ttl <= 0 {
+		ttl = DefaultQuoteTTL
+	}
+	return s.repo.CreateQuote(ctx, models.FXQuote{
+		FromWalletID:    from.ID,
+		ToWalletID:      to.ID,
+		FromCurrency:    from.Currency,
+		ToCurrency:      to.Currency,
+		Amount:          amount,
+		ConvertedAmount: converted,
+		Rate:            rate.Rate,
+		ExpiresAt:       time.Now().UTC().Add(ttl),
+	})
+}
+
+// ExecuteQuote performs the conversion priced by the quote. The balance and
+// the limits of both wallets are checked at execution, not when the quote is
+// issued, inside the transaction that locks the wallets.
+func (s *WalletService) ExecuteQuote(ctx context.Context, quoteID string) (models.FXQuote, error) {
+	logger.Info(fmt.Sprintf("service ExecuteQuote id=%s", quoteID))
+	return s.repo.ExecuteQuote(ctx, quoteID)
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"test-psql/internal/models"
+	"test-psql/internal/queue"
+)
+
+func TestWalletService_CreateQuote(t *testing.T) {
+	usd := models.Wallet{ID: uuid.New(), Currency: "USD", Status: models.WalletStatusActive}
+	rub := models.Wallet{ID: uuid.New(), Currency: "RUB", Status: models.WalletStatusActive}
+	newService := func(rate models.Rate, wallets ...models.Wallet) (*WalletService, *stubWalletRepo) {
+		repo := &stubWalletRepo{
+			walletsByID: make(map[string]models.Wallet),
+			rate:        models.ExchangeRate{FromCurrency: "RUB", ToCurrency: "USD", Rate: rate},
+		}
+		for _, w := range wallets {
+			repo.walletsByID[w.ID.String()] = w
+		}
+		return NewWalletService(queue.NewQueue(repo, 50, 100*time.Millisecond), repo), repo
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		svc, repo := newService("0.010846", rub, usd)
+		svc.SetQuoteTTL(time.Minute)
+		quote, err := svc.CreateQuote(context.Background(), rub.ID.String(), usd.ID.String(), 1000000)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if quote.FromCurrency != "RUB" || quote.ToCurrency != "USD" || quote.Rate != "0.010846" {
+			t.Errorf("unexpected quote: %+v", quote)
+		}
+		if quote.ConvertedAmount != 10846 || repo.gotQuote.ConvertedAmount != 10846 {
+			t.Errorf("got converted %d, want 10846", quote.ConvertedAmount)
+		}
+		if ttl := time.Until(quote.ExpiresAt); ttl < 50*time.Second || ttl > time.Minute {
+			t.Errorf("quote expires in %s, want about a minute", ttl)
+		}
+	})
+
+	t.Run("same currency", func(t *testing.T) {
+		other := models.Wallet{ID: uuid.New(), Currency: "RUB", Status: models.WalletStatusActive}
+		svc, _ := newService("1", rub, other)
+		_, err := svc.CreateQuote(context.Background(), rub.ID.String(), other.ID.String(), 100)
+		if !errors.Is(err, models.ErrSameCurrency) {
+			t.Errorf("want ErrSameCurrency, got %v", err)
+		}
+	})
+
+	t.Run("frozen wallet", func(t *testing.T) {
+		frozen := usd
+		frozen.Status = models.WalletStatusFrozen
+		svc, _ := newService("0.010846", rub, frozen)
+		_, err := svc.CreateQuote(context.Background(), rub.ID.String(), frozen.ID.String(), 100)
+		if !errors.Is(err, models.ErrWalletFrozen) {
+			t.Errorf("want ErrWalletFrozen, got %v", err)
+		}
+	})
+
+	t.Run("no rate", func(t *testing.T) {
+		svc, repo := newService("", rub, usd)
+		repo.rateErr = models.ErrRateNotFound
+		_, err := svc.CreateQuote(context.Background(), rub.ID.String(), usd.ID.String(), 100)
+		if !errors.Is(err, models.ErrRateNotFound) {
+			t.Errorf("want ErrRateNotFound, got %v", err)
+		}
+	})
+
+	t.Run("converts to zero", func(t *testing.T) {
+		svc, repo := newService("0.010846", rub, usd)
+		_, err := svc.CreateQuote(context.Background(), rub.ID.String(), usd.ID.String(), 50)
+		if !errors.Is(err, models.ErrConversionTooSmall) {
+			t.Errorf("want ErrConversionTooSmall, got %v", err)
+		}
+		if repo.gotQuote.Amount != 0 {
+			t.Error("quote must not be stored")
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"test-psql/internal/models"
+	"test-psql/pkg/logger"
+)
+
+// expireHoldsBatch caps how many holds one sweep releases.
+const expireHoldsBatch = 1000
+
+// CreateHold reserves amount on the wallet until ttl elapses. A hold is a
+// debit to come, so it counts against the withdrawal limits from the moment
+// it is created.
+func (s *WalletService) CreateHold(ctx context.Context, walletID string, amount int64, currency string, ttl time.Duration) (models.Hold, error) {
+	logger.Info(fmt.Sprintf("service CreateHold walletId=%s amount=%d ttl=%s", walletID, amount, ttl))
+	if amount <= 0 {
+		return models.Hold{}, fmt.Errorf("amount must be positive")
+	}
+	if ttl <= 0 {
+		return models.Hold{}, fmt.Errorf("ttl must be positive")
+	}
+	if err := validateCurrency(currency); err != nil {
+		return models.Hold{}, err
+	}
+	if err := s.checkWithdrawalLimits(ctx, walletID, 1, amount); err != nil {
+		return models.Hold{}, err
+	}
+	return s.repo.CreateHold(ctx, walletID, amount, currency, time.Now().UTC().Add(ttl))
+}
+
+// CaptureHold debits amount from the wallet and finalizes the hold. An amount
+// of 0 captures the whole hold.
+func (s *WalletService) CaptureHold(ctx context.Context, walletID, holdID string, amount int64) (models.Hold, error) {
+	logger.Info(fmt.Sprintf("service CaptureHold walletId=%s holdId=%s amount=%d", walletID, holdID, amount))
+	if amount < 0 {
+		return models.Hold{}, fmt.Errorf("amount must not be negative")
+	}
+	return s.repo.CaptureHold(ctx, walletID, holdID, amount)
+}
+
+func (s *WalletService) VoidHold(ctx context.Context, walletID, holdID string) (models.Hold, error) {
+	logger.Info(fmt.Sprintf("service VoidHold walletId=%s holdId=%s", walletID, holdID))
+	return s.repo.VoidHold(ctx, walletID, holdID)
+}
+
+// RunHoldExpiry releases expired holds every period until ctx is done.
+func (s *WalletService) RunHoldExpiry(ctx context.Context, period time.Duration) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.expireHolds(ctx)
+		}
+	}
+}
+
+func (s *WalletService) expireHolds(ctx context.Context) {
+	// Выпускаем партиями, пока истёкшие холды не закончатся
+	for {
+		n, err := s.repo.ExpireHolds(ctx, expireHoldsBatch)
+		if err != nil {
+			logger.Error(fmt.Sprintf("service expireHolds error: %v", err))
+			return
+		}
+		if n > 0 {
+			logger.Info(fmt.Sprintf("service expireHolds expired=%d", n))
+		}
+		if n < expireHoldsBatch {
+			return
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"test-psql/internal/queue"
+)
+
+func TestWalletService_CreateHold(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		repo := &stubWalletRepo{}
+		svc := NewWalletService(queue.NewQueue(repo, 50, 100*time.Millisecond), repo)
+		before := time.Now()
+		if _, err := svc.CreateHold(context.Background(), "id1", 100, "RUB", time.Hour); err != nil {
+			t.Fatal(err)
+		}
+		if repo.gotAmount != 100 {
+			t.Errorf("got amount %d, want 100", repo.gotAmount)
+		}
+		if repo.gotExpiresAt.Before(before.Add(time.Hour)) {
+			t.Errorf("expiresAt %s is earlier than now+ttl", repo.gotExpiresAt)
+		}
+	})
+
+	invalid := []struct {
+		name     string
+		amount   int64
+		currency string
+		ttl      time.Duration
+	}{
+		{"zero amount", 0, "", time.Hour},
+		{"zero ttl", 100, "", 0},
+		{"unknown currency", 100, "XXX", time.Hour},
+	}
+	for _, tc := range invalid {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &stubWalletRepo{}
+			svc := NewWalletService(queue.NewQueue(repo, 50, 100*time.Millisecond), repo)
+			if _, err := svc.CreateHold(context.Background(), "id1", tc.amount, tc.currency, tc.ttl); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestWalletService_CaptureHold(t *testing.T) {
+	repo := &stubWalletRepo{gotAmount: -1}
+	svc := NewWalletService(queue.NewQueue(repo, 50, 100*time.Millisecond), repo)
+	if _, err := svc.CaptureHold(context.Background(), "id1", "h1", -5); err == nil {
+		t.Error("expected error for negative amount")
+	}
+	if repo.gotAmount != -1 {
+		t.Error("repo called for negative amount")
+	}
+	if _, err := svc.CaptureHold(context.Background(), "id1", "h1", 0); err != nil {
+		t.Fatal(err)
+	}
+	if repo.gotAmount != 0 {
+		t.Errorf("got amount %d, want 0", repo.gotAmount)
+	}
+}
+
+func TestWalletService_ExpireHolds(t *testing.T) {
+	// Полная партия означает, что истёкшие холды могли остаться
+	repo := &stubWalletRepo{expireResults: []int{expireHoldsBatch, expireHoldsBatch, 3}}
+	svc := NewWalletService(queue.NewQueue(repo, 50, 100*time.Millisecond), repo)
+	svc.expireHolds(context.Background())
+	if repo.expireCalls != 3 {
+		t.Errorf("got %d ExpireHolds calls, want 3", repo.expireCalls)
+	}
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"test-psql/internal/models"
+	"test-psql/pkg/logger"
+)
+
+// CreateWallet creates an empty active wallet. An empty walletID lets the
+// server pick one; an empty currency means models.DefaultCurrency.
+func (s *WalletService) CreateWallet(ctx context.Context, walletID, currency string, meta models.WalletMetadata) (models.Wallet, error) {
+	logger.Info(fmt.Sprintf("service CreateWallet walletId=%s currency=%s", walletID, currency))
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+	id := uuid.New()
+	if walletID != "" {
+		var err error
+		if id, err = uuid.Parse(walletID); err != nil {
+			return models.Wallet{}, fmt.Errorf("walletId must be a UUID")
+		}
+	}
+	return s.repo.CreateWallet(ctx, id, currency, meta)
+}
+
+func (s *WalletService) GetWallet(ctx context.Context, walletID string) (models.Wallet, error) {
+	logger.Info(fmt.Sprintf("service GetWallet walletId=%s", walletID))
+	return s.repo.GetWallet(ctx, walletID)
+}
+
+func (s *WalletService) FreezeWallet(ctx context.Context, walletID string) (models.Wallet, error) {
+	logger.Info(fmt.Sprintf("service FreezeWallet walletId=%s", walletID))
+	return s.repo.SetStatus(ctx, walletID, models.WalletStatusFrozen)
+}
+
+func (s *WalletService) UnfreezeWallet(ctx context.Context, walletID string) (models.Wallet, error) {
+	logger.Info(fmt.Sprintf("service UnfreezeWallet walletId=%s", walletID))
+	return s.repo.SetStatus(ctx, walletID, models.WalletStatusActive)
+}
+
+func (s *WalletService) CloseWallet(ctx context.Context, walletID string) (models.Wallet, error) {
+	logger.Info(fmt.Sprintf("service CloseWallet walletId=%s", walletID))
+	return s.repo.SetStatus(ctx, walletID, models.WalletStatusClosed)
+}
+
+// SetCreditLimit sets how far the wallet balance may go below zero, in minor
+// units of the wallet currency.
+func (s *WalletService) SetCreditLimit(ctx context.Context, walletID string, limit int64) (models.Wallet, error) {
+	logger.Info(fmt.Sprintf("service SetCreditLimit walletId=%s limit=%d", walletID, limit))
+	if limit < 0 {
+		return models.Wallet{}, fmt.Errorf("credit limit must not be negative")
+	}
+	return s.repo.SetCreditLimit(ctx, walletID, limit)
+}
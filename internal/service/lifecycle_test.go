@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"test-psql/internal/models"
+	"test-psql/internal/queue"
+)
+
+func TestWalletService_CreateWallet(t *testing.T) {
+	t.Run("generated id", func(t *testing.T) {
+		repo := &stubWalletRepo{}
+		svc := NewWalletService(queue.NewQueue(repo, 50, 100*time.Millisecond), repo)
+		w, err := svc.CreateWallet(context.Background(), "", "", models.WalletMetadata{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if w.ID == uuid.Nil {
+			t.Error("wallet id not generated")
+		}
+		if w.Currency != models.DefaultCurrency {
+			t.Errorf("got currency %q, want %q", w.Currency, models.DefaultCurrency)
+		}
+	})
+
+	t.Run("client id", func(t *testing.T) {
+		repo := &stubWalletRepo{}
+		svc := NewWalletService(queue.NewQueue(repo, 50, 100*time.Millisecond), repo)
+		w, err := svc.CreateWallet(context.Background(), "550e8400-e29b-41d4-a716-446655440009", "USD", models.WalletMetadata{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if w.ID.String() != "550e8400-e29b-41d4-a716-446655440009" {
+			t.Errorf("got id %s", w.ID)
+		}
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		repo := &stubWalletRepo{}
+		svc := NewWalletService(queue.NewQueue(repo, 50, 100*time.Millisecond), repo)
+		if _, err := svc.CreateWallet(context.Background(), "id1", "", models.WalletMetadata{}); err == nil {
+			t.Error("expected error for invalid id")
+		}
+	})
+
+	t.Run("exists", func(t *testing.T) {
+		repo := &stubWalletRepo{walletErr: models.ErrWalletExists}
+		svc := NewWalletService(queue.NewQueue(repo, 50, 100*time.Millisecond), repo)
+		_, err := svc.CreateWallet(context.Background(), "", "", models.WalletMetadata{})
+		if !errors.Is(err, models.ErrWalletExists) {
+			t.Errorf("want ErrWalletExists, got %v", err)
+		}
+	})
+}
+
+func TestWalletService_ChangeStatus(t *testing.T) {
+	cases := []struct {
+		name   string
+		change func(svc *WalletService) (models.Wallet, error)
+		want   models.WalletStatus
+	}{
+		{"freeze", func(svc *WalletService) (models.Wallet, error) { return svc.FreezeWallet(context.Background(), "id1") }, models.WalletStatusFrozen},
+		{"unfreeze", func(svc *WalletService) (models.Wallet, error) {
+			return svc.UnfreezeWallet(context.Background(), "id1")
+		}, models.WalletStatusActive},
+		{"close", func(svc *WalletService) (models.Wallet, error) { return svc.CloseWallet(context.Background(), "id1") }, models.WalletStatusClosed},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &stubWalletRepo{}
+			svc := NewWalletService(queue.NewQueue(repo, 50, 100*time.Millisecond), repo)
+			if _, err := tc.change(svc); err != nil {
+				t.Fatal(err)
+			}
+			if repo.gotStatus != tc.want {
+				t.Errorf("got status %s, want %s", repo.gotStatus, tc.want)
+			}
+		})
+	}
+
+	t.Run("frozen wallet rejects operations", func(t *testing.T) {
+		repo := &stubWalletRepo{depositErr: models.ErrWalletFrozen}
+		q := queue.NewQueue(repo, 50, 100*time.Millisecond)
+		go q.ProcessQueue(context.Background())
+		svc := NewWalletService(q, repo)
+		_, err := svc.UpdateBalance(context.Background(), models.Operation{WalletID: "id1", Type: "DEPOSIT", Amount: 100})
+		if !errors.Is(err, models.ErrWalletFrozen) {
+			t.Errorf("want ErrWalletFrozen, got %v", err)
+		}
+	})
+}
+
+func TestWalletService_SetCreditLimit(t *testing.T) {
+	repo := &stubWalletRepo{gotLimit: -1}
+	svc := NewWalletService(queue.NewQueue(repo, 50, 100*time.Millisecond), repo)
+	if _, err := svc.SetCreditLimit(context.Background(), "id1", -100); err == nil {
+		t.Error("expected error for negative limit")
+	}
+	if repo.gotLimit != -1 {
+		t.Error("repo called for negative limit")
+	}
+	if _, err := svc.SetCreditLimit(context.Background(), "id1", 100); err != nil {
+		t.Fatal(err)
+	}
+	if repo.gotLimit != 100 {
+		t.Errorf("got limit %d, want 100", repo.gotLimit)
+	}
+}
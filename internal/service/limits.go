@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"test-psql/internal/models"
+	"test-psql/pkg/logger"
+)
+
+// GetWalletLimits returns the limit rules of an existing wallet.
+func (s *WalletService) GetWalletLimits(ctx context.Context, walletID string) (models.WalletLimits, error) {
+	logger.Info(fmt.Sprintf("service GetWalletLimits walletId=%s", walletID))
+	// Кошелёк без правил не отличить от несуществующего по wallet_limits
+	if _, err := s.repo.GetWallet(ctx, walletID); err != nil {
+		return models.WalletLimits{}, err
+	}
+	return s.repo.GetWalletLimits(ctx, walletID)
+}
+
+// SetWalletLimits replaces all limit rules of the wallet; a nil rule removes
+// it.
+func (s *WalletService) SetWalletLimits(ctx context.Context, limits models.WalletLimits) (models.WalletLimits, error) {
+	logger.Info(fmt.Sprintf("service SetWalletLimits walletId=%s", limits.WalletID))
+	if limits.WalletID == uuid.Nil {
+		return models.WalletLimits{}, models.ErrWalletNotFound
+	}
+	for _, v := range []*int64{limits.MaxWithdrawalAmount24h, limits.MaxWithdrawalsPerHour, limits.MaxBalance} {
+		if v != nil && *v < 0 {
+			return models.WalletLimits{}, fmt.Errorf("limits must not be negative")
+		}
+	}
+	return s.repo.SetWalletLimits(ctx, limits)
+}
+
+// checkLimits rejects op with ErrLimitExceeded if it would break one of the
+// rules of a wallet it touches: a transfer is a debit of the source wallet
+// and a credit of the destination. The check runs before the op is queued,
+// so concurrent ops on one wallet can each pass it and together overshoot a
+// limit by the ops in flight.
+func (s *WalletService) checkLimits(ctx context.Context, op models.Operation) error {
+	switch op.Type {
+	case "WITHDRAW":
+		return s.checkWithdrawalLimits(ctx, op.WalletID, 1, op.Amount)
+	case "DEPOSIT":
+		return s.checkMaxBalance(ctx, op.WalletID, op.Amount)
+	case "TRANSFER":
+		if err := s.checkWithdrawalLimits(ctx, op.WalletID, 1, op.Amount); err != nil {
+			return err
+		}
+		return s.checkMaxBalance(ctx, op.ToWalletID, op.Amount)
+	}
+	return nil
+}
+
+// checkWithdrawalLimits rejects count debits of amount in total from the
+// wallet if, together with its recent debits, they break a velocity rule.
+func (s *WalletService) checkWithdrawalLimits(ctx context.Context, walletID string, count, amount int64) error {
+	limits, err := s.repo.GetWalletLimits(ctx, walletID)
+	if err != nil || (limits.MaxWithdrawalAmount24h == nil && limits.MaxWithdrawalsPerHour == nil) {
+		return err
+	}
+	usage, err := s.repo.GetWithdrawalUsage(ctx, walletID)
+	if err != nil {
+		return err
+	}
+	if limit := limits.MaxWithdrawalAmount24h; limit != nil && usage.Amount24h+amount > *limit {
+		return models.LimitExceeded(models.LimitRuleMaxWithdrawalAmount24h, *limit)
+	}
+	if limit := limits.MaxWithdrawalsPerHour; limit != nil && usage.Count1h+count > *limit {
+		return models.LimitExceeded(models.LimitRuleMaxWithdrawalsPerHour, *limit)
+	}
+	return nil
+}
+
+// checkMaxBalance rejects crediting amount to the wallet if its balance would
+// go over the max_balance rule.
+func (s *WalletService) checkMaxBalance(ctx context.Context, walletID string, amount int64) error {
+	limits, err := s.repo.GetWalletLimits(ctx, walletID)
+	if err != nil || limits.MaxBalance == nil {
+		return err
+	}
+	balance, err := s.repo.GetBalance(ctx, walletID)
+	if err != nil {
+		return err
+	}
+	if limit := limits.MaxBalance; balance.Balance+amount > *limit {
+		return models.LimitExceeded(models.LimitRuleMaxBalance, *limit)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"test-psql/internal/models"
+	"test-psql/internal/queue"
+)
+
+func ptr(v int64) *int64 { return &v }
+
+func TestWalletService_UpdateBalance_Limits(t *testing.T) {
+	cases := []struct {
+		name     string
+		limits   models.WalletLimits
+		usage    models.WithdrawalUsage
+		balance  int64
+		op       models.Operation
+		wantRule string
+	}{
+		{
+			name:     "withdrawn amount over 24h",
+			limits:   models.WalletLimits{MaxWithdrawalAmount24h: ptr(50000)},
+			usage:    models.WithdrawalUsage{Amount24h: 49900},
+			op:       models.Operation{WalletID: "id1", Type: "WITHDRAW", Amount: 200},
+			wantRule: models.LimitRuleMaxWithdrawalAmount24h,
+		},
+		{
+			name:     "withdrawals per hour",
+			limits:   models.WalletLimits{MaxWithdrawalsPerHour: ptr(20)},
+			usage:    models.WithdrawalUsage{Count1h: 20},
+			op:       models.Operation{WalletID: "id1", Type: "WITHDRAW", Amount: 1},
+			wantRule: models.LimitRuleMaxWithdrawalsPerHour,
+		},
+		{
+			name:     "max balance",
+			limits:   models.WalletLimits{MaxBalance: ptr(1000)},
+			balance:  900,
+			op:       models.Operation{WalletID: "id1", Type: "DEPOSIT", Amount: 101},
+			wantRule: models.LimitRuleMaxBalance,
+		},
+		{
+			name:    "within limits",
+			limits:  models.WalletLimits{MaxWithdrawalAmount24h: ptr(50000), MaxWithdrawalsPerHour: ptr(20), MaxBalance: ptr(1000)},
+			usage:   models.WithdrawalUsage{Amount24h: 49800, Count1h: 19},
+			balance: 900,
+			op:      models.Operation{WalletID: "id1", Type: "WITHDRAW", Amount: 200},
+		},
+		{
+			name:    "deposit up to max balance",
+			limits:  models.WalletLimits{MaxBalance: ptr(1000)},
+			balance: 900,
+			op:      models.Operation{WalletID: "id1", Type: "DEPOSIT", Amount: 100},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &stubWalletRepo{limits: tc.limits, usage: tc.usage, getBalanceVal: tc.balance}
+			q := queue.NewQueue(repo, 50, 10*time.Millisecond)
+			go q.ProcessQueue(context.Background())
+			svc := NewWalletService(q, repo)
+
+			_, err := svc.UpdateBalance(context.Background(), tc.op)
+
+			if tc.wantRule == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, models.ErrLimitExceeded) {
+				t.Fatalf("want ErrLimitExceeded, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tc.wantRule) {
+				t.Errorf("error %q does not name rule %s", err, tc.wantRule)
+			}
+			if len(repo.depositOps) != 0 {
+				t.Error("rejected op reached the queue")
+			}
+		})
+	}
+
+	t.Run("no limits skips usage query", func(t *testing.T) {
+		repo := &stubWalletRepo{}
+		q := queue.NewQueue(repo, 50, 10*time.Millisecond)
+		go q.ProcessQueue(context.Background())
+		svc := NewWalletService(q, repo)
+		if _, err := svc.UpdateBalance(context.Background(), models.Operation{WalletID: "id1", Type: "WITHDRAW", Amount: 1}); err != nil {
+			t.Fatal(err)
+		}
+		if repo.usageCalls != 0 {
+			t.Errorf("got %d usage queries, want 0", repo.usageCalls)
+		}
+	})
+}
+
+func TestWalletService_OtherDebits_Limits(t *testing.T) {
+	from, to := uuid.New(), uuid.New()
+
+	t.Run("transfer counts as a withdrawal", func(t *testing.T) {
+		repo := &stubWalletRepo{
+			limits: models.WalletLimits{MaxWithdrawalsPerHour: ptr(2)},
+			usage:  models.WithdrawalUsage{Count1h: 2},
+		}
+		q := queue.NewQueue(repo, 50, 10*time.Millisecond)
+		go q.ProcessQueue(context.Background())
+		svc := NewWalletService(q, repo)
+
+		_, err := svc.Transfer(context.Background(), models.Operation{WalletID: from.String(), ToWalletID: to.String(), Amount: 1})
+
+		if !errors.Is(err, models.ErrLimitExceeded) {
+			t.Fatalf("want ErrLimitExceeded, got %v", err)
+		}
+		if len(repo.transferOps) != 0 {
+			t.Error("rejected transfer reached the queue")
+		}
+	})
+
+	t.Run("transfer over the recipient max balance", func(t *testing.T) {
+		repo := &stubWalletRepo{limits: models.WalletLimits{MaxBalance: ptr(1000)}, getBalanceVal: 900}
+		svc := NewWalletService(queue.NewQueue(repo, 50, 10*time.Millisecond), repo)
+
+		_, err := svc.Transfer(context.Background(), models.Operation{WalletID: from.String(), ToWalletID: to.String(), Amount: 101})
+
+		if err == nil || !strings.Contains(err.Error(), models.LimitRuleMaxBalance) {
+			t.Fatalf("want max balance error, got %v", err)
+		}
+	})
+
+	t.Run("hold counts as a withdrawal", func(t *testing.T) {
+		repo := &stubWalletRepo{
+			limits: models.WalletLimits{MaxWithdrawalAmount24h: ptr(50000)},
+			usage:  models.WithdrawalUsage{Amount24h: 49900},
+		}
+		svc := NewWalletService(queue.NewQueue(repo, 50, 10*time.Millisecond), repo)
+
+		_, err := svc.CreateHold(context.Background(), from.String(), 200, "", time.Hour)
+
+		if !errors.Is(err, models.ErrLimitExceeded) {
+			t.Fatalf("want ErrLimitExceeded, got %v", err)
+		}
+		if repo.gotAmount != 0 {
+			t.Error("rejected hold reached the repo")
+		}
+	})
+
+}
+
+func TestWalletService_SetWalletLimits(t *testing.T) {
+	repo := &stubWalletRepo{}
+	svc := NewWalletService(queue.NewQueue(repo, 50, 100*time.Millisecond), repo)
+	id := uuid.New()
+
+	if _, err := svc.SetWalletLimits(context.Background(), models.WalletLimits{WalletID: id, MaxBalance: ptr(-1)}); err == nil {
+		t.Error("expected error for negative limit")
+	}
+	if _, err := svc.SetWalletLimits(context.Background(), models.WalletLimits{WalletID: id, MaxBalance: ptr(10)}); err != nil {
+		t.Fatal(err)
+	}
+	if repo.gotLimits.WalletID != id || *repo.gotLimits.MaxBalance != 10 {
+		t.Errorf("unexpected limits: %+v", repo.gotLimits)
+	}
+}
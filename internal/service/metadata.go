@@ -0,0 +1,27 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"test-psql/internal/models"
+	"test-psql/pkg/logger"
+)
+
+// SetWalletMetadata replaces the owner, name and labels of the wallet.
+func (s *WalletService) SetWalletMetadata(ctx context.Context, walletID string, meta models.WalletMetadata) (models.Wallet, error) {
+	logger.Info(fmt.Sprintf("service SetWalletMetadata walletId=%s", walletID))
+	return s.repo.SetWalletMetadata(ctx, walletID, meta)
+}
+
+// ListWallets returns one page of wallets matching q.
+func (s *WalletService) ListWallets(ctx context.Context, q models.WalletListQuery) ([]models.Wallet, error) {
+	logger.Info(fmt.Sprintf("service ListWallets ownerId=%s limit=%d", q.OwnerID, q.Limit))
+	if q.Sort != models.WalletSortCreatedAt && q.Sort != models.WalletSortBalance {
+		return nil, fmt.Errorf("unknown sort: %s", q.Sort)
+	}
+	if q.Limit <= 0 {
+		return nil, fmt.Errorf("limit must be positive")
+	}
+	return s.repo.ListWallets(ctx, q)
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"test-psql/internal/models"
+	"test-psql/internal/queue"
+)
+
+func TestWalletService_ListWallets(t *testing.T) {
+	repo := &stubWalletRepo{}
+	svc := NewWalletService(queue.NewQueue(repo, 50, 100*time.Millisecond), repo)
+
+	q := models.WalletListQuery{OwnerID: "c1", Sort: models.WalletSortBalance, Limit: 10}
+	if _, err := svc.ListWallets(context.Background(), q); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.gotQuery.OwnerID != "c1" {
+		t.Errorf("query not passed to repo: %+v", repo.gotQuery)
+	}
+
+	for _, bad := range []models.WalletListQuery{
+		{Sort: "name", Limit: 10},
+		{Sort: models.WalletSortCreatedAt},
+	} {
+		if _, err := svc.ListWallets(context.Background(), bad); err == nil {
+			t.Errorf("want error for %+v", bad)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"test-psql/internal/models"
+	"test-psql/pkg/logger"
+)
+
+// ReverseOperation undoes amount of a DEPOSIT or WITHDRAW with a linked
+// compensating ledger row. An amount of 0 reverses whatever is left.
+func (s *WalletService) ReverseOperation(ctx context.Context, operationID string, amount int64) (models.Transaction, error) {
+	logger.Info(fmt.Sprintf("service ReverseOperation operationId=%s amount=%d", operationID, amount))
+	if amount < 0 {
+		return models.Transaction{}, fmt.Errorf("amount must not be negative")
+	}
+	return s.repo.ReverseOperation(ctx, operationID, amount)
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"test-psql/internal/models"
+	"test-psql/internal/queue"
+)
+
+func TestWalletService_ReverseOperation(t *testing.T) {
+	t.Run("negative amount", func(t *testing.T) {
+		repo := &stubWalletRepo{gotAmount: -100}
+		svc := NewWalletService(queue.NewQueue(repo, 50, 100*time.Millisecond), repo)
+		if _, err := svc.ReverseOperation(context.Background(), "id1", -5); err == nil {
+			t.Error("want error")
+		}
+		if repo.gotAmount != -100 {
+			t.Error("repo must not be called")
+		}
+	})
+
+	t.Run("repo error", func(t *testing.T) {
+		repo := &stubWalletRepo{reversalErr: models.ErrAlreadyReversed}
+		svc := NewWalletService(queue.NewQueue(repo, 50, 100*time.Millisecond), repo)
+		_, err := svc.ReverseOperation(context.Background(), "id1", 0)
+		if !errors.Is(err, models.ErrAlreadyReversed) {
+			t.Errorf("want ErrAlreadyReversed, got %v", err)
+		}
+	})
+}
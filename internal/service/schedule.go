@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"test-psql/internal/models"
+	"test-psql/pkg/logger"
+)
+
+// runSchedulesBatch caps how many schedules one scheduler tick runs.
+const runSchedulesBatch = 1000
+
+// runScheduleWorkers caps how many runs of a tick are submitted at once. Each
+// of them holds its schedule row and a database connection until its
+// operation is applied.
+const runScheduleWorkers = 8
+
+// CreateSchedule validates s and stores it as ACTIVE. A one-off schedule runs
+// at runAt; a recurring one (s.Cron set) first runs at the next match of its
+// spec.
+func (s *WalletService) CreateSchedule(ctx context.Context, sched models.Schedule, runAt time.Time) (models.Schedule, error) {
+	logger.Info(fmt.Sprintf("service CreateSchedule walletId=%s type=%s amount=%d cron=%q", sched.WalletID, sched.OperationType, sched.Amount, sched.Cron))
+	if sched.Amount <= 0 {
+		return models.Schedule{}, fmt.Errorf("amount must be positive")
+	}
+	if err := validateCurrency(sched.Currency); err != nil {
+		return models.Schedule{}, err
+	}
+
+	wallets := []string{sched.WalletID.String()}
+	switch sched.OperationType {
+	case "DEPOSIT":
+		sched.ToWalletID = nil
+	case "TRANSFER":
+		if sched.ToWalletID == nil {
+			return models.Schedule{}, fmt.Errorf("toWalletId is required for a transfer")
+		}
+		if *sched.ToWalletID == sched.WalletID {
+			return models.Schedule{}, models.ErrSameWalletTransfer
+		}
+		wallets = append(wallets, sched.ToWalletID.String())
+	default:
+		return models.Schedule{}, fmt.Errorf("unknown operation type: %s", sched.OperationType)
+	}
+	for _, id := range wallets {
+		w, err := s.repo.GetWallet(ctx, id)
+		if err != nil {
+			return models.Schedule{}, err
+		}
+		if w.Status == models.WalletStatusClosed {
+			return models.Schedule{}, models.ErrWalletClosed
+		}
+	}
+
+	now := time.Now().UTC()
+	if sched.Cron != "" {
+		c, err := models.ParseCron(sched.Cron)
+		if err != nil {
+			return models.Schedule{}, err
+		}
+		next, ok := c.Next(now)
+		if !ok {
+			return models.Schedule{}, fmt.Errorf("cron spec %q never matches", sched.Cron)
+		}
+		runAt = next
+	} else if !runAt.After(now) {
+		return models.Schedule{}, fmt.Errorf("runAt must be in the future")
+	}
+	runAt = runAt.UTC()
+	sched.NextRunAt = &runAt
+	sched.Status = models.ScheduleStatusActive
+	return s.repo.CreateSchedule(ctx, sched)
+}
+
+func (s *WalletService) GetSchedule(ctx context.Context, scheduleID string) (models.Schedule, error) {
+	logger.Info(fmt.Sprintf("service GetSchedule id=%s", scheduleID))
+	return s.repo.GetSchedule(ctx, scheduleID)
+}
+
+func (s *WalletService) ListSchedules(ctx context.Context, walletID string, limit, offset int) ([]models.Schedule, error) {
+	logger.Info(fmt.Sprintf("service ListSchedules walletId=%s limit=%d offset=%d", walletID, limit, offset))
+	return s.repo.ListSchedules(ctx, walletID, limit, offset)
+}
+
+func (s *WalletService) PauseSchedule(ctx context.Context, scheduleID string) (models.Schedule, error) {
+	logger.Info(fmt.Sprintf("service PauseSchedule id=%s", scheduleID))
+	return s.repo.SetScheduleStatus(ctx, scheduleID, models.ScheduleStatusPaused)
+}
+
+func (s *WalletService) ResumeSchedule(ctx context.Context, scheduleID string) (models.Schedule, error) {
+	logger.Info(fmt.Sprintf("service ResumeSchedule id=%s", scheduleID))
+	return s.repo.SetScheduleStatus(ctx, scheduleID, models.ScheduleStatusActive)
+}
+
+func (s *WalletService) CancelSchedule(ctx context.Context, scheduleID string) (models.Schedule, error) {
+	logger.Info(fmt.Sprintf("service CancelSchedule id=%s", scheduleID))
+	return s.repo.SetScheduleStatus(ctx, scheduleID, models.ScheduleStatusCancelled)
+}
+
+// RunScheduler runs due schedules every period until ctx is done.
+func (s *WalletService) RunScheduler(ctx context.Context, period time.Duration) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.runDueSchedules(ctx)
+		}
+	}
+}
+
+// runDueSchedules runs the schedules due now with runScheduleWorkers
+// workers. Each worker takes the next unlocked due schedule, so only the
+// schedules being run are locked, and a run waiting for its flush does not
+// hold up the others.
+func (s *WalletService) runDueSchedules(ctx context.Context) {
+	// Все запуски тика считаются от одного момента
+	now := time.Now().UTC()
+	var left atomic.Int64
+	left.Store(runSchedulesBatch)
+	var wg sync.WaitGroup
+	for range runScheduleWorkers {
+		wg.Go(func() {
+			for left.Add(-1) >= 0 {
+				sched, ran, err := s.repo.RunDueSchedule(ctx, now, s.runScheduledOperation)
+				if err != nil {
+					logger.Error(fmt.Sprintf("service runDueSchedules error: %v", err))
+					return
+				}
+				if !ran {
+					return
+				}
+				if sched.LastError != "" {
+					logger.Error(fmt.Sprintf("service runDueSchedules id=%s failed: %s", sched.ID, sched.LastError))
+					continue
+				}
+				logger.Info(fmt.Sprintf("service runDueSchedules id=%s operationId=%s", sched.ID, sched.LastOperationID))
+			}
+		})
+	}
+	wg.Wait()
+}
+
+// runScheduledOperation submits one run of a schedule the way a client
+// request is submitted: through the queue, with the wallet limits and fees.
+func (s *WalletService) runScheduledOperation(ctx context.Context, op models.Operation) error {
+	switch op.Type {
+	case "DEPOSIT":
+		_, err := s.UpdateBalance(ctx, op)
+		return err
+	case "TRANSFER":
+		_, err := s.Transfer(ctx, op)
+		return err
+	}
+	return fmt.Errorf("unknown operation type: %s", op.Type)
+}
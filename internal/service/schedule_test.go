@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"test-psql/internal/models"
+	"test-psql/internal/queue"
+)
+
+func TestWalletService_CreateSchedule(t *testing.T) {
+	newService := func() (*WalletService, *stubWalletRepo) {
+		repo := &stubWalletRepo{}
+		return NewWalletService(queue.NewQueue(repo, 50, 100*time.Millisecond), repo), repo
+	}
+	walletID, toWalletID := uuid.New(), uuid.New()
+
+	t.Run("recurring", func(t *testing.T) {
+		svc, repo := newService()
+		_, err := svc.CreateSchedule(context.Background(), models.Schedule{
+			WalletID:      walletID,
+			ToWalletID:    &toWalletID,
+			OperationType: "TRANSFER",
+			Amount:        500000,
+			Cron:          "@monthly",
+		}, time.Time{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got := repo.gotSchedule
+		if got.Status != models.ScheduleStatusActive || got.NextRunAt == nil {
+			t.Fatalf("unexpected schedule: %+v", got)
+		}
+		if next := got.NextRunAt; next.Day() != 1 || next.Hour() != 0 || !next.After(time.Now()) {
+			t.Errorf("first run at %s, want the start of next month", next)
+		}
+	})
+
+	t.Run("one-off", func(t *testing.T) {
+		svc, repo := newService()
+		runAt := time.Now().Add(time.Hour)
+		_, err := svc.CreateSchedule(context.Background(), models.Schedule{
+			WalletID:      walletID,
+			ToWalletID:    &toWalletID,
+			OperationType: "DEPOSIT",
+			Amount:        1000,
+		}, runAt)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := repo.gotSchedule; !got.NextRunAt.Equal(runAt) || got.ToWalletID != nil {
+			t.Errorf("unexpected schedule: %+v", got)
+		}
+	})
+
+	invalid := map[string]models.Schedule{
+		"same wallet":   {WalletID: walletID, ToWalletID: &walletID, OperationType: "TRANSFER", Amount: 1, Cron: "@daily"},
+		"no recipient":  {WalletID: walletID, OperationType: "TRANSFER", Amount: 1, Cron: "@daily"},
+		"withdraw":      {WalletID: walletID, OperationType: "WITHDRAW", Amount: 1, Cron: "@daily"},
+		"zero amount":   {WalletID: walletID, OperationType: "DEPOSIT", Cron: "@daily"},
+		"bad cron":      {WalletID: walletID, OperationType: "DEPOSIT", Amount: 1, Cron: "@often"},
+		"never matches": {WalletID: walletID, OperationType: "DEPOSIT", Amount: 1, Cron: "0 0 30 2 *"},
+		"in the past":   {WalletID: walletID, OperationType: "DEPOSIT", Amount: 1},
+	}
+	for name, sched := range invalid {
+		t.Run(name, func(t *testing.T) {
+			svc, repo := newService()
+			if _, err := svc.CreateSchedule(context.Background(), sched, time.Now().Add(-time.Minute)); err == nil {
+				t.Error("want error")
+			}
+			if repo.gotSchedule.OperationType != "" {
+				t.Error("schedule must not be stored")
+			}
+		})
+	}
+
+	t.Run("unknown wallet", func(t *testing.T) {
+		svc, repo := newService()
+		repo.walletErr = models.ErrWalletNotFound
+		_, err := svc.CreateSchedule(context.Background(), models.Schedule{
+			WalletID: walletID, OperationType: "DEPOSIT", Amount: 1, Cron: "@daily",
+		}, time.Time{})
+		if !errors.Is(err, models.ErrWalletNotFound) {
+			t.Errorf("want ErrWalletNotFound, got %v", err)
+		}
+	})
+
+	t.Run("closed wallet", func(t *testing.T) {
+		svc, repo := newService()
+		repo.wallet = models.Wallet{ID: walletID, Status: models.WalletStatusClosed}
+		_, err := svc.CreateSchedule(context.Background(), models.Schedule{
+			WalletID: walletID, OperationType: "DEPOSIT", Amount: 1, Cron: "@daily",
+		}, time.Time{})
+		if !errors.Is(err, models.ErrWalletClosed) {
+			t.Errorf("want ErrWalletClosed, got %v", err)
+		}
+		if repo.gotSchedule.OperationType != "" {
+			t.Error("schedule must not be stored")
+		}
+	})
+}
+
+func TestWalletService_RunDueSchedules(t *testing.T) {
+	repo := &stubWalletRepo{dueRuns: []models.Schedule{
+		{ID: uuid.New()},
+		{ID: uuid.New(), LastError: models.ErrInsufficientBalance.Error()},
+		{ID: uuid.New()},
+	}}
+	svc := NewWalletService(queue.NewQueue(repo, 50, 100*time.Millisecond), repo)
+
+	svc.runDueSchedules(context.Background())
+
+	// Три запуска и по одному пустому вызову на обработчик; ошибка
+	// запуска не останавливает тик
+	if want := 3 + runScheduleWorkers; len(repo.gotNow) != want {
+		t.Fatalf("got %d calls, want %d", len(repo.gotNow), want)
+	}
+	for _, now := range repo.gotNow {
+		if !now.Equal(repo.gotNow[0]) {
+			t.Error("all runs of a tick must share one moment")
+		}
+	}
+}
+
+func TestWalletService_RunDueSchedules_Concurrent(t *testing.T) {
+	repo := &stubWalletRepo{
+		dueRuns:    []models.Schedule{{ID: uuid.New()}, {ID: uuid.New()}, {ID: uuid.New()}},
+		runRelease: make(chan struct{}),
+	}
+	svc := NewWalletService(queue.NewQueue(repo, 50, 100*time.Millisecond), repo)
+
+	done := make(chan struct{})
+	go func() {
+		svc.runDueSchedules(context.Background())
+		close(done)
+	}()
+
+	// Все три запуска начинаются, не дожидаясь друг друга
+	deadline := time.Now().Add(time.Second)
+	for {
+		repo.mu.Lock()
+		running := repo.running
+		repo.mu.Unlock()
+		if running == 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d runs at once, want 3", running)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(repo.runRelease)
+	<-done
+}
+
+func TestWalletService_RunDueSchedules_ThroughQueue(t *testing.T) {
+	walletID, toWalletID := uuid.New(), uuid.New()
+	deposit := models.Schedule{ID: uuid.New(), WalletID: walletID, OperationType: "DEPOSIT", Amount: 100, RunCount: 2}
+	transfer := models.Schedule{ID: uuid.New(), WalletID: walletID, ToWalletID: &toWalletID, OperationType: "TRANSFER", Amount: 50}
+	repo := &stubWalletRepo{dueRuns: []models.Schedule{deposit, transfer}}
+	q := queue.NewQueue(repo, 50, 10*time.Millisecond)
+	go q.ProcessQueue(context.Background())
+	svc := NewWalletService(q, repo)
+
+	svc.runDueSchedules(context.Background())
+
+	if len(repo.depositOps) != 1 || len(repo.transferOps) != 1 {
+		t.Fatalf("got %d deposits and %d transfers through the queue, want 1 and 1", len(repo.depositOps), len(repo.transferOps))
+	}
+	op := repo.depositOps[0]
+	if op.IdempotencyKey != deposit.Operation().IdempotencyKey || op.ID != deposit.Operation().ID {
+		t.Errorf("run is not idempotent: %+v", op)
+	}
+	if op.IdempotencyKey == transfer.Operation().IdempotencyKey {
+		t.Error("runs of different schedules share a key")
+	}
+}
+
+func TestWalletService_RunDueSchedules_Limits(t *testing.T) {
+	walletID := uuid.New()
+	repo := &stubWalletRepo{
+		limits:        models.WalletLimits{MaxBalance: ptr(1000)},
+		getBalanceVal: 950,
+		dueRuns:       []models.Schedule{{ID: uuid.New(), WalletID: walletID, OperationType: "DEPOSIT", Amount: 100}},
+	}
+	svc := NewWalletService(queue.NewQueue(repo, 50, 10*time.Millisecond), repo)
+
+	svc.runDueSchedules(context.Background())
+
+	if len(repo.depositOps) != 0 {
+		t.Error("deposit over the limit reached the repo")
+	}
+}
+
+func TestWalletService_ScheduleStatus(t *testing.T) {
+	repo := &stubWalletRepo{}
+	svc := NewWalletService(queue.NewQueue(repo, 50, 100*time.Millisecond), repo)
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		change func(context.Context, string) (models.Schedule, error)
+		want   models.ScheduleStatus
+	}{
+		{svc.PauseSchedule, models.ScheduleStatusPaused},
+		{svc.ResumeSchedule, models.ScheduleStatusActive},
+		{svc.CancelSchedule, models.ScheduleStatusCancelled},
+	} {
+		if _, err := tc.change(ctx, "id1"); err != nil {
+			t.Fatal(err)
+		}
+		if repo.gotStatusSet != tc.want {
+			t.Errorf("got status %s, want %s", repo.gotStatusSet, tc.want)
+		}
+	}
+}
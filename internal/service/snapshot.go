@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"test-psql/pkg/logger"
+)
+
+// balanceSnapshotLag keeps snapshots behind the present by more than any
+// operation transaction lasts, so every posting a snapshot covers is
+// already committed when it is taken.
+const balanceSnapshotLag = 5 * time.Minute
+
+// RunBalanceSnapshots snapshots wallet balances every period until ctx is
+// done, so GetBalanceAt reads at most one period of postings.
+func (s *WalletService) RunBalanceSnapshots(ctx context.Context, period time.Duration) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.takeBalanceSnapshots(ctx)
+		}
+	}
+}
+
+func (s *WalletService) takeBalanceSnapshots(ctx context.Context) {
+	n, err := s.repo.TakeBalanceSnapshots(ctx, time.Now().UTC().Add(-balanceSnapshotLag))
+	if err != nil {
+		logger.Error(fmt.Sprintf("service takeBalanceSnapshots error: %v", err))
+		return
+	}
+	if n > 0 {
+		logger.Info(fmt.Sprintf("service takeBalanceSnapshots wallets=%d", n))
+	}
+}
@@ -0,0 +1,22 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"test-psql/internal/models"
+	"test-psql/pkg/logger"
+)
+
+// StreamStatement passes the statement header of the wallet for [from, to) to
+// open and then every ledger row of the period to row, oldest first. It
+// returns the header with the closing balance filled in.
+func (s *WalletService) StreamStatement(ctx context.Context, walletID string, from, to time.Time,
+	open func(models.Statement) error, row func(models.Transaction) error) (models.Statement, error) {
+	logger.Info(fmt.Sprintf("service StreamStatement walletId=%s", walletID))
+	if !from.Before(to) {
+		return models.Statement{}, fmt.Errorf("from must be before to")
+	}
+	return s.repo.StreamStatement(ctx, walletID, from, to, open, row)
+}
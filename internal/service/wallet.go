@@ -4,44 +4,176 @@ package service
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/google/uuid"
+
+	"test-psql/internal/models"
 	"test-psql/internal/queue"
 	"test-psql/pkg/logger"
 )
 
 type walletRepo interface {
-	GetBalance(ctx context.Context, walletID string) (int64, error)
-	Deposit(ctx context.Context, walletID string, amount int64) error
-	Withdraw(ctx context.Context, walletID string, amount int64) error
+	GetBalance(ctx context.Context, walletID string) (models.Balance, error)
+	GetBalanceAt(ctx context.Context, walletID string, at time.Time) (models.Balance, error)
+	Deposit(ctx context.Context, walletID string, ops []models.Operation) ([]models.Transaction, error)
+	Withdraw(ctx context.Context, walletID string, ops []models.Operation) ([]models.Transaction, error)
+	Transfer(ctx context.Context, op models.Operation) ([]models.Transaction, error)
+	GetTransactions(ctx context.Context, walletID string, limit, offset int) ([]models.Transaction, error)
+	StreamStatement(ctx context.Context, walletID string, from, to time.Time,
+		open func(models.Statement) error, row func(models.Transaction) error) (models.Statement, error)
+	CreateWallet(ctx context.Context, id uuid.UUID, currency string, meta models.WalletMetadata) (models.Wallet, error)
+	GetWallet(ctx context.Context, walletID string) (models.Wallet, error)
+	SetWalletMetadata(ctx context.Context, walletID string, meta models.WalletMetadata) (models.Wallet, error)
+	ListWallets(ctx context.Context, q models.WalletListQuery) ([]models.Wallet, error)
+	SetStatus(ctx context.Context, walletID string, status models.WalletStatus) (models.Wallet, error)
+	SetCreditLimit(ctx context.Context, walletID string, limit int64) (models.Wallet, error)
+	GetWalletLimits(ctx context.Context, walletID string) (models.WalletLimits, error)
+	SetWalletLimits(ctx context.Context, limits models.WalletLimits) (models.WalletLimits, error)
+	GetWithdrawalUsage(ctx context.Context, walletID string) (models.WithdrawalUsage, error)
+	CreateHold(ctx context.Context, walletID string, amount int64, currency string, expiresAt time.Time) (models.Hold, error)
+	CaptureHold(ctx context.Context, walletID, holdID string, amount int64) (models.Hold, error)
+	VoidHold(ctx context.Context, walletID, holdID string) (models.Hold, error)
+	ExpireHolds(ctx context.Context, limit int) (int, error)
+	ReverseOperation(ctx context.Context, operationID string, amount int64) (models.Transaction, error)
+	GetExchangeRates(ctx context.Context) ([]models.ExchangeRate, error)
+	GetExchangeRate(ctx context.Context, from, to string) (models.ExchangeRate, error)
+	SetExchangeRates(ctx context.Context, rates []models.ExchangeRate) ([]models.ExchangeRate, error)
+	CreateQuote(ctx context.Context, quote models.FXQuote) (models.FXQuote, error)
+	ExecuteQuote(ctx context.Context, quoteID string) (models.FXQuote, error)
+	CreateSchedule(ctx context.Context, s models.Schedule) (models.Schedule, error)
+	GetSchedule(ctx context.Context, scheduleID string) (models.Schedule, error)
+	ListSchedules(ctx context.Context, walletID string, limit, offset int) ([]models.Schedule, error)
+	SetScheduleStatus(ctx context.Context, scheduleID string, status models.ScheduleStatus) (models.Schedule, error)
+	RunDueSchedule(ctx context.Context, now time.Time, run func(context.Context, models.Operation) error) (models.Schedule, bool, error)
+	TakeBalanceSnapshots(ctx context.Context, upTo time.Time) (int64, error)
+	GetSystemAccountBalances(ctx context.Context) ([]models.SystemAccountBalance, error)
+	GetIdempotencyKey(ctx context.Context, key string) (*models.IdempotencyKey, error)
+	GetOperationFee(ctx context.Context, operationID uuid.UUID) (int64, error)
+	SaveIdempotentResponse(ctx context.Context, key, requestHash string, statusCode int, body []byte) (*models.IdempotencyKey, error)
 }
 
 type WalletService struct {
 	queue *queue.Queue
 	repo  walletRepo
+	fees  models.FeeSchedule
+	// quoteTTL is how long a conversion quote holds its rate
+	quoteTTL time.Duration
 }
 
 func NewWalletService(queue *queue.Queue, repo walletRepo) *WalletService {
 	return &WalletService{queue: queue, repo: repo}
 }
 
-func (s *WalletService) UpdateBalance(ctx context.Context, walletID string, operationType string, amount int64) error {
-	logger.Info(fmt.Sprintf("service UpdateBalance walletId=%s op=%s amount=%d", walletID, operationType, amount))
+// UpdateBalance applies a DEPOSIT or WITHDRAW through the queue and returns
+// the fees charged on it together with the operation.
+func (s *WalletService) UpdateBalance(ctx context.Context, op models.Operation) ([]models.FeeCharge, error) {
+	logger.Info(fmt.Sprintf("service UpdateBalance walletId=%s op=%s amount=%d", op.WalletID, op.Type, op.Amount))
 
-	resultChan := make(chan error)
+	if op.ID == uuid.Nil {
+		op.ID = uuid.New()
+	}
+	if err := validateCurrency(op.Currency); err != nil {
+		return nil, err
+	}
+	resultChan := make(chan error, 1)
 
-	switch operationType {
-	case "DEPOSIT":
-		s.queue.Add(ctx, "DEPOSIT", walletID, amount, resultChan)
-		return <-resultChan
-	case "WITHDRAW":
-		s.queue.Add(ctx, "WITHDRAW", walletID, amount, resultChan)
-		return <-resultChan
+	switch op.Type {
+	case "DEPOSIT", "WITHDRAW":
+		if err := s.checkLimits(ctx, op); err != nil {
+			return nil, err
+		}
+		fees, err := s.chargeFees(ctx, op)
+		if err != nil {
+			return nil, err
+		}
+		op.Fees = fees
+		if err := s.queue.Add(ctx, op, resultChan); err != nil {
+			return nil, err
+		}
+		if err := <-resultChan; err != nil {
+			return nil, err
+		}
+		return fees, nil
 	default:
-		return fmt.Errorf("unknown operation type: %s", operationType)
+		return nil, fmt.Errorf("unknown operation type: %s", op.Type)
+	}
+}
+
+// Transfer moves op.Amount from op.WalletID to op.ToWalletID through the queue
+// and returns the transfer ID. Either both balances change or neither does.
+func (s *WalletService) Transfer(ctx context.Context, op models.Operation) (uuid.UUID, error) {
+	logger.Info(fmt.Sprintf("service Transfer from=%s to=%s amount=%d", op.WalletID, op.ToWalletID, op.Amount))
+
+	if op.WalletID == op.ToWalletID {
+		return uuid.Nil, models.ErrSameWalletTransfer
 	}
+	if err := validateCurrency(op.Currency); err != nil {
+		return uuid.Nil, err
+	}
+	op.Type = "TRANSFER"
+	if op.ID == uuid.Nil {
+		op.ID = uuid.New()
+	}
+	if err := s.checkLimits(ctx, op); err != nil {
+		return uuid.Nil, err
+	}
+	resultChan := make(chan error, 1)
+	if err := s.queue.Add(ctx, op, resultChan); err != nil {
+		return uuid.Nil, err
+	}
+	if err := <-resultChan; err != nil {
+		return uuid.Nil, err
+	}
+	return op.ID, nil
 }
 
-func (s *WalletService) GetBalance(ctx context.Context, walletID string) (int64, error) {
+func (s *WalletService) GetBalance(ctx context.Context, walletID string) (models.Balance, error) {
 	logger.Info(fmt.Sprintf("service GetBalance walletId=%s", walletID))
 	return s.repo.GetBalance(ctx, walletID)
 }
+
+// GetBalanceAt returns the wallet balance as of at. Moments in the future
+// report the current balance.
+func (s *WalletService) GetBalanceAt(ctx context.Context, walletID string, at time.Time) (models.Balance, error) {
+	logger.Info(fmt.Sprintf("service GetBalanceAt walletId=%s at=%s", walletID, at.Format(time.RFC3339)))
+	if now := time.Now(); at.After(now) {
+		at = now
+	}
+	return s.repo.GetBalanceAt(ctx, walletID, at)
+}
+
+func (s *WalletService) GetTransactions(ctx context.Context, walletID string, limit, offset int) ([]models.Transaction, error) {
+	logger.Info(fmt.Sprintf("service GetTransactions walletId=%s limit=%d offset=%d", walletID, limit, offset))
+	return s.repo.GetTransactions(ctx, walletID, limit, offset)
+}
+
+// GetIdempotencyKey returns the stored outcome for key, or nil if the key has
+// not been seen.
+func (s *WalletService) GetIdempotencyKey(ctx context.Context, key string) (*models.IdempotencyKey, error) {
+	logger.Info(fmt.Sprintf("service GetIdempotencyKey key=%s", key))
+	return s.repo.GetIdempotencyKey(ctx, key)
+}
+
+// GetOperationFee returns the total fee charged on an applied operation.
+func (s *WalletService) GetOperationFee(ctx context.Context, operationID uuid.UUID) (int64, error) {
+	logger.Info(fmt.Sprintf("service GetOperationFee operationId=%s", operationID))
+	return s.repo.GetOperationFee(ctx, operationID)
+}
+
+func (s *WalletService) SaveIdempotentResponse(ctx context.Context, key, requestHash string, statusCode int, body []byte) (*models.IdempotencyKey, error) {
+	logger.Info(fmt.Sprintf("service SaveIdempotentResponse key=%s status=%d", key, statusCode))
+	return s.repo.SaveIdempotentResponse(ctx, key, requestHash, statusCode, body)
+}
+
+// validateCurrency accepts an empty currency, meaning "whatever the wallet
+// holds", or a known ISO 4217 code.
+func validateCurrency(currency string) error {
+	if currency == "" {
+		return nil
+	}
+	if _, ok := models.CurrencyExponent(currency); !ok {
+		return fmt.Errorf("%w: %s", models.ErrUnknownCurrency, currency)
+	}
+	return nil
+}
@@ -3,29 +3,312 @@ package service
 import (
 	"context"
 	"errors"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
+
+	"test-psql/internal/models"
 	"test-psql/internal/queue"
 )
 
 type stubWalletRepo struct {
 	getBalanceVal int64
 	getBalanceErr error
+	gotAt         time.Time
 	depositErr    error
 	withdrawErr   error
+	txs           []models.Transaction
+	txsErr        error
+
+	transferErr error
+	wallet      models.Wallet
+	walletErr   error
+	gotStatus   models.WalletStatus
+	gotLimit    int64
+	wallets     []models.Wallet
+	gotQuery    models.WalletListQuery
+
+	limits     models.WalletLimits
+	usage      models.WithdrawalUsage
+	usageCalls int
+	gotLimits  models.WalletLimits
+
+	hold          models.Hold
+	holdErr       error
+	gotExpiresAt  time.Time
+	gotAmount     int64
+	expireResults []int
+	expireCalls   int
+
+	systemAccounts []models.SystemAccountBalance
+
+	batchErr error
+	batchOps [][]models.Operation
+
+	reversal    models.Transaction
+	reversalErr error
+
+	walletsByID map[string]models.Wallet
+	rate        models.ExchangeRate
+	rateErr     error
+	gotQuote    models.FXQuote
+
+	schedule     models.Schedule
+	gotSchedule  models.Schedule
+	gotStatusSet models.ScheduleStatus
+	dueRuns      []models.Schedule
+	gotNow       []time.Time
+	// runRelease, if set, holds every schedule run until it is closed
+	runRelease chan struct{}
+	running    int
+	gotUpTo    time.Time
+
+	mu sync.Mutex
+	// funds, if set, is the balance Deposit adds to and Withdraw pays out of
+	funds         *int64
+	depositOps    []models.Operation
+	depositGroups [][]models.Operation
+	withdrawOps   []models.Operation
+	transferOps   []models.Operation
+}
+
+func (s *stubWalletRepo) GetBalance(ctx context.Context, walletID string) (models.Balance, error) {
+	return models.Balance{Balance: s.getBalanceVal, Currency: "RUB", CurrencyExponent: 2}, s.getBalanceErr
+}
+
+func (s *stubWalletRepo) GetBalanceAt(ctx context.Context, walletID string, at time.Time) (models.Balance, error) {
+	s.gotAt = at
+	return models.Balance{Balance: s.getBalanceVal, Currency: "RUB", CurrencyExponent: 2}, s.getBalanceErr
+}
+
+func (s *stubWalletRepo) Deposit(ctx context.Context, walletID string, ops []models.Operation) ([]models.Transaction, error) {
+	s.mu.Lock()
+	s.depositOps = append(s.depositOps, ops...)
+	s.depositGroups = append(s.depositGroups, ops)
+	if s.funds != nil {
+		for _, op := range ops {
+			*s.funds += op.Amount
+		}
+	}
+	s.mu.Unlock()
+	return nil, s.depositErr
+}
+
+func (s *stubWalletRepo) Withdraw(ctx context.Context, walletID string, ops []models.Operation) ([]models.Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.withdrawOps = append(s.withdrawOps, ops...)
+	if s.funds != nil {
+		var total int64
+		for _, op := range ops {
+			total += op.Amount
+		}
+		if total > *s.funds {
+			return nil, models.ErrInsufficientBalance
+		}
+		*s.funds -= total
+	}
+	return nil, s.withdrawErr
+}
+
+func (s *stubWalletRepo) WithdrawEach(ctx context.Context, walletID string, ops []models.Operation) ([]error, error) {
+	errs := make([]error, len(ops))
+	for i := range errs {
+		errs[i] = s.withdrawErr
+	}
+	return errs, nil
+}
+
+func (s *stubWalletRepo) Transfer(ctx context.Context, op models.Operation) ([]models.Transaction, error) {
+	s.mu.Lock()
+	s.transferOps = append(s.transferOps, op)
+	s.mu.Unlock()
+	return nil, s.transferErr
+}
+
+func (s *stubWalletRepo) ApplyBatch(ctx context.Context, ops []models.Operation) ([]models.Transaction, error) {
+	s.mu.Lock()
+	s.batchOps = append(s.batchOps, ops)
+	s.mu.Unlock()
+	return nil, s.batchErr
+}
+
+// ApplyGroups applies nothing, so the queue falls back to Deposit and Withdraw.
+func (s *stubWalletRepo) ApplyGroups(ctx context.Context, groups []models.OpGroup) ([]models.GroupResult, error) {
+	return make([]models.GroupResult, len(groups)), nil
+}
+
+func (s *stubWalletRepo) GetTransactions(ctx context.Context, walletID string, limit, offset int) ([]models.Transaction, error) {
+	return s.txs, s.txsErr
+}
+
+func (s *stubWalletRepo) StreamStatement(ctx context.Context, walletID string, from, to time.Time,
+	open func(models.Statement) error, row func(models.Transaction) error) (models.Statement, error) {
+	return models.Statement{}, s.txsErr
+}
+
+func (s *stubWalletRepo) CreateWallet(ctx context.Context, id uuid.UUID, currency string, meta models.WalletMetadata) (models.Wallet, error) {
+	if s.walletErr != nil {
+		return models.Wallet{}, s.walletErr
+	}
+	return models.Wallet{ID: id, Status: models.WalletStatusActive, Currency: currency, WalletMetadata: meta}, nil
+}
+
+func (s *stubWalletRepo) SetWalletMetadata(ctx context.Context, walletID string, meta models.WalletMetadata) (models.Wallet, error) {
+	return s.wallet, s.walletErr
+}
+
+func (s *stubWalletRepo) ListWallets(ctx context.Context, q models.WalletListQuery) ([]models.Wallet, error) {
+	s.gotQuery = q
+	return s.wallets, s.walletErr
+}
+
+func (s *stubWalletRepo) GetWallet(ctx context.Context, walletID string) (models.Wallet, error) {
+	if w, ok := s.walletsByID[walletID]; ok {
+		return w, nil
+	}
+	return s.wallet, s.walletErr
+}
+
+func (s *stubWalletRepo) SetStatus(ctx context.Context, walletID string, status models.WalletStatus) (models.Wallet, error) {
+	s.gotStatus = status
+	return s.wallet, s.walletErr
+}
+
+func (s *stubWalletRepo) SetCreditLimit(ctx context.Context, walletID string, limit int64) (models.Wallet, error) {
+	s.gotLimit = limit
+	return s.wallet, s.walletErr
+}
+
+func (s *stubWalletRepo) GetWalletLimits(ctx context.Context, walletID string) (models.WalletLimits, error) {
+	return s.limits, nil
 }
 
-func (s *stubWalletRepo) GetBalance(ctx context.Context, walletID string) (int64, error) {
-	return s.getBalanceVal, s.getBalanceErr
+func (s *stubWalletRepo) SetWalletLimits(ctx context.Context, limits models.WalletLimits) (models.WalletLimits, error) {
+	s.gotLimits = limits
+	return limits, s.walletErr
 }
 
-func (s *stubWalletRepo) Deposit(ctx context.Context, walletID string, amount int64) error {
-	return s.depositErr
+func (s *stubWalletRepo) GetWithdrawalUsage(ctx context.Context, walletID string) (models.WithdrawalUsage, error) {
+	s.usageCalls++
+	return s.usage, nil
 }
 
-func (s *stubWalletRepo) Withdraw(ctx context.Context, walletID string, amount int64) error {
-	return s.withdrawErr
+func (s *stubWalletRepo) CreateHold(ctx context.Context, walletID string, amount int64, currency string, expiresAt time.Time) (models.Hold, error) {
+	s.gotAmount, s.gotExpiresAt = amount, expiresAt
+	return s.hold, s.holdErr
+}
+
+func (s *stubWalletRepo) CaptureHold(ctx context.Context, walletID, holdID string, amount int64) (models.Hold, error) {
+	s.gotAmount = amount
+	return s.hold, s.holdErr
+}
+
+func (s *stubWalletRepo) VoidHold(ctx context.Context, walletID, holdID string) (models.Hold, error) {
+	return s.hold, s.holdErr
+}
+
+func (s *stubWalletRepo) ExpireHolds(ctx context.Context, limit int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expireCalls++
+	if len(s.expireResults) == 0 {
+		return 0, nil
+	}
+	n := s.expireResults[0]
+	s.expireResults = s.expireResults[1:]
+	return n, nil
+}
+
+func (s *stubWalletRepo) ReverseOperation(ctx context.Context, operationID string, amount int64) (models.Transaction, error) {
+	s.gotAmount = amount
+	return s.reversal, s.reversalErr
+}
+
+func (s *stubWalletRepo) GetExchangeRates(ctx context.Context) ([]models.ExchangeRate, error) {
+	return []models.ExchangeRate{s.rate}, nil
+}
+
+func (s *stubWalletRepo) GetExchangeRate(ctx context.Context, from, to string) (models.ExchangeRate, error) {
+	return s.rate, s.rateErr
+}
+
+func (s *stubWalletRepo) SetExchangeRates(ctx context.Context, rates []models.ExchangeRate) ([]models.ExchangeRate, error) {
+	return rates, nil
+}
+
+func (s *stubWalletRepo) CreateQuote(ctx context.Context, quote models.FXQuote) (models.FXQuote, error) {
+	s.gotQuote = quote
+	return quote, nil
+}
+
+func (s *stubWalletRepo) ExecuteQuote(ctx context.Context, quoteID string) (models.FXQuote, error) {
+	return s.gotQuote, nil
+}
+
+func (s *stubWalletRepo) CreateSchedule(ctx context.Context, sched models.Schedule) (models.Schedule, error) {
+	s.gotSchedule = sched
+	return sched, nil
+}
+
+func (s *stubWalletRepo) GetSchedule(ctx context.Context, scheduleID string) (models.Schedule, error) {
+	return s.schedule, nil
+}
+
+func (s *stubWalletRepo) ListSchedules(ctx context.Context, walletID string, limit, offset int) ([]models.Schedule, error) {
+	return []models.Schedule{s.schedule}, nil
+}
+
+func (s *stubWalletRepo) SetScheduleStatus(ctx context.Context, scheduleID string, status models.ScheduleStatus) (models.Schedule, error) {
+	s.gotStatusSet = status
+	return s.schedule, nil
+}
+
+func (s *stubWalletRepo) TakeBalanceSnapshots(ctx context.Context, upTo time.Time) (int64, error) {
+	s.gotUpTo = upTo
+	return 0, nil
+}
+
+// RunDueSchedule hands out dueRuns one per call and runs the operation of
+// those that have one.
+func (s *stubWalletRepo) RunDueSchedule(ctx context.Context, now time.Time, run func(context.Context, models.Operation) error) (models.Schedule, bool, error) {
+	s.mu.Lock()
+	s.gotNow = append(s.gotNow, now)
+	if len(s.dueRuns) == 0 {
+		s.mu.Unlock()
+		return models.Schedule{}, false, nil
+	}
+	sched := s.dueRuns[0]
+	s.dueRuns = s.dueRuns[1:]
+	s.running++
+	s.mu.Unlock()
+	if s.runRelease != nil {
+		<-s.runRelease
+	}
+	if sched.OperationType != "" {
+		if err := run(ctx, sched.Operation()); err != nil {
+			sched.LastError = err.Error()
+		}
+	}
+	return sched, true, nil
+}
+
+func (s *stubWalletRepo) GetSystemAccountBalances(ctx context.Context) ([]models.SystemAccountBalance, error) {
+	return s.systemAccounts, nil
+}
+
+func (s *stubWalletRepo) GetIdempotencyKey(ctx context.Context, key string) (*models.IdempotencyKey, error) {
+	return nil, nil
+}
+
+func (s *stubWalletRepo) GetOperationFee(ctx context.Context, operationID uuid.UUID) (int64, error) {
+	return 0, nil
+}
+
+func (s *stubWalletRepo) SaveIdempotentResponse(ctx context.Context, key, requestHash string, statusCode int, body []byte) (*models.IdempotencyKey, error) {
+	return &models.IdempotencyKey{Key: key, RequestHash: requestHash, StatusCode: statusCode, ResponseBody: body}, nil
 }
 
 func TestWalletService_UpdateBalance(t *testing.T) {
@@ -34,7 +317,7 @@ func TestWalletService_UpdateBalance(t *testing.T) {
 		q := queue.NewQueue(repo, 50, 100*time.Millisecond)
 		go q.ProcessQueue(context.Background())
 		svc := NewWalletService(q, repo)
-		err := svc.UpdateBalance(context.Background(), "id1", "DEPOSIT", 100)
+		_, err := svc.UpdateBalance(context.Background(), models.Operation{WalletID: "id1", Type: "DEPOSIT", Amount: 100})
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
@@ -45,7 +328,7 @@ func TestWalletService_UpdateBalance(t *testing.T) {
 		q := queue.NewQueue(repo, 50, 100*time.Millisecond)
 		go q.ProcessQueue(context.Background())
 		svc := NewWalletService(q, repo)
-		err := svc.UpdateBalance(context.Background(), "id1", "DEPOSIT", 100)
+		_, err := svc.UpdateBalance(context.Background(), models.Operation{WalletID: "id1", Type: "DEPOSIT", Amount: 100})
 		if err == nil || err.Error() != "db error" {
 			t.Errorf("want db error, got %v", err)
 		}
@@ -56,7 +339,7 @@ func TestWalletService_UpdateBalance(t *testing.T) {
 		q := queue.NewQueue(repo, 50, 100*time.Millisecond)
 		go q.ProcessQueue(context.Background())
 		svc := NewWalletService(q, repo)
-		err := svc.UpdateBalance(context.Background(), "id1", "WITHDRAW", 50)
+		_, err := svc.UpdateBalance(context.Background(), models.Operation{WalletID: "id1", Type: "WITHDRAW", Amount: 50})
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
@@ -67,7 +350,7 @@ func TestWalletService_UpdateBalance(t *testing.T) {
 		q := queue.NewQueue(repo, 50, 100*time.Millisecond)
 		go q.ProcessQueue(context.Background())
 		svc := NewWalletService(q, repo)
-		err := svc.UpdateBalance(context.Background(), "id1", "WITHDRAW", 50)
+		_, err := svc.UpdateBalance(context.Background(), models.Operation{WalletID: "id1", Type: "WITHDRAW", Amount: 50})
 		if err == nil || err.Error() != "insufficient balance" {
 			t.Errorf("want insufficient balance, got %v", err)
 		}
@@ -78,7 +361,7 @@ func TestWalletService_UpdateBalance(t *testing.T) {
 		q := queue.NewQueue(repo, 50, 100*time.Millisecond)
 		go q.ProcessQueue(context.Background())
 		svc := NewWalletService(q, repo)
-		err := svc.UpdateBalance(context.Background(), "id1", "UNKNOWN", 10)
+		_, err := svc.UpdateBalance(context.Background(), models.Operation{WalletID: "id1", Type: "UNKNOWN", Amount: 10})
 		if err == nil {
 			t.Fatal("expected error for unknown operation")
 		}
@@ -88,6 +371,161 @@ func TestWalletService_UpdateBalance(t *testing.T) {
 	})
 }
 
+func TestWalletService_UpdateBalance_Currency(t *testing.T) {
+	t.Run("unknown currency", func(t *testing.T) {
+		repo := &stubWalletRepo{}
+		q := queue.NewQueue(repo, 50, 100*time.Millisecond)
+		svc := NewWalletService(q, repo)
+		_, err := svc.UpdateBalance(context.Background(), models.Operation{WalletID: "id1", Type: "DEPOSIT", Amount: 100, Currency: "XXX"})
+		if !errors.Is(err, models.ErrUnknownCurrency) {
+			t.Errorf("want ErrUnknownCurrency, got %v", err)
+		}
+	})
+
+	t.Run("batch never mixes currencies", func(t *testing.T) {
+		repo := &stubWalletRepo{}
+		q := queue.NewQueue(repo, 3, time.Hour)
+		go q.ProcessQueue(context.Background())
+		svc := NewWalletService(q, repo)
+
+		var wg sync.WaitGroup
+		for _, currency := range []string{"RUB", "USD", "RUB"} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				op := models.Operation{WalletID: "id1", Type: "DEPOSIT", Amount: 100, Currency: currency}
+				if _, err := svc.UpdateBalance(context.Background(), op); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		// Операции одной валюты объединяются, только если пришли подряд,
+		// поэтому групп две или три в зависимости от порядка поступления
+		if n := len(repo.depositGroups); n < 2 || n > 3 {
+			t.Fatalf("got %d deposit groups, want 2 or 3", n)
+		}
+		applied := 0
+		for _, group := range repo.depositGroups {
+			applied += len(group)
+			for _, op := range group {
+				if op.Currency != group[0].Currency {
+					t.Errorf("group mixes %s and %s", group[0].Currency, op.Currency)
+				}
+			}
+		}
+		if applied != 3 {
+			t.Errorf("got %d deposits applied, want 3", applied)
+		}
+	})
+}
+
+func TestWalletService_UpdateBalance_Idempotency(t *testing.T) {
+	t.Run("duplicates in one batch are applied once", func(t *testing.T) {
+		repo := &stubWalletRepo{}
+		q := queue.NewQueue(repo, 3, time.Hour)
+		go q.ProcessQueue(context.Background())
+		svc := NewWalletService(q, repo)
+
+		op := models.Operation{WalletID: "id1", Type: "DEPOSIT", Amount: 100, IdempotencyKey: "key-1", RequestHash: "hash"}
+		var wg sync.WaitGroup
+		errs := make([]error, 3)
+		for i := range errs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, errs[i] = svc.UpdateBalance(context.Background(), op)
+			}()
+		}
+		wg.Wait()
+
+		for i, err := range errs {
+			if err != nil {
+				t.Errorf("request %d: unexpected error: %v", i, err)
+			}
+		}
+		if len(repo.depositOps) != 1 {
+			t.Errorf("got %d deposited ops, want 1", len(repo.depositOps))
+		}
+	})
+
+	t.Run("same key with different request", func(t *testing.T) {
+		repo := &stubWalletRepo{}
+		q := queue.NewQueue(repo, 2, time.Hour)
+		go q.ProcessQueue(context.Background())
+		svc := NewWalletService(q, repo)
+
+		first := models.Operation{WalletID: "id1", Type: "DEPOSIT", Amount: 100, IdempotencyKey: "key-1", RequestHash: "hash-1"}
+		second := first
+		second.Amount, second.RequestHash = 200, "hash-2"
+
+		var wg sync.WaitGroup
+		var firstErr, secondErr error
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, firstErr = svc.UpdateBalance(context.Background(), first)
+		}()
+		go func() {
+			defer wg.Done()
+			_, secondErr = svc.UpdateBalance(context.Background(), second)
+		}()
+		wg.Wait()
+
+		if (firstErr == nil) == (secondErr == nil) {
+			t.Fatalf("want exactly one request rejected, got %v and %v", firstErr, secondErr)
+		}
+		if err := errors.Join(firstErr, secondErr); !errors.Is(err, models.ErrIdempotencyKeyReused) {
+			t.Errorf("want ErrIdempotencyKeyReused, got %v", err)
+		}
+		if len(repo.depositOps) != 1 {
+			t.Errorf("got %d deposited ops, want 1", len(repo.depositOps))
+		}
+	})
+}
+
+func TestWalletService_Transfer(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		repo := &stubWalletRepo{}
+		q := queue.NewQueue(repo, 50, 100*time.Millisecond)
+		go q.ProcessQueue(context.Background())
+		svc := NewWalletService(q, repo)
+		id, err := svc.Transfer(context.Background(), models.Operation{WalletID: "id1", ToWalletID: "id2", Amount: 100})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(repo.transferOps) != 1 {
+			t.Fatalf("got %d transfers, want 1", len(repo.transferOps))
+		}
+		got := repo.transferOps[0]
+		if got.ID != id || got.WalletID != "id1" || got.ToWalletID != "id2" || got.Amount != 100 {
+			t.Errorf("unexpected transfer op: %+v", got)
+		}
+	})
+
+	t.Run("insufficient balance", func(t *testing.T) {
+		repo := &stubWalletRepo{transferErr: models.ErrInsufficientBalance}
+		q := queue.NewQueue(repo, 50, 100*time.Millisecond)
+		go q.ProcessQueue(context.Background())
+		svc := NewWalletService(q, repo)
+		_, err := svc.Transfer(context.Background(), models.Operation{WalletID: "id1", ToWalletID: "id2", Amount: 100})
+		if !errors.Is(err, models.ErrInsufficientBalance) {
+			t.Errorf("want insufficient balance, got %v", err)
+		}
+	})
+
+	t.Run("same wallet", func(t *testing.T) {
+		repo := &stubWalletRepo{}
+		q := queue.NewQueue(repo, 50, 100*time.Millisecond)
+		svc := NewWalletService(q, repo)
+		_, err := svc.Transfer(context.Background(), models.Operation{WalletID: "id1", ToWalletID: "id1", Amount: 100})
+		if !errors.Is(err, models.ErrSameWalletTransfer) {
+			t.Errorf("want same wallet error, got %v", err)
+		}
+	})
+}
+
 func TestWalletService_GetBalance(t *testing.T) {
 	t.Run("ok", func(t *testing.T) {
 		repo := &stubWalletRepo{getBalanceVal: 999}
@@ -98,8 +536,8 @@ func TestWalletService_GetBalance(t *testing.T) {
 		if err != nil {
 			t.Fatal(err)
 		}
-		if balance != 999 {
-			t.Errorf("got balance %d, want 999", balance)
+		if balance.Balance != 999 {
+			t.Errorf("got balance %d, want 999", balance.Balance)
 		}
 	})
 
@@ -114,3 +552,66 @@ func TestWalletService_GetBalance(t *testing.T) {
 		}
 	})
 }
+
+func TestWalletService_GetBalanceAt(t *testing.T) {
+	t.Run("past", func(t *testing.T) {
+		repo := &stubWalletRepo{getBalanceVal: 42}
+		svc := NewWalletService(queue.NewQueue(repo, 50, 100*time.Millisecond), repo)
+		at := time.Now().Add(-time.Hour)
+		balance, err := svc.GetBalanceAt(context.Background(), "id1", at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if balance.Balance != 42 || !repo.gotAt.Equal(at) {
+			t.Errorf("got balance %d at %s", balance.Balance, repo.gotAt)
+		}
+	})
+
+	t.Run("future is clamped to now", func(t *testing.T) {
+		repo := &stubWalletRepo{}
+		svc := NewWalletService(queue.NewQueue(repo, 50, 100*time.Millisecond), repo)
+		if _, err := svc.GetBalanceAt(context.Background(), "id1", time.Now().Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		if repo.gotAt.After(time.Now()) {
+			t.Errorf("repo asked for future moment %s", repo.gotAt)
+		}
+	})
+}
+
+func TestWalletService_TakeBalanceSnapshots(t *testing.T) {
+	repo := &stubWalletRepo{}
+	svc := NewWalletService(queue.NewQueue(repo, 50, 100*time.Millisecond), repo)
+
+	svc.takeBalanceSnapshots(context.Background())
+
+	// Снимок отстаёт от текущего момента, чтобы не захватить незавершённые операции
+	if lag := time.Since(repo.gotUpTo); lag < balanceSnapshotLag {
+		t.Errorf("snapshot taken %s ago, want at least %s", lag, balanceSnapshotLag)
+	}
+}
+
+func TestWalletService_GetTransactions(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		repo := &stubWalletRepo{txs: []models.Transaction{{Type: "DEPOSIT", Amount: 100, BalanceAfter: 100}}}
+		q := queue.NewQueue(repo, 50, 100*time.Millisecond)
+		svc := NewWalletService(q, repo)
+		txs, err := svc.GetTransactions(context.Background(), "id1", 10, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(txs) != 1 || txs[0].BalanceAfter != 100 {
+			t.Errorf("unexpected transactions: %+v", txs)
+		}
+	})
+
+	t.Run("repo error", func(t *testing.T) {
+		repo := &stubWalletRepo{txsErr: errors.New("db error")}
+		q := queue.NewQueue(repo, 50, 100*time.Millisecond)
+		svc := NewWalletService(q, repo)
+		_, err := svc.GetTransactions(context.Background(), "id1", 10, 0)
+		if err == nil || err.Error() != "db error" {
+			t.Errorf("want db error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_wallet_transactions_transfer;
+
+ALTER TABLE wallet_transactions
+    DROP COLUMN IF EXISTS counterparty_wallet_id,
+    DROP COLUMN IF EXISTS transfer_id;
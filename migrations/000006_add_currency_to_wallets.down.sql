@@ -0,0 +1,3 @@
+ALTER TABLE wallets
+    DROP COLUMN IF EXISTS currency_exponent,
+    DROP COLUMN IF EXISTS currency;
@@ -0,0 +1,4 @@
+ALTER TABLE wallets
+    ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'RUB',
+    ADD COLUMN IF NOT EXISTS currency_exponent SMALLINT NOT NULL DEFAULT 2
+    CHECK (currency_exponent BETWEEN 0 AND 4);
@@ -0,0 +1,5 @@
+ALTER TABLE wallet_transactions DROP COLUMN IF EXISTS hold_id;
+
+DROP TABLE IF EXISTS wallet_holds;
+
+ALTER TABLE wallets DROP COLUMN IF EXISTS held;
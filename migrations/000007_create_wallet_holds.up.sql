@@ -0,0 +1,22 @@
+ALTER TABLE wallets
+    ADD COLUMN IF NOT EXISTS held BIGINT NOT NULL DEFAULT 0
+    CHECK (held >= 0);
+
+CREATE TABLE IF NOT EXISTS wallet_holds (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    wallet_id UUID NOT NULL REFERENCES wallets (id),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    captured_amount BIGINT NOT NULL DEFAULT 0,
+    status VARCHAR(16) NOT NULL DEFAULT 'ACTIVE'
+        CHECK (status IN ('ACTIVE', 'CAPTURED', 'VOIDED', 'EXPIRED')),
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_wallet_holds_active_expires
+    ON wallet_holds (expires_at)
+    WHERE status = 'ACTIVE';
+
+ALTER TABLE wallet_transactions
+    ADD COLUMN IF NOT EXISTS hold_id UUID REFERENCES wallet_holds (id);
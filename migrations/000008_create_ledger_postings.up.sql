@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS ledger_postings (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    seq BIGSERIAL NOT NULL,
+    entry_id UUID NOT NULL,
+    wallet_id UUID REFERENCES wallets (id),
+    system_account VARCHAR(32),
+    currency CHAR(3) NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount <> 0),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((wallet_id IS NULL) <> (system_account IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_postings_entry
+    ON ledger_postings (entry_id);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_postings_system_account
+    ON ledger_postings (system_account, currency)
+    WHERE system_account IS NOT NULL;
+
+-- Существующие балансы заводятся как начальные проводки из external_cash,
+-- чтобы сумма проводок кошелька совпадала с его балансом
+WITH opening AS (
+    SELECT uuid_generate_v4() AS entry_id, id, currency, balance
+    FROM wallets
+    WHERE balance <> 0
+)
+INSERT INTO ledger_postings (entry_id, wallet_id, system_account, currency, amount)
+SELECT entry_id, id, NULL, currency, balance FROM opening
+UNION ALL
+SELECT entry_id, NULL, 'external_cash', currency, -balance FROM opening;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_ledger_postings_wallet;
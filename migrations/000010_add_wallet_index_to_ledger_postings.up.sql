@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS idx_ledger_postings_wallet
+    ON ledger_postings (wallet_id)
+    WHERE wallet_id IS NOT NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE wallets
+    ADD COLUMN IF NOT EXISTS credit_limit BIGINT NOT NULL DEFAULT 0
+    CHECK (credit_limit >= 0);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS wallet_limits (
+    wallet_id UUID PRIMARY KEY REFERENCES wallets (id),
+    max_withdrawal_amount_24h BIGINT CHECK (max_withdrawal_amount_24h >= 0),
+    max_withdrawals_per_hour BIGINT CHECK (max_withdrawals_per_hour >= 0),
+    max_balance BIGINT CHECK (max_balance >= 0),
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
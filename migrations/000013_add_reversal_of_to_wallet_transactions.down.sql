@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_wallet_transactions_reversal_of;
+
+ALTER TABLE wallet_transactions DROP COLUMN IF EXISTS reversal_of;
@@ -0,0 +1,6 @@
+ALTER TABLE wallet_transactions
+    ADD COLUMN IF NOT EXISTS reversal_of UUID REFERENCES wallet_transactions (id);
+
+CREATE INDEX IF NOT EXISTS idx_wallet_transactions_reversal_of
+    ON wallet_transactions (reversal_of)
+    WHERE reversal_of IS NOT NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE wallet_transactions
+    ADD COLUMN IF NOT EXISTS fee_for UUID REFERENCES wallet_transactions (id);
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_wallets_labels;
+DROP INDEX IF EXISTS idx_wallets_owner_balance;
+DROP INDEX IF EXISTS idx_wallets_owner_created;
+DROP INDEX IF EXISTS idx_wallets_balance;
+DROP INDEX IF EXISTS idx_wallets_created;
+
+ALTER TABLE wallets
+    DROP COLUMN IF EXISTS labels,
+    DROP COLUMN IF EXISTS name,
+    DROP COLUMN IF EXISTS owner_id;
@@ -0,0 +1,17 @@
+ALTER TABLE wallets
+    ADD COLUMN IF NOT EXISTS owner_id VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS name VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS labels JSONB NOT NULL DEFAULT '[]';
+
+-- Ключи курсора списка: (created_at, id) и (balance, id), в том числе
+-- внутри одного владельца
+CREATE INDEX IF NOT EXISTS idx_wallets_created
+    ON wallets (created_at, id);
+CREATE INDEX IF NOT EXISTS idx_wallets_balance
+    ON wallets (balance, id);
+CREATE INDEX IF NOT EXISTS idx_wallets_owner_created
+    ON wallets (owner_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_wallets_owner_balance
+    ON wallets (owner_id, balance, id);
+CREATE INDEX IF NOT EXISTS idx_wallets_labels
+    ON wallets USING GIN (labels jsonb_path_ops);
@@ -0,0 +1,3 @@
+ALTER TABLE wallet_transactions DROP COLUMN IF EXISTS fx_rate;
+DROP TABLE IF EXISTS fx_quotes;
+DROP TABLE IF EXISTS exchange_rates;
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    from_currency VARCHAR(3) NOT NULL,
+    to_currency VARCHAR(3) NOT NULL,
+    rate NUMERIC(24, 12) NOT NULL CHECK (rate > 0),
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (from_currency, to_currency),
+    CHECK (from_currency <> to_currency)
+);
+
+CREATE TABLE IF NOT EXISTS fx_quotes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    from_wallet_id UUID NOT NULL REFERENCES wallets (id),
+    to_wallet_id UUID NOT NULL REFERENCES wallets (id),
+    from_currency VARCHAR(3) NOT NULL,
+    to_currency VARCHAR(3) NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    converted_amount BIGINT NOT NULL CHECK (converted_amount > 0),
+    rate NUMERIC(24, 12) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    executed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE wallet_transactions
+    ADD COLUMN IF NOT EXISTS fx_rate NUMERIC(24, 12);
@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS schedules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    wallet_id UUID NOT NULL REFERENCES wallets (id),
+    to_wallet_id UUID REFERENCES wallets (id),
+    operation_type VARCHAR(16) NOT NULL
+        CHECK (operation_type IN ('DEPOSIT', 'TRANSFER')),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL DEFAULT '',
+    cron VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL DEFAULT 'ACTIVE'
+        CHECK (status IN ('ACTIVE', 'PAUSED', 'CANCELLED', 'COMPLETED', 'FAILED')),
+    next_run_at TIMESTAMP,
+    run_count BIGINT NOT NULL DEFAULT 0,
+    last_run_at TIMESTAMP,
+    last_operation_id UUID,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((operation_type = 'TRANSFER') = (to_wallet_id IS NOT NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_schedules_due
+    ON schedules (next_run_at)
+    WHERE status = 'ACTIVE';
+
+CREATE INDEX IF NOT EXISTS idx_schedules_wallet
+    ON schedules (wallet_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_schedules_to_wallet
+    ON schedules (to_wallet_id, created_at DESC)
+    WHERE to_wallet_id IS NOT NULL;
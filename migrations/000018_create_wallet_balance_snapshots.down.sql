@@ -0,0 +1,9 @@
+ALTER TABLE ledger_postings
+    ALTER COLUMN created_at SET DEFAULT CURRENT_TIMESTAMP;
+
+ALTER TABLE wallet_transactions
+    ALTER COLUMN created_at SET DEFAULT CURRENT_TIMESTAMP;
+
+DROP INDEX IF EXISTS idx_ledger_postings_created;
+DROP INDEX IF EXISTS idx_ledger_postings_wallet_created;
+DROP TABLE IF EXISTS wallet_balance_snapshots;
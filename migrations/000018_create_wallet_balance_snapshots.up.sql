@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS wallet_balance_snapshots (
+    wallet_id UUID NOT NULL REFERENCES wallets (id),
+    taken_at TIMESTAMP NOT NULL,
+    balance BIGINT NOT NULL,
+    PRIMARY KEY (wallet_id, taken_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_wallet_balance_snapshots_taken
+    ON wallet_balance_snapshots (taken_at);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_postings_wallet_created
+    ON ledger_postings (wallet_id, created_at)
+    WHERE wallet_id IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_ledger_postings_created
+    ON ledger_postings (created_at);
+
+-- Время в журнале хранится в UTC независимо от часового пояса сессии
+ALTER TABLE wallet_transactions
+    ALTER COLUMN created_at SET DEFAULT (CURRENT_TIMESTAMP AT TIME ZONE 'UTC');
+
+ALTER TABLE ledger_postings
+    ALTER COLUMN created_at SET DEFAULT (CURRENT_TIMESTAMP AT TIME ZONE 'UTC');
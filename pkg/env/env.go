@@ -25,6 +25,14 @@ type Env struct {
 	RateLimitPeriod  time.Duration
 	QueueBuffSize    int
 	QueueFlushPeriod time.Duration
+	QueueEnqueueTimeout time.Duration
+	QueueWorkers     int
+	HoldExpiryPeriod time.Duration
+	SchedulerPeriod  time.Duration
+	SnapshotPeriod   time.Duration
+	FeeRulesFile     string
+	FXRatesFile      string
+	FXQuoteTTL       time.Duration
 }
 
 func LoadFromFile(path string) (*Env, error) {
@@ -98,6 +106,52 @@ func LoadFromFile(path string) (*Env, error) {
 	}
 	e.QueueFlushPeriod = flushPeriod
 
+	enqueueTimeoutStr := defaultString(getEnv("QUEUE_ENQUEUE_TIMEOUT"), "1s")
+	enqueueTimeout, err := time.ParseDuration(enqueueTimeoutStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid QUEUE_ENQUEUE_TIMEOUT: %w", err)
+	}
+	e.QueueEnqueueTimeout = enqueueTimeout
+
+	workersStr := defaultString(getEnv("QUEUE_WORKERS"), "10")
+	if err := parseInt(workersStr, &e.QueueWorkers); err != nil {
+		return nil, fmt.Errorf("invalid QUEUE_WORKERS: %w", err)
+	}
+
+	holdExpiryPeriodStr := defaultString(getEnv("HOLD_EXPIRY_PERIOD"), "1m")
+	holdExpiryPeriod, err := time.ParseDuration(holdExpiryPeriodStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid HOLD_EXPIRY_PERIOD: %w", err)
+	}
+	e.HoldExpiryPeriod = holdExpiryPeriod
+
+	schedulerPeriodStr := defaultString(getEnv("SCHEDULER_PERIOD"), "10s")
+	schedulerPeriod, err := time.ParseDuration(schedulerPeriodStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SCHEDULER_PERIOD: %w", err)
+	}
+	e.SchedulerPeriod = schedulerPeriod
+
+	snapshotPeriodStr := defaultString(getEnv("BALANCE_SNAPSHOT_PERIOD"), "1h")
+	snapshotPeriod, err := time.ParseDuration(snapshotPeriodStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid BALANCE_SNAPSHOT_PERIOD: %w", err)
+	}
+	e.SnapshotPeriod = snapshotPeriod
+
+	// Без файла правил комиссии не взимаются
+	e.FeeRulesFile = getEnv("FEE_RULES_FILE")
+
+	// Курсы из файла дополняют таблицу курсов при старте
+	e.FXRatesFile = getEnv("FX_RATES_FILE")
+
+	fxQuoteTTLStr := defaultString(getEnv("FX_QUOTE_TTL"), "30s")
+	fxQuoteTTL, err := time.ParseDuration(fxQuoteTTLStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid FX_QUOTE_TTL: %w", err)
+	}
+	e.FXQuoteTTL = fxQuoteTTL
+
 	if err := e.Validate(); err != nil {
 		return nil, err
 	}
@@ -137,6 +191,21 @@ func (e *Env) Validate() error {
 	if e.QueueBuffSize <= 0 {
 		return fmt.Errorf("QUEUE_BUFF_SIZE must be > 0")
 	}
+	if e.QueueWorkers <= 0 {
+		return fmt.Errorf("QUEUE_WORKERS must be > 0")
+	}
+	if e.HoldExpiryPeriod <= 0 {
+		return fmt.Errorf("HOLD_EXPIRY_PERIOD must be > 0")
+	}
+	if e.SchedulerPeriod <= 0 {
+		return fmt.Errorf("SCHEDULER_PERIOD must be > 0")
+	}
+	if e.SnapshotPeriod <= 0 {
+		return fmt.Errorf("BALANCE_SNAPSHOT_PERIOD must be > 0")
+	}
+	if e.FXQuoteTTL <= 0 {
+		return fmt.Errorf("FX_QUOTE_TTL must be > 0")
+	}
 
 	return nil
 }